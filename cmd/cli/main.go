@@ -1,16 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math/rand"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
+	"time"
 
 	"battery-backtest/internal/analysis"
 	"battery-backtest/internal/backtest"
+	"battery-backtest/internal/builder"
 	"battery-backtest/internal/config"
 	"battery-backtest/internal/data"
+	"battery-backtest/internal/data/synthetic"
 	"battery-backtest/internal/model"
 	"battery-backtest/internal/strategy"
 )
@@ -26,6 +36,12 @@ func main() {
 		cmdBacktest(os.Args[2:])
 	case "rank":
 		cmdRank(os.Args[2:])
+	case "train":
+		cmdTrain(os.Args[2:])
+	case "sync":
+		cmdSync(os.Args[2:])
+	case "generate":
+		cmdGenerate(os.Args[2:])
 	default:
 		usage()
 		os.Exit(2)
@@ -36,18 +52,39 @@ func usage() {
 	fmt.Println("usage:")
 	fmt.Println("  cli backtest --data sample_data.json --config examples/config.yaml --out results/dispatch.csv")
 	fmt.Println("  cli rank --data sample_data.json")
+	fmt.Println("  cli rank --dataset caiso_lmp_real_time_5_min --all --start 2024-06-01 --end 2024-07-01 --limit 25")
+	fmt.Println("  cli train --data sample_data.json --config examples/qlearn_config.yaml --train-end 2026-01-15 --out results/policy.json")
+	fmt.Println("  cli generate --start 2024-07-01 --days 14 --seed 1 --out results/synthetic.json")
+	fmt.Println("  cli sync --store data/store --dataset caiso_lmp_real_time_5_min --locations TH_NP15_GEN-APND --start 2024-01-01 --end 2024-07-01")
 	fmt.Println("")
 	fmt.Println("notes:")
 	fmt.Println("  - backtest outputs CSV with action=CHARGING/IDLE/DISCHARGING per interval")
 	fmt.Println("  - rank computes an 'arbitrage potential' oracle score per node")
+	fmt.Println("  - rank --dataset resumes from its --checkpoint file when interrupted")
+	fmt.Println("  - generate writes a seeded synthetic price series (no API key needed)")
+	fmt.Println("  - sync fetches days missing from the local price store (needs GRIDSTATUS_API_KEY)")
 }
 
 func cmdBacktest(args []string) {
 	fs := flag.NewFlagSet("backtest", flag.ExitOnError)
 	dataPath := fs.String("data", "sample_data.json", "Path to Grid Status JSON response")
+	dataset := fs.String("dataset", "", "Optional: query this dataset (with --location, --start, --end) instead of reading --data")
+	location := fs.String("location", "", "Location ID for --dataset")
+	start := fs.String("start", "", "Start date (YYYY-MM-DD) for --dataset")
+	end := fs.String("end", "", "End date (YYYY-MM-DD, exclusive) for --dataset")
+	storeDir := fs.String("store", os.Getenv("PRICE_STORE_DIR"), "Price store read before live queries for --dataset")
 	cfgPath := fs.String("config", "", "Path to YAML config")
 	outPath := fs.String("out", "results/dispatch.csv", "Output CSV path")
 	n := fs.Int("n", 0, "Optional: limit to first N intervals (0=all)")
+	tempPath := fs.String("temperature", "", "Optional: CSV of time,ambient_temp_c joined to the intervals")
+	emissionsPath := fs.String("emissions", "", "Optional: CSV of time,t_per_mwh marginal emissions rates joined to the intervals")
+	ghgPrice := fs.Float64("ghg-price", 0, "Optional: $/tCO2 to derive emissions rates from the LMP GHG component where no rate is joined")
+	repair := fs.String("repair", "none", "Data repair policy: none, drop, ffill, interpolate or unavailable")
+	outlierMADs := fs.Float64("outlier-mads", 0, "Outlier threshold in scaled MADs from the median LMP (0 = default 15, negative disables)")
+	repairOutliers := fs.Bool("repair-outliers", false, "Also drop, fill or mark outlier prices per --repair (default: report only)")
+	resample := fs.Duration("resample", 0, "Optional: resample intervals to this step (at least 1m), e.g. 1h or 15m")
+	resampleMethod := fs.String("resample-method", "time_weighted", "Aggregation when resampling: mean, time_weighted, min or max")
+	timezone := fs.String("timezone", "market", "Local time for days, months and schedules: market, UTC or an IANA zone (e.g. America/Los_Angeles)")
 	_ = fs.Parse(args)
 
 	if *cfgPath == "" {
@@ -55,7 +92,26 @@ func cmdBacktest(args []string) {
 		os.Exit(2)
 	}
 
-	resp, err := data.LoadGridStatusJSON(*dataPath)
+	if *resample != 0 && *resample < data.MinResampleStep {
+		fmt.Printf("--resample must be at least %s\n", data.MinResampleStep)
+		os.Exit(2)
+	}
+	loc, err := data.LoadTimezone(*timezone)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+	var resp *model.GridStatusLMPResponse
+	if *dataset != "" {
+		client := data.NewGridStatusClient(os.Getenv("GRIDSTATUS_API_KEY"), "")
+		client.Store = nil
+		if *storeDir != "" {
+			client.Store = data.NewStore(*storeDir)
+		}
+		resp, err = client.QueryLocationInZone(context.Background(), *dataset, *location, *start, *end, *timezone)
+	} else {
+		resp, err = data.LoadGridStatusJSON(*dataPath)
+	}
 	if err != nil {
 		panic(err)
 	}
@@ -63,6 +119,40 @@ func cmdBacktest(args []string) {
 	if *n > 0 && *n < len(intervals) {
 		intervals = intervals[:*n]
 	}
+	data.ApplyTimezone(intervals, loc)
+	policy, err := data.ParseRepairPolicy(*repair)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+	intervals, quality := data.Repair(intervals, policy, data.ValidateOptions{OutlierMADs: *outlierMADs, RepairOutliers: *repairOutliers})
+	printQualityReport(quality)
+	if *resample > 0 {
+		method, err := data.ParseResampleMethod(*resampleMethod)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		if intervals, err = data.Resample(intervals, *resample, method); err != nil {
+			panic(err)
+		}
+		fmt.Printf("Resampled to %d intervals of %s (%s)\n", len(intervals), *resample, method)
+	}
+	if *tempPath != "" {
+		temps, err := data.LoadSeriesCSV(*tempPath)
+		if err != nil {
+			panic(err)
+		}
+		data.JoinAmbientTemperature(intervals, temps)
+	}
+	if *emissionsPath != "" {
+		rates, err := data.LoadSeriesCSV(*emissionsPath)
+		if err != nil {
+			panic(err)
+		}
+		data.JoinEmissionsRate(intervals, rates)
+	}
+	data.EmissionsFromGHG(intervals, *ghgPrice)
 
 	cfg, err := config.Load(*cfgPath)
 	if err != nil {
@@ -79,9 +169,25 @@ func cmdBacktest(args []string) {
 	// choose to support an explicit initial_soc override.
 	batt.State.SOC = batt.Params.MinSOC
 
-	strat := buildStrategy(cfg, intervals, batt)
+	// Availability trials reuse the main run's plans.
+	plans := builder.NewPlans()
+	strat, err := builder.Strategy(cfg, intervals, batt, builder.Options{Plans: plans})
+	if err != nil {
+		panic(err)
+	}
+	if c, ok := strat.(io.Closer); ok {
+		defer c.Close()
+	}
 
 	engine := backtest.New()
+	var avail backtest.Availability
+	if cfg.Availability != nil {
+		if avail, err = builder.Availability(cfg.Availability); err != nil {
+			panic(err)
+		}
+		// The ledger shows trial 0 of the Monte Carlo.
+		engine.Available = avail.Sample(intervals, rand.New(rand.NewSource(cfg.Availability.Seed)))
+	}
 	res, err := engine.Run(intervals, batt, strat)
 	if err != nil {
 		panic(err)
@@ -97,14 +203,218 @@ func cmdBacktest(args []string) {
 
 	fmt.Printf("Wrote %d rows to %s\n", len(res.Ledger), *outPath)
 	fmt.Printf("Total PnL=$%.2f Final SOC=%.3f\n", res.TotalPNL, res.FinalSOC)
+	printComponents(res.Components)
+	if e := res.Emissions; e.RatedIntervals > 0 {
+		fmt.Printf("Emissions: charging=%.2f tCO2 avoided=%.2f tCO2 net=%.2f tCO2 abatement cost=$%.2f/tCO2\n",
+			e.ChargingT, e.AvoidedT, e.NetT, e.AbatementCostPerTCO2)
+	}
+
+	if sto, ok := strat.(*strategy.ScenarioStrategy); ok {
+		printScenarioReport(sto.Report())
+	}
+	if p := batt.Params; p.MaxCyclesPerDay > 0 || p.MaxCyclesPerYear > 0 {
+		printCycleUsage(res.Cycles)
+	}
+	if batt.Params.Settlement != nil {
+		printSettlement(res.Settlement)
+	}
+	printPriceEvents(res.PriceEvents)
+	if a := cfg.Availability; a != nil {
+		setup := func() (*model.Battery, strategy.Strategy, error) {
+			b, err := model.NewBattery(cfg.Battery.ToModelParams(), cfg.Battery.InitialSOC)
+			if err != nil {
+				return nil, nil, err
+			}
+			b.State.SOC = b.Params.MinSOC
+			s, err := builder.Strategy(cfg, intervals, b, builder.Options{Plans: plans})
+			return b, s, err
+		}
+		mc, err := backtest.RunMonteCarlo(context.Background(), intervals, setup, avail, a.TrialCount(), a.Seed)
+		if err != nil {
+			panic(err)
+		}
+		printMonteCarlo(mc)
+	}
+	if b := cfg.Bootstrap; b != nil {
+		paths, err := synthetic.Bootstrap(intervals, builder.Bootstrap(b))
+		if err != nil {
+			panic(err)
+		}
+		bs, err := backtest.RunPaths(paths, func(path []model.LMPInterval) (*model.Battery, strategy.Strategy, error) {
+			b, err := model.NewBattery(cfg.Battery.ToModelParams(), cfg.Battery.InitialSOC)
+			if err != nil {
+				return nil, nil, err
+			}
+			b.State.SOC = b.Params.MinSOC
+			s, err := builder.Strategy(cfg, path, b, builder.Options{})
+			return b, s, err
+		})
+		if err != nil {
+			panic(err)
+		}
+		printBootstrap(bs, res.TotalPNL)
+	}
+}
+
+func printBootstrap(bs *backtest.PathsResult, historical float64) {
+	p := bs.PNL
+	below := 0
+	for _, r := range bs.Runs {
+		if r.TotalPNL < historical {
+			below++
+		}
+	}
+	fmt.Printf("\nBootstrap (%d price paths)\n", p.Count)
+	fmt.Printf("PnL: mean=$%.2f std=$%.2f p10=$%.2f p50=$%.2f p90=$%.2f cvar10=$%.2f\n", p.Mean, p.Std, p.P10, p.P50, p.P90, p.CVaR10)
+	fmt.Printf("Historical PnL $%.2f is above %d of %d paths\n", historical, below, p.Count)
+}
+
+func printMonteCarlo(mc *backtest.MonteCarloResult) {
+	p, a := mc.PNL, mc.Availability
+	fmt.Printf("\nMonte Carlo (%d trials)\n", p.Count)
+	fmt.Printf("PnL:          mean=$%.2f p10=$%.2f p50=$%.2f p90=$%.2f\n", p.Mean, p.P10, p.P50, p.P90)
+	fmt.Printf("Availability: mean=%.2f%% p10=%.2f%% p50=%.2f%% p90=%.2f%%\n", 100*a.Mean, 100*a.P10, 100*a.P50, 100*a.P90)
+}
+
+func printQualityReport(r *data.QualityReport) {
+	fmt.Printf("Data quality: %d intervals of %s", r.Intervals, r.IntervalLength)
+	if r.OK() {
+		fmt.Println(", no issues")
+		return
+	}
+	fmt.Printf(", %d issues (%d missing intervals)\n", len(r.Issues), r.MissingIntervals)
+	kinds := make([]string, 0, len(r.Counts))
+	for k := range r.Counts {
+		kinds = append(kinds, string(k))
+	}
+	sort.Strings(kinds)
+	for _, k := range kinds {
+		fmt.Printf("  %-13s %d\n", k, r.Counts[data.IssueKind(k)])
+	}
+	for i, is := range r.Issues {
+		if i == 10 {
+			fmt.Printf("  ... %d more\n", len(r.Issues)-i)
+			break
+		}
+		fmt.Printf("  [%d] %s %s: %s\n", is.Index, is.Time.Format(time.RFC3339), is.Kind, is.Detail)
+	}
+	if r.Policy != data.RepairNone {
+		fmt.Printf("Repair (%s): dropped %d, filled %d, unavailable %d\n", r.Policy, r.Dropped, r.Filled, r.Unavailable)
+	}
+}
+
+func printComponents(a backtest.ComponentAttribution) {
+	fmt.Printf("LMP components: energy=$%.2f congestion=$%.2f loss=$%.2f other=$%.2f (congestion share %.1f%%)\n",
+		a.Energy.NetUSD, a.Congestion.NetUSD, a.Loss.NetUSD, a.Other.NetUSD, 100*a.CongestionShare)
+}
+
+func printPriceEvents(e backtest.PriceEvents) {
+	fmt.Printf("Negative prices: charged %d intervals (%.2f MWh, paid $%.2f), discharged %d intervals (%.2f MWh, cost $%.2f)\n",
+		e.NegativeChargeIntervals, e.NegativeChargeMWh, e.NegativeChargeValueUSD,
+		e.NegativeDischargeIntervals, e.NegativeDischargeMWh, e.NegativeDischargeCostUSD)
+	if e.ScarcityIntervals > 0 {
+		fmt.Printf("Scarcity: %d intervals, discharged in %d (%.2f MWh, revenue $%.2f)\n",
+			e.ScarcityIntervals, e.ScarcityDischargeIntervals, e.ScarcityDischargeMWh, e.ScarcityRevenueUSD)
+	}
+	if e.FloorIntervals > 0 || e.CapIntervals > 0 {
+		fmt.Printf("Floor/cap: %d floored, %d capped intervals, PnL impact $%.2f\n", e.FloorIntervals, e.CapIntervals, e.FloorCapValueUSD)
+	}
+	if e.UpliftDays > 0 {
+		fmt.Printf("Bid cost recovery: $%.2f over %d days\n", e.BidCostRecoveryUSD, e.UpliftDays)
+	}
+}
+
+func printSettlement(s backtest.SettlementSummary) {
+	fmt.Printf("\nSettlement\n")
+	cost := func(x float64) float64 { return 0 - x } // avoid printing -0.00
+	for _, line := range []struct {
+		name string
+		usd  float64
+	}{
+		{"energy revenue", s.EnergyRevenueUSD},
+		{"energy cost", cost(s.EnergyCostUSD)},
+		{"degradation", cost(s.DegradationUSD)},
+		{"charging fees", cost(s.ChargingFeesUSD)},
+		{"throughput fees", cost(s.ThroughputFeesUSD)},
+		{"demand charges", cost(s.DemandChargesUSD)},
+		{"fixed charges", cost(s.FixedChargesUSD)},
+		{"switch costs", cost(s.SwitchCostsUSD)},
+		{"BCR uplift", s.BidCostRecoveryUSD},
+		{"net", s.NetUSD},
+	} {
+		fmt.Printf("%-16s %14.2f\n", line.name, line.usd)
+	}
+}
+
+func printCycleUsage(u *backtest.CycleUsage) {
+	fmt.Printf("\nCycles: %.2f total, %d intervals clipped by warranty caps\n", u.TotalCycles, u.ClippedIntervals)
+	fmt.Printf("%-10s %-10s %-10s\n", "period", "cycles", "allowance")
+	for _, periods := range [][]backtest.CyclePeriod{u.Days, u.Years} {
+		for _, p := range periods {
+			allowance := "-"
+			if p.Allowance > 0 {
+				allowance = fmt.Sprintf("%.2f", p.Allowance)
+			}
+			fmt.Printf("%-10s %-10.2f %-10s\n", p.Period, p.Cycles, allowance)
+		}
+	}
+}
+
+func printScenarioReport(days []strategy.ScenarioDayReport) {
+	if len(days) == 0 {
+		fmt.Println("No days had scenarios (need earlier analog days in the data)")
+		return
+	}
+	fmt.Printf("\n%-10s %-5s %-12s %-12s %-12s %-12s %-12s %-12s\n", "day", "K", "mean$", "p10$", "p50$", "p90$", "cvar10$", "realized$")
+	for _, d := range days {
+		o := d.Outcomes
+		fmt.Printf("%-10s %-5d %-12.2f %-12.2f %-12.2f %-12.2f %-12.2f %-12.2f\n",
+			d.Day, d.Scenarios, o.Mean, o.P10, o.P50, o.P90, o.CVaR10, d.Realized)
+	}
 }
 
 func cmdRank(args []string) {
 	fs := flag.NewFlagSet("rank", flag.ExitOnError)
 	dataPaths := fs.String("data", "sample_data.json", "Comma-separated JSON paths or a directory")
+	dataset := fs.String("dataset", "", "Optional: screen locations of this Grid Status dataset instead of reading --data")
+	all := fs.Bool("all", false, "With --dataset: screen every location in the locations file")
+	locations := fs.String("locations", "", "With --dataset: comma-separated location IDs (instead of --all)")
+	locationsFile := fs.String("locations-file", "", "Locations file for --all (default: $LOCATIONS_FILE or ./data/locations.json)")
+	start := fs.String("start", "", "Start date (YYYY-MM-DD) for --dataset")
+	end := fs.String("end", "", "End date (YYYY-MM-DD, exclusive) for --dataset")
+	storeDir := fs.String("store", os.Getenv("PRICE_STORE_DIR"), "Price store read before live queries for --dataset")
+	concurrency := fs.Int("concurrency", 4, "Locations fetched at a time for --dataset")
+	checkpoint := fs.String("checkpoint", "", "Results file for --dataset, resumed if it exists (default: results/rank_<dataset>_<start>_<end>.jsonl)")
+	restart := fs.Bool("restart", false, "Discard an existing --checkpoint instead of resuming it")
+	limit := fs.Int("limit", 0, "Print only the top N locations (0=all)")
 	_ = fs.Parse(args)
 
-	paths := splitPaths(*dataPaths)
+	var ranked []analysis.RankedPotential
+	if *dataset != "" {
+		ranked = screenDataset(screenOptions{
+			dataset:       *dataset,
+			all:           *all,
+			locations:     *locations,
+			locationsFile: *locationsFile,
+			start:         *start,
+			end:           *end,
+			storeDir:      *storeDir,
+			concurrency:   *concurrency,
+			checkpoint:    *checkpoint,
+			restart:       *restart,
+		})
+	} else {
+		ranked = analysis.RankByOracleProfit(loadByLocation(splitPaths(*dataPaths)))
+	}
+	if *limit > 0 && *limit < len(ranked) {
+		ranked = ranked[:*limit]
+	}
+	printRanking(ranked)
+}
+
+// loadByLocation reads Grid Status JSON files (or directories of them) and
+// groups their intervals by location.
+func loadByLocation(paths []string) map[string][]model.LMPInterval {
 	byLoc := map[string][]model.LMPInterval{}
 	for _, p := range paths {
 		info, err := os.Stat(p)
@@ -137,12 +447,152 @@ func cmdRank(args []string) {
 			mergeByLoc(byLoc, data.GroupByLocation(resp))
 		}
 	}
+	return byLoc
+}
+
+type screenOptions struct {
+	dataset, locations, locationsFile string
+	start, end, storeDir, checkpoint  string
+	all, restart                      bool
+	concurrency                       int
+}
+
+// screenDataset fetches and scores each location of a dataset, appending
+// results to a checkpoint so an interrupted run (e.g. Ctrl-C) resumes where
+// it stopped. Locations that failed are retried on the next run.
+func screenDataset(o screenOptions) []analysis.RankedPotential {
+	if o.start == "" || o.end == "" {
+		fmt.Println("--start and --end are required with --dataset")
+		os.Exit(2)
+	}
+	from, err1 := time.Parse("2006-01-02", o.start)
+	to, err2 := time.Parse("2006-01-02", o.end)
+	if err1 != nil || err2 != nil || !to.After(from) {
+		fmt.Println("--start and --end must be YYYY-MM-DD with --end after --start")
+		os.Exit(2)
+	}
+
+	var ids []string
+	switch {
+	case o.all && o.locations != "":
+		fmt.Println("use either --all or --locations")
+		os.Exit(2)
+	case o.all:
+		path := o.locationsFile
+		if path == "" {
+			path = data.GetDefaultLocationsPath()
+		}
+		list, err := data.LoadLocations(path)
+		if err != nil {
+			fmt.Println(err, "(run cmd/update-locations to build it)")
+			os.Exit(2)
+		}
+		for _, loc := range list.ForDataset(o.dataset) {
+			ids = append(ids, loc.ID)
+		}
+	case o.locations != "":
+		ids = splitPaths(o.locations)
+	default:
+		fmt.Println("--all or --locations is required with --dataset")
+		os.Exit(2)
+	}
+	if len(ids) == 0 {
+		fmt.Printf("no locations for dataset %s\n", o.dataset)
+		os.Exit(2)
+	}
 
-	ranked := analysis.RankByOracleProfit(byLoc)
-	fmt.Printf("%-4s %-18s %-14s %-8s %-10s %-10s %-12s\n", "rank", "location", "market", "count", "p95-p05", "min/max", "oracle$")
+	if o.checkpoint == "" {
+		o.checkpoint = filepath.Join("results", fmt.Sprintf("rank_%s_%s_%s.jsonl", o.dataset, o.start, o.end))
+	}
+	if o.restart {
+		if err := os.Remove(o.checkpoint); err != nil && !os.IsNotExist(err) {
+			panic(err)
+		}
+	}
+	results, err := analysis.LoadScreenCheckpoint(o.checkpoint)
+	if err != nil {
+		panic(err)
+	}
+	pending := analysis.PendingLocations(ids, results)
+	fmt.Printf("Screening %d locations of %s (%d done in %s, %d to fetch)\n",
+		len(ids), o.dataset, len(ids)-len(pending), o.checkpoint, len(pending))
+
+	if len(pending) > 0 {
+		client := data.NewGridStatusClient(os.Getenv("GRIDSTATUS_API_KEY"), "")
+		client.Store = nil
+		if o.storeDir != "" {
+			client.Store = data.NewStore(o.storeDir)
+		}
+		cp, err := analysis.OpenScreenCheckpoint(o.checkpoint)
+		if err != nil {
+			panic(err)
+		}
+		defer cp.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		fetch := func(ctx context.Context, loc string) ([]model.LMPInterval, error) {
+			resp, err := client.QueryLocationContext(ctx, data.QueryLocationParams{
+				DatasetID:  o.dataset,
+				LocationID: loc,
+				StartTime:  from,
+				EndTime:    to,
+				Timezone:   data.TimezoneMarket,
+				Download:   true,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return resp.Data, nil
+		}
+		n := len(ids) - len(pending)
+		err = analysis.Screen(ctx, pending, o.concurrency, fetch, func(r analysis.ScreenResult, err error) error {
+			n++
+			if r.Potential != nil {
+				fmt.Printf("[%d/%d] %-18s oracle$ %.2f\n", n, len(ids), r.Location, r.Potential.OracleProfit)
+			} else {
+				fmt.Printf("[%d/%d] %-18s failed: %s\n", n, len(ids), r.Location, r.Error)
+			}
+			results[r.Location] = r
+			if err := cp.Append(r); err != nil {
+				return err
+			}
+			if data.IsAuthError(err) {
+				return err
+			}
+			return nil
+		})
+		if errors.Is(err, context.Canceled) {
+			fmt.Println("Screening interrupted; rerun the same command to resume")
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("Screening stopped: %v (rerun the same command to resume)\n", err)
+			os.Exit(1)
+		}
+	}
+
+	list := make([]analysis.ScreenResult, 0, len(ids))
+	failed := 0
+	for _, id := range ids {
+		if r, ok := results[id]; ok {
+			list = append(list, r)
+			if r.Potential == nil {
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("%d locations failed; rerun the same command to retry them\n", failed)
+	}
+	return analysis.RankScreen(list)
+}
+
+func printRanking(ranked []analysis.RankedPotential) {
+	fmt.Printf("%-4s %-18s %-14s %-8s %-10s %-10s %-12s %-8s\n", "rank", "location", "market", "count", "p95-p05", "min/max", "oracle$", "cong%")
 	for i, r := range ranked {
 		fmt.Printf(
-			"%-4d %-18s %-14s %-8d %-10.2f %-5.1f/%-5.1f %-12.2f\n",
+			"%-4d %-18s %-14s %-8d %-10.2f %-5.1f/%-5.1f %-12.2f %-8.1f\n",
 			i+1,
 			r.Location,
 			r.Market,
@@ -151,41 +601,224 @@ func cmdRank(args []string) {
 			r.MinLMP,
 			r.MaxLMP,
 			r.OracleProfit,
+			100*r.CongestionShare,
 		)
 	}
 }
 
-func buildStrategy(cfg *config.Config, intervals []model.LMPInterval, batt *model.Battery) strategy.Strategy {
-	switch cfg.Strategy.Name {
-	case "schedule":
-		chargeStart := mustStr(cfg.Strategy.Params, "charge_start", "10:00")
-		dischargeStart := mustStr(cfg.Strategy.Params, "discharge_start", "17:00")
-		chargeEnd := mustStr(cfg.Strategy.Params, "charge_end", dischargeStart)
-		dischargeEnd := mustStr(cfg.Strategy.Params, "discharge_end", dischargeStart) // empty window by default
-		chargeMW := mustNum(cfg.Strategy.Params, "charge_power_mw", cfg.Battery.PowerCapacityMW)
-		dischargeMW := mustNum(cfg.Strategy.Params, "discharge_power_mw", cfg.Battery.PowerCapacityMW)
-		return &strategy.ScheduleStrategy{Params: strategy.ScheduleParams{
-			ChargeStart:      chargeStart,
-			ChargeEnd:        chargeEnd,
-			DischargeStart:   dischargeStart,
-			DischargeEnd:     dischargeEnd,
-			ChargePowerMW:    chargeMW,
-			DischargePowerMW: dischargeMW,
-		}}
-	case "oracle":
-		socSteps := int(mustNum(cfg.Strategy.Params, "soc_steps", 200))
-		powerSteps := int(mustNum(cfg.Strategy.Params, "power_steps", 10))
-		orc, err := strategy.NewOracleStrategy(intervals, batt.Params, batt.State.SOC, strategy.OracleParams{
-			SocSteps:   socSteps,
-			PowerSteps: powerSteps,
-		})
+func cmdTrain(args []string) {
+	fs := flag.NewFlagSet("train", flag.ExitOnError)
+	dataPath := fs.String("data", "sample_data.json", "Path to Grid Status JSON response")
+	cfgPath := fs.String("config", "", "Path to YAML config (battery + qlearn strategy params)")
+	trainStart := fs.String("train-start", "", "First training day, YYYY-MM-DD local (default: first day in data)")
+	trainEnd := fs.String("train-end", "", "Last training day, inclusive, YYYY-MM-DD local (default: last day in data)")
+	outPath := fs.String("out", "results/policy.json", "Output policy path")
+	_ = fs.Parse(args)
+
+	if *cfgPath == "" {
+		fmt.Println("--config is required")
+		os.Exit(2)
+	}
+
+	resp, err := data.LoadGridStatusJSON(*dataPath)
+	if err != nil {
+		panic(err)
+	}
+	cfg, err := config.Load(*cfgPath)
+	if err != nil {
+		panic(err)
+	}
+
+	intervals := make([]model.LMPInterval, 0, len(resp.Data))
+	for _, it := range resp.Data {
+		day := it.IntervalStartLocal.Format("2006-01-02")
+		if *trainStart != "" && day < *trainStart {
+			continue
+		}
+		if *trainEnd != "" && day > *trainEnd {
+			continue
+		}
+		intervals = append(intervals, it)
+	}
+	if len(intervals) == 0 {
+		fmt.Println("no intervals in the training window")
+		os.Exit(1)
+	}
+
+	params := cfg.Battery.ToModelParams()
+	p := cfg.Strategy.Params
+	powerLevels, err := builder.Nums(p, "power_levels")
+	if err != nil {
+		panic(err)
+	}
+	policy, err := strategy.TrainQPolicy(intervals, params, params.MinSOC, strategy.QLearnConfig{
+		SOCBins:       int(builder.Num(p, "soc_bins", 0)),
+		PriceBins:     int(builder.Num(p, "price_bins", 0)),
+		LookbackHours: builder.Num(p, "lookback_hours", 0),
+		PowerLevels:   powerLevels,
+		Episodes:      int(builder.Num(p, "episodes", 0)),
+		Alpha:         builder.Num(p, "alpha", 0),
+		Gamma:         builder.Num(p, "gamma", -1),
+		Epsilon:       builder.Num(p, "epsilon", -1),
+		Seed:          int64(builder.Num(p, "seed", 1)),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(*outPath), 0o755); err != nil {
+		panic(err)
+	}
+	if err := strategy.SaveQPolicy(*outPath, policy); err != nil {
+		panic(err)
+	}
+	fmt.Printf("Trained on %d intervals (%s to %s), %d episodes\n",
+		len(intervals),
+		policy.TrainStartUTC.Format(time.RFC3339),
+		policy.TrainEndUTC.Format(time.RFC3339),
+		policy.Config.Episodes)
+	fmt.Printf("Wrote policy to %s (backtests must start at or after %s)\n", *outPath, policy.TrainEndUTC.Format(time.RFC3339))
+}
+
+func cmdSync(args []string) {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	storeDir := fs.String("store", os.Getenv("PRICE_STORE_DIR"), "Price store directory (default: $PRICE_STORE_DIR)")
+	dataset := fs.String("dataset", "", "Grid Status dataset ID")
+	locations := fs.String("locations", "", "Comma-separated location IDs")
+	start := fs.String("start", "", "Start date (YYYY-MM-DD)")
+	end := fs.String("end", "", "End date (YYYY-MM-DD, exclusive; default: today in market time)")
+	marketTZ := fs.String("market-tz", "", "IANA zone of the market's days (default: from the dataset's ISO)")
+	chunkDays := fs.Int("chunk-days", 31, "Maximum days fetched per request")
+	_ = fs.Parse(args)
+
+	if *storeDir == "" || *dataset == "" || *locations == "" || *start == "" {
+		fmt.Println("--store, --dataset, --locations and --start are required")
+		os.Exit(2)
+	}
+	apiKey := os.Getenv("GRIDSTATUS_API_KEY")
+	if apiKey == "" {
+		fmt.Println("GRIDSTATUS_API_KEY environment variable is required")
+		os.Exit(2)
+	}
+	from, err := time.Parse("2006-01-02", *start)
+	if err != nil {
+		fmt.Println("invalid --start:", err)
+		os.Exit(2)
+	}
+	if *marketTZ == "" {
+		*marketTZ = data.MarketZone(*dataset)
+	}
+	if *marketTZ == "" {
+		fmt.Printf("unknown market for dataset %q: set --market-tz\n", *dataset)
+		os.Exit(2)
+	}
+	marketLoc, err := time.LoadLocation(*marketTZ)
+	if err != nil {
+		fmt.Println("invalid --market-tz:", err)
+		os.Exit(2)
+	}
+	// Today's market day is still open: never mark it synced. Store days are
+	// market-local, so "today" is too.
+	today := data.MarketToday(time.Now(), marketLoc)
+	to := today
+	if *end != "" {
+		if to, err = time.Parse("2006-01-02", *end); err != nil {
+			fmt.Println("invalid --end:", err)
+			os.Exit(2)
+		}
+		if to.After(today) {
+			to = today
+		}
+	}
+	if *chunkDays < 1 {
+		*chunkDays = 1
+	}
+
+	client := data.NewGridStatusClient(apiKey, "")
+	client.Store = nil // always fetch live
+	store := data.NewStore(*storeDir)
+	failed := false
+	for _, loc := range splitPaths(*locations) {
+		missing, err := store.Missing(*dataset, loc, from, to)
 		if err != nil {
 			panic(err)
 		}
-		return orc
-	default:
-		panic(fmt.Errorf("unsupported strategy: %q", cfg.Strategy.Name))
+		if len(missing) == 0 {
+			fmt.Printf("%s: up to date\n", loc)
+			continue
+		}
+		rows := 0
+		for _, r := range missing {
+			for c := r.Start; c.Before(r.End); c = c.AddDate(0, 0, *chunkDays) {
+				chunk := data.DateRange{Start: c, End: c.AddDate(0, 0, *chunkDays)}
+				if chunk.End.After(r.End) {
+					chunk.End = r.End
+				}
+				resp, err := client.QueryLocation(data.QueryLocationParams{
+					DatasetID:  *dataset,
+					LocationID: loc,
+					StartTime:  chunk.Start,
+					EndTime:    chunk.End,
+					Timezone:   data.TimezoneMarket,
+					Download:   true,
+				})
+				if err == nil {
+					err = store.Write(*dataset, loc, resp.Data, chunk)
+				}
+				if err != nil {
+					fmt.Printf("%s %s: %v\n", loc, chunk, err)
+					failed = true
+					break
+				}
+				rows += len(resp.Data)
+			}
+		}
+		fmt.Printf("%s: fetched %d intervals in %d missing range(s)\n", loc, rows, len(missing))
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func cmdGenerate(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	outPath := fs.String("out", "results/synthetic.json", "Output Grid Status JSON path")
+	start := fs.String("start", "2024-07-01", "First local day (YYYY-MM-DD)")
+	p := synthetic.Params{}
+	fs.IntVar(&p.Days, "days", 7, "Number of days")
+	fs.DurationVar(&p.Step, "step", 5*time.Minute, "Interval length")
+	fs.StringVar(&p.Timezone, "timezone", "America/Los_Angeles", "IANA timezone of the local timestamps")
+	fs.Int64Var(&p.Seed, "seed", 1, "Random seed")
+	fs.StringVar(&p.Location, "location", "SYNTHETIC_NODE", "Location name")
+	fs.Float64Var(&p.BasePrice, "base-price", 0, "Mean price $/MWh (0 = default 40)")
+	fs.Float64Var(&p.DailyAmplitude, "daily-amplitude", 0, "Daily shape amplitude $/MWh (0 = default 25, negative = flat)")
+	fs.Float64Var(&p.SeasonalAmplitude, "seasonal-amplitude", 0, "Fractional seasonal swing (0 = default 0.25, negative = none)")
+	fs.Float64Var(&p.Volatility, "volatility", 0, "Noise std dev $/MWh (0 = default 6, negative = none)")
+	fs.Float64Var(&p.MeanReversion, "mean-reversion", 0, "Noise reversion rate per hour (0 = default 0.5)")
+	fs.Float64Var(&p.SpikesPerDay, "spikes-per-day", 0, "Expected price spikes per day (0 = default 0.3, negative = none)")
+	fs.Float64Var(&p.SpikeMean, "spike-mean", 0, "Mean spike height $/MWh (0 = default 300)")
+	fs.Float64Var(&p.NegativePerDay, "negative-per-day", 0, "Expected negative-price episodes per day (0 = default 0.1, negative = none)")
+	fs.Float64Var(&p.NegativePrice, "negative-price", 0, "Price during negative episodes $/MWh (0 = default -25)")
+	fs.Float64Var(&p.NegativeHours, "negative-hours", 0, "Length of negative episodes in hours (0 = default 2)")
+	_ = fs.Parse(args)
+
+	var err error
+	if p.Start, err = time.Parse("2006-01-02", *start); err != nil {
+		fmt.Println("invalid --start:", err)
+		os.Exit(2)
+	}
+	intervals, err := synthetic.Generate(p)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+	if err := os.MkdirAll(filepath.Dir(*outPath), 0o755); err != nil {
+		panic(err)
 	}
+	if err := data.WriteGridStatusJSON(*outPath, &model.GridStatusLMPResponse{StatusCode: 200, Data: intervals}); err != nil {
+		panic(err)
+	}
+	fmt.Printf("Wrote %d intervals (%d days, seed %d) to %s\n", len(intervals), p.Days, p.Seed, *outPath)
 }
 
 func splitPaths(s string) []string {
@@ -205,24 +838,3 @@ func mergeByLoc(dst, src map[string][]model.LMPInterval) {
 		dst[k] = append(dst[k], v...)
 	}
 }
-
-func mustNum(m map[string]any, key string, def float64) float64 {
-	if v, ok := m[key]; ok && v != nil {
-		switch x := v.(type) {
-		case float64:
-			return x
-		case int:
-			return float64(x)
-		}
-	}
-	return def
-}
-
-func mustStr(m map[string]any, key string, def string) string {
-	if v, ok := m[key]; ok && v != nil {
-		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
-			return s
-		}
-	}
-	return def
-}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"battery-backtest/internal/data/gridstatusmock"
+)
+
+// Local stand-in for the Grid Status API. Point the API server or CLI at it:
+//
+//	go run ./cmd/gridstatus-mock --data-dir testdata --synthetic
+//	GRIDSTATUS_BASE_URL=http://localhost:8090 go run ./cmd/api
+func main() {
+	var (
+		addr       = flag.String("addr", ":8090", "Listen address")
+		dataDir    = flag.String("data-dir", "", "Directory of Grid Status JSON files (<dataset>/<location>.json or <location>.json)")
+		synth      = flag.Bool("synthetic", false, "Serve synthetic prices for locations without a file")
+		catalog    = flag.Int("catalog-size", 0, "Synthetic nodes added to each dataset's location listing (with --synthetic)")
+		keys       = flag.String("keys", "", "Comma-separated accepted API keys (default: any non-empty key)")
+		rateLimit  = flag.Int("rate-limit", 0, "Requests per key per --rate-window before 429 (0 = unlimited)")
+		rateWindow = flag.Duration("rate-window", time.Minute, "Rate limit window")
+	)
+	flag.Parse()
+
+	opts := gridstatusmock.Options{
+		DataDir:     *dataDir,
+		Synthetic:   *synth,
+		CatalogSize: *catalog,
+		RateLimit:   *rateLimit,
+		RateWindow:  *rateWindow,
+	}
+	for _, k := range strings.Split(*keys, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			opts.APIKeys = append(opts.APIKeys, k)
+		}
+	}
+	if opts.DataDir == "" && !opts.Synthetic {
+		log.Fatal("--data-dir or --synthetic is required")
+	}
+
+	log.Printf("Grid Status mock listening on %s (data-dir=%q synthetic=%v)", *addr, opts.DataDir, opts.Synthetic)
+	if err := http.ListenAndServe(*addr, gridstatusmock.NewHandler(opts)); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
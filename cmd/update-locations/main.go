@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"time"
 
 	"battery-backtest/internal/data"
@@ -15,7 +17,8 @@ func main() {
 		datasetID  = flag.String("dataset-id", "caiso_lmp_real_time_5_min", "Grid Status dataset ID")
 		outputPath = flag.String("output", "", "Output file path (default: ./data/locations.json)")
 		seedFile   = flag.String("seed", "", "Path to existing locations file to use as seed")
-		days       = flag.Int("days", 7, "Number of days to look back for location discovery")
+		days       = flag.Int("days", 7, "Number of days to look back when refreshing seed locations (with --discover=false)")
+		discover   = flag.Bool("discover", true, "Discover all locations from the dataset's listing API (false: refresh seed locations only)")
 	)
 	flag.Parse()
 
@@ -32,32 +35,40 @@ func main() {
 
 	fmt.Printf("Updating locations for dataset: %s\n", *datasetID)
 
-	// Load existing locations as seed if provided
+	// Load existing locations for this dataset as seed if provided
 	var existingLocations []data.Location
 	if *seedFile != "" {
 		if list, err := data.LoadLocations(*seedFile); err == nil {
-			existingLocations = list.Locations
+			existingLocations = seedLocations(list, *datasetID)
 			fmt.Printf("Loaded %d existing locations from seed file\n", len(existingLocations))
 		}
 	} else {
 		// Try to load from default path
 		if list, err := data.LoadLocations(data.GetDefaultLocationsPath()); err == nil {
-			existingLocations = list.Locations
+			existingLocations = seedLocations(list, *datasetID)
 			fmt.Printf("Loaded %d existing locations from default file\n", len(existingLocations))
 		}
 	}
 
-	// Query known locations to update their metadata
-	endDate := time.Now()
-	startDate := endDate.AddDate(0, 0, -*days)
+	var locations []data.Location
+	var err error
+	if *discover {
+		fmt.Println("Listing all locations from the dataset catalog...")
+		locations, err = discoverLocations(client, *datasetID, existingLocations)
+	} else {
+		// Query known locations to update their metadata
+		endDate := time.Now()
+		startDate := endDate.AddDate(0, 0, -*days)
 
-	fmt.Printf("Querying locations from %s to %s to update metadata...\n",
-		startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+		fmt.Printf("Querying locations from %s to %s to update metadata...\n",
+			startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
 
-	locations, err := updateLocationsFromAPI(client, *datasetID, startDate, endDate, existingLocations)
+		locations, err = updateLocationsFromAPI(client, *datasetID, startDate, endDate, existingLocations)
+	}
 	if err != nil {
 		log.Fatalf("Failed to update locations: %v", err)
 	}
+	sort.Slice(locations, func(i, j int) bool { return locations[i].ID < locations[j].ID })
 
 	fmt.Printf("Found %d total locations\n", len(locations))
 
@@ -76,6 +87,50 @@ func main() {
 	fmt.Printf("Saved %d locations to %s\n", len(locations), *outputPath)
 }
 
+// seedLocations returns the locations in list that belong to datasetID;
+// untagged locations belong to the list's dataset.
+func seedLocations(list *data.LocationList, datasetID string) []data.Location {
+	var out []data.Location
+	for _, loc := range list.Locations {
+		if loc.DatasetID == "" {
+			loc.DatasetID = list.DatasetID
+		}
+		if loc.DatasetID == datasetID {
+			out = append(out, loc)
+		}
+	}
+	return out
+}
+
+// discoverLocations lists every location in the dataset. Names from the seed
+// fill in where the listing has none, and seed locations missing from the
+// listing are kept.
+func discoverLocations(client *data.GridStatusClient, datasetID string, seedLocations []data.Location) ([]data.Location, error) {
+	listed, err := client.ListLocations(context.Background(), datasetID)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Listed %d locations\n", len(listed))
+
+	seed := make(map[string]data.Location, len(seedLocations))
+	for _, loc := range seedLocations {
+		seed[loc.ID] = loc
+	}
+	locations := make([]data.Location, 0, len(listed)+len(seedLocations))
+	for _, loc := range listed {
+		if loc.Name == "" || loc.Name == loc.ID {
+			loc.Name = inferLocationName(loc.ID, seed[loc.ID].Name)
+		}
+		delete(seed, loc.ID)
+		locations = append(locations, loc)
+	}
+	for _, loc := range seed {
+		fmt.Printf("  ⚠️  Seed location %s not in listing, keeping it\n", loc.ID)
+		locations = append(locations, loc)
+	}
+	return locations, nil
+}
+
 // updateLocationsFromAPI updates location metadata by querying known locations
 // Since Grid Status API requires a location_id to query, we maintain a seed list
 // and update metadata for those locations. New locations can be added manually.
@@ -0,0 +1,57 @@
+//go:build wasip1
+
+// Command threshold is an example WebAssembly strategy: charge when the LMP is
+// below a low threshold, discharge when it is above a high threshold.
+//
+// Build (Go 1.24+) as a WASI reactor:
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o threshold.wasm ./examples/wasm/threshold
+//
+// Then reference it from a config:
+//
+//	strategy:
+//	  name: wasm
+//	  params:
+//	    module: threshold.wasm
+package main
+
+import "unsafe"
+
+const (
+	chargeBelow    = 20.0  // $/MWh
+	dischargeAbove = 100.0 // $/MWh
+)
+
+var (
+	powerMW float64
+	minSOC  float64
+	maxSOC  float64
+	name    = []byte("wasm-threshold")
+)
+
+//go:wasmexport init
+func initBattery(energyCapacityMWh, powerCapacityMW, minSoc, maxSoc float64) {
+	powerMW = powerCapacityMW
+	minSOC = minSoc
+	maxSOC = maxSoc
+}
+
+//go:wasmexport name
+func strategyName() int64 {
+	ptr := uintptr(unsafe.Pointer(&name[0]))
+	return int64(ptr)<<32 | int64(len(name))
+}
+
+//go:wasmexport decide
+func decide(index int32, startUnix int64, utcOffsetS int32, durationH, lmp, energy, congestion, loss, soc float64) float64 {
+	switch {
+	case lmp < chargeBelow && soc < maxSOC:
+		return -powerMW
+	case lmp > dischargeAbove && soc > minSOC:
+		return powerMW
+	default:
+		return 0
+	}
+}
+
+func main() {}
@@ -5,6 +5,7 @@ go 1.21
 require (
 	github.com/gin-gonic/gin v1.9.1
 	github.com/rs/cors v1.11.1
+	github.com/tetratelabs/wazero v1.8.2
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -0,0 +1,82 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+)
+
+// Distribution summarizes a sample of outcomes (e.g. PnL across scenarios or
+// Monte Carlo trials).
+type Distribution struct {
+	Count int
+	Mean  float64
+	Std   float64
+	Min   float64
+	P10   float64
+	P50   float64
+	P90   float64
+	Max   float64
+
+	// CVaR10 is the mean of the worst 10% of outcomes (expected shortfall).
+	CVaR10 float64
+}
+
+// Summarize computes a Distribution. The input is not modified.
+func Summarize(values []float64) Distribution {
+	d := Distribution{Count: len(values)}
+	if len(values) == 0 {
+		return d
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	d.Mean = sum / float64(len(sorted))
+	ss := 0.0
+	for _, v := range sorted {
+		ss += (v - d.Mean) * (v - d.Mean)
+	}
+	d.Std = math.Sqrt(ss / float64(len(sorted)))
+	d.Min = sorted[0]
+	d.Max = sorted[len(sorted)-1]
+	d.P10 = Percentile(sorted, 0.10)
+	d.P50 = Percentile(sorted, 0.50)
+	d.P90 = Percentile(sorted, 0.90)
+	d.CVaR10 = LowerTailMean(sorted, 0.10)
+	return d
+}
+
+// Percentile returns the q-quantile (0..1) of an ascending-sorted slice using
+// linear interpolation between order statistics.
+func Percentile(sorted []float64, q float64) float64 {
+	return percentileSorted(sorted, q)
+}
+
+// LowerTailMean is the mean of the worst alpha fraction of an ascending-sorted
+// slice, weighting the boundary observation fractionally (CVaR at level alpha).
+func LowerTailMean(sorted []float64, alpha float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	if alpha <= 0 {
+		return sorted[0]
+	}
+	if alpha >= 1 {
+		alpha = 1
+	}
+	mass := alpha * float64(len(sorted))
+	sum := 0.0
+	used := 0.0
+	for _, v := range sorted {
+		w := math.Min(1, mass-used)
+		if w <= 0 {
+			break
+		}
+		sum += w * v
+		used += w
+	}
+	return sum / used
+}
@@ -34,6 +34,10 @@ type ArbitragePotential struct {
 	// - SOC bounds [0,1], initial SOC 0.5
 	// - dispatch choices {-1, 0, +1} MW each interval
 	OracleProfit float64
+
+	// CongestionShare is the share of OracleProfit earned on the congestion
+	// component of the LMP (0 when the profit is not positive).
+	CongestionShare float64
 }
 
 func ComputePotential(intervals []model.LMPInterval) ArbitragePotential {
@@ -70,7 +74,11 @@ func ComputePotential(intervals []model.LMPInterval) ArbitragePotential {
 	p.P95LMP = percentileSorted(vals, 0.95)
 	p.SpreadP95P05 = p.P95LMP - p.P05LMP
 
-	p.OracleProfit = oracleProfitCanonical(intervals)
+	var congestion float64
+	p.OracleProfit, congestion = oracleProfitCanonical(intervals)
+	if p.OracleProfit > 0 {
+		p.CongestionShare = congestion / p.OracleProfit
+	}
 	return p
 }
 
@@ -96,14 +104,15 @@ func percentileSorted(sorted []float64, q float64) float64 {
 }
 
 // oracleProfitCanonical computes a best-effort "upper bound" using a simple DP:
-// SOC discretized into steps of dt (since P=1MW, E=1MWh).
-func oracleProfitCanonical(intervals []model.LMPInterval) float64 {
+// SOC discretized into steps of dt (since P=1MW, E=1MWh). It also returns the
+// part of that profit earned on the congestion component along the best path.
+func oracleProfitCanonical(intervals []model.LMPInterval) (profit, congestion float64) {
 	if len(intervals) == 0 {
-		return 0
+		return 0, 0
 	}
 	dt := intervals[0].DurationHours()
 	if dt <= 0 {
-		return 0
+		return 0, 0
 	}
 	stepSOC := dt // with 1MW, 1MWh => dt MWh per step => dt SOC
 	steps := int(math.Round(1.0 / stepSOC))
@@ -115,6 +124,8 @@ func oracleProfitCanonical(intervals []model.LMPInterval) float64 {
 	negInf := -1e100
 	dp := make([]float64, nStates)
 	next := make([]float64, nStates)
+	cong := make([]float64, nStates) // congestion value of the best path to each state
+	congNext := make([]float64, nStates)
 	for i := range dp {
 		dp[i] = negInf
 	}
@@ -142,6 +153,7 @@ func oracleProfitCanonical(intervals []model.LMPInterval) float64 {
 			// Idle
 			if dp[socIdx] > next[socIdx] {
 				next[socIdx] = dp[socIdx]
+				congNext[socIdx] = cong[socIdx]
 			}
 
 			// Charge: -1MW for dt hours => buy dt MWh, SOC increases by dt.
@@ -149,6 +161,7 @@ func oracleProfitCanonical(intervals []model.LMPInterval) float64 {
 				gain := -(price * dt) // cost
 				if dp[socIdx]+gain > next[socIdx+1] {
 					next[socIdx+1] = dp[socIdx] + gain
+					congNext[socIdx+1] = cong[socIdx] - it.Congestion*dt
 				}
 			}
 
@@ -157,21 +170,22 @@ func oracleProfitCanonical(intervals []model.LMPInterval) float64 {
 				gain := price * dt
 				if dp[socIdx]+gain > next[socIdx-1] {
 					next[socIdx-1] = dp[socIdx] + gain
+					congNext[socIdx-1] = cong[socIdx] + it.Congestion*dt
 				}
 			}
 		}
 		dp, next = next, dp
+		cong, congNext = congNext, cong
 	}
 
-	best := negInf
-	for _, v := range dp {
+	best, bestIdx := negInf, -1
+	for i, v := range dp {
 		if v > best {
-			best = v
+			best, bestIdx = v, i
 		}
 	}
 	if best <= negInf/2 {
-		return 0
+		return 0, 0
 	}
-	return best
+	return best, cong[bestIdx]
 }
-
@@ -0,0 +1,188 @@
+package analysis
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"battery-backtest/internal/model"
+)
+
+// ScreenResult is the outcome of screening one location. Exactly one of
+// Potential and Error is set.
+type ScreenResult struct {
+	Location  string              `json:"location"`
+	Potential *ArbitragePotential `json:"potential,omitempty"`
+	Error     string              `json:"error,omitempty"`
+}
+
+// ScreenFetch loads the price history of one location.
+type ScreenFetch func(ctx context.Context, location string) ([]model.LMPInterval, error)
+
+// Screen computes the arbitrage potential of each location, fetching up to
+// concurrency (default 4) locations at a time. Only the potentials are kept,
+// so memory does not grow with the number of locations.
+//
+// done is called once per location, one call at a time, with fetch errors
+// recorded in the result. A non-nil return from done (e.g. a failed
+// checkpoint write, or an auth error that will fail every location) stops the
+// screen and is returned. When ctx is cancelled Screen stops starting new
+// locations, waits for those in flight and returns ctx.Err().
+func Screen(ctx context.Context, locations []string, concurrency int, fetch ScreenFetch, done func(ScreenResult, error) error) error {
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		stopErr error
+		wg      sync.WaitGroup
+	)
+	jobs := make(chan string)
+	for w := 0; w < concurrency && w < len(locations); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for loc := range jobs {
+				intervals, err := fetch(ctx, loc)
+				if ctx.Err() != nil {
+					// Cancelled mid-fetch: leave the location for a resumed run.
+					continue
+				}
+				r := ScreenResult{Location: loc}
+				switch {
+				case err != nil:
+					r.Error = err.Error()
+				case len(intervals) == 0:
+					err = errors.New("no data in range")
+					r.Error = err.Error()
+				default:
+					p := ComputePotential(intervals)
+					r.Potential = &p
+				}
+
+				mu.Lock()
+				if stopErr == nil {
+					if stopErr = done(r, err); stopErr != nil {
+						cancel()
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, loc := range locations {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- loc:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if stopErr != nil {
+		return stopErr
+	}
+	return ctx.Err()
+}
+
+// RankScreen returns the successful results as potentials sorted descending
+// by OracleProfit.
+func RankScreen(results []ScreenResult) []RankedPotential {
+	out := make([]RankedPotential, 0, len(results))
+	for _, r := range results {
+		if r.Potential != nil {
+			out = append(out, RankedPotential{ArbitragePotential: *r.Potential})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].OracleProfit > out[j].OracleProfit
+	})
+	return out
+}
+
+// ScreenCheckpoint is an append-only JSON-lines file of screen results, so an
+// interrupted screen can resume where it stopped.
+type ScreenCheckpoint struct {
+	f *os.File
+}
+
+// LoadScreenCheckpoint reads the results in a checkpoint file (none if it does
+// not exist). A later result for a location replaces an earlier one, and a
+// truncated last line from an interrupted write is ignored.
+func LoadScreenCheckpoint(path string) (map[string]ScreenResult, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return map[string]ScreenResult{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	out := map[string]ScreenResult{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var r ScreenResult
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil || r.Location == "" {
+			continue
+		}
+		out[r.Location] = r
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read checkpoint %s: %w", path, err)
+	}
+	return out, nil
+}
+
+// OpenScreenCheckpoint opens a checkpoint for appending, creating it and its
+// directory if needed.
+func OpenScreenCheckpoint(path string) (*ScreenCheckpoint, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &ScreenCheckpoint{f: f}, nil
+}
+
+// Append writes one result as a single line.
+func (c *ScreenCheckpoint) Append(r ScreenResult) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = c.f.Write(append(b, '\n'))
+	return err
+}
+
+// Close closes the file.
+func (c *ScreenCheckpoint) Close() error {
+	return c.f.Close()
+}
+
+// PendingLocations returns the locations without a successful result in
+// done, in their original order. Failed locations are retried.
+func PendingLocations(locations []string, done map[string]ScreenResult) []string {
+	var out []string
+	for _, loc := range locations {
+		if r, ok := done[loc]; !ok || r.Potential == nil {
+			out = append(out, loc)
+		}
+	}
+	return out
+}
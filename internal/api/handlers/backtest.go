@@ -1,18 +1,26 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math/rand"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"battery-backtest/internal/analysis"
 	"battery-backtest/internal/api/models"
 	"battery-backtest/internal/backtest"
+	"battery-backtest/internal/builder"
 	"battery-backtest/internal/config"
 	"battery-backtest/internal/data"
+	"battery-backtest/internal/data/synthetic"
 	"battery-backtest/internal/model"
 	"battery-backtest/internal/strategy"
 
@@ -42,7 +50,7 @@ func (h *BacktestHandler) RunBacktest(c *gin.Context) {
 	}
 
 	// Validate API key
-	if err := validateAPIKey(req.APIKey); err != nil {
+	if err := validateSourceAPIKey(req.DataSource, req.APIKey); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error: models.ErrorDetail{
 				Code:    "INVALID_API_KEY",
@@ -64,7 +72,7 @@ func (h *BacktestHandler) RunBacktest(c *gin.Context) {
 	}
 
 	// Fetch data from Grid Status
-	intervals, err := h.fetchData(req.DataSource, req.APIKey)
+	intervals, quality, err := h.fetchData(c.Request.Context(), req.DataSource, req.APIKey)
 	if err != nil {
 		// Handle Grid Status API errors
 		if gsErr, ok := err.(*data.GridStatusError); ok {
@@ -127,11 +135,33 @@ func (h *BacktestHandler) RunBacktest(c *gin.Context) {
 	// Start at min SOC
 	batt.State.SOC = batt.Params.MinSOC
 
-	// Build strategy
-	strat := h.buildStrategy(cfg, intervals, batt)
+	// Build strategy; availability trials reuse its plans.
+	plans := builder.NewPlans()
+	strat, err := h.buildStrategy(cfg, intervals, batt, plans)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INVALID_STRATEGY",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+	if closer, ok := strat.(io.Closer); ok {
+		defer closer.Close()
+	}
 
 	// Run backtest
 	engine := backtest.New()
+	if engine.Available, err = availabilityMask(cfg, intervals); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INVALID_CONFIG",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
 	result, err := engine.Run(intervals, batt, strat)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -145,9 +175,167 @@ func (h *BacktestHandler) RunBacktest(c *gin.Context) {
 
 	// Build response
 	response := h.buildResponse(result, req.Options.IncludeLedger)
+	response.DataQuality = convertQualityReport(quality)
+	if sto, ok := strat.(*strategy.ScenarioStrategy); ok {
+		response.Scenarios = convertScenarioReport(sto.Report())
+	}
+	if a := cfg.Availability; a != nil {
+		setup := func() (*model.Battery, strategy.Strategy, error) {
+			b, err := model.NewBattery(cfg.Battery.ToModelParams(), cfg.Battery.InitialSOC)
+			if err != nil {
+				return nil, nil, err
+			}
+			b.State.SOC = b.Params.MinSOC
+			s, err := h.buildStrategy(cfg, intervals, b, plans)
+			return b, s, err
+		}
+		avail, err := builder.Availability(a)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "INVALID_CONFIG",
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), monteCarloTimeout)
+		mc, err := backtest.RunMonteCarlo(ctx, intervals, setup, avail, a.TrialCount(), a.Seed)
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) {
+			c.JSON(http.StatusGatewayTimeout, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "BACKTEST_TIMEOUT",
+					Message: fmt.Sprintf("availability trials did not finish within %s; lower trials", monteCarloTimeout),
+				},
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "BACKTEST_ERROR",
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+		response.MonteCarlo = convertMonteCarlo(mc)
+	}
+	if b := cfg.Bootstrap; b != nil {
+		bs, err := h.runBootstrap(cfg, intervals)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "BACKTEST_ERROR",
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+		response.Bootstrap = bs
+	}
 	c.JSON(http.StatusOK, response)
 }
 
+// runBootstrap backtests the configured strategy on block-bootstrapped paths.
+func (h *BacktestHandler) runBootstrap(cfg *config.Config, intervals []model.LMPInterval) (*models.BootstrapResult, error) {
+	params := builder.Bootstrap(cfg.Bootstrap)
+	paths, err := synthetic.Bootstrap(intervals, params)
+	if err != nil {
+		return nil, err
+	}
+	res, err := backtest.RunPaths(paths, func(path []model.LMPInterval) (*model.Battery, strategy.Strategy, error) {
+		b, err := model.NewBattery(cfg.Battery.ToModelParams(), cfg.Battery.InitialSOC)
+		if err != nil {
+			return nil, nil, err
+		}
+		b.State.SOC = b.Params.MinSOC
+		s, err := h.buildStrategy(cfg, path, b, nil)
+		return b, s, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := &models.BootstrapResult{BlockDays: max(params.BlockDays, 1), PNL: convertDistribution(res.PNL)}
+	for _, r := range res.Runs {
+		out.Paths = append(out.Paths, models.BootstrapPath{Path: r.Path, TotalPNL: r.TotalPNL})
+	}
+	return out, nil
+}
+
+// maxTrials caps availability Monte Carlo trials per request.
+const maxTrials = 1000
+
+// monteCarloTimeout bounds the availability trials of one request.
+const monteCarloTimeout = 2 * time.Minute
+
+// maxPaths caps bootstrap price paths per request.
+const maxPaths = 1000
+
+// availabilityMask samples the outage timeline of trial 0, or nil without an
+// availability config.
+func availabilityMask(cfg *config.Config, intervals []model.LMPInterval) ([]bool, error) {
+	if cfg.Availability == nil {
+		return nil, nil
+	}
+	avail, err := builder.Availability(cfg.Availability)
+	if err != nil {
+		return nil, err
+	}
+	return avail.Sample(intervals, rand.New(rand.NewSource(cfg.Availability.Seed))), nil
+}
+
+// maxReportedIssues caps the issues listed in a response; counts stay complete.
+const maxReportedIssues = 100
+
+func convertQualityReport(r *data.QualityReport) *models.DataQualityReport {
+	if r == nil {
+		return nil
+	}
+	out := &models.DataQualityReport{
+		Intervals:        r.Intervals,
+		IntervalMinutes:  r.IntervalLength.Minutes(),
+		Counts:           map[string]int{},
+		MissingIntervals: r.MissingIntervals,
+		Policy:           string(r.Policy),
+		Dropped:          r.Dropped,
+		Filled:           r.Filled,
+		Unavailable:      r.Unavailable,
+	}
+	for k, n := range r.Counts {
+		out.Counts[string(k)] = n
+	}
+	for i, is := range r.Issues {
+		if i == maxReportedIssues {
+			break
+		}
+		out.Issues = append(out.Issues, models.DataIssue{Kind: string(is.Kind), Index: is.Index, Time: is.Time, Detail: is.Detail})
+	}
+	return out
+}
+
+func convertComponents(a backtest.ComponentAttribution) models.LMPComponents {
+	return models.LMPComponents{
+		Energy:          models.ComponentValue(a.Energy),
+		Congestion:      models.ComponentValue(a.Congestion),
+		Loss:            models.ComponentValue(a.Loss),
+		Other:           models.ComponentValue(a.Other),
+		CongestionShare: a.CongestionShare,
+	}
+}
+
+func convertMonteCarlo(mc *backtest.MonteCarloResult) *models.MonteCarloResult {
+	out := &models.MonteCarloResult{
+		PNL:          convertDistribution(mc.PNL),
+		Availability: convertDistribution(mc.Availability),
+	}
+	for _, t := range mc.Trials {
+		out.Trials = append(out.Trials, models.MonteCarloTrial{Seed: t.Seed, TotalPNL: t.TotalPNL, Availability: t.Availability})
+	}
+	return out
+}
+
 // GetLedger handles GET /api/v1/backtest/:id/ledger
 // For now, this is a placeholder - we'd need to implement result caching
 func (h *BacktestHandler) GetLedger(c *gin.Context) {
@@ -175,7 +363,7 @@ func (h *BacktestHandler) CompareBacktests(c *gin.Context) {
 	}
 
 	// Validate API key
-	if err := validateAPIKey(req.APIKey); err != nil {
+	if err := validateSourceAPIKey(req.DataSource, req.APIKey); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error: models.ErrorDetail{
 				Code:    "INVALID_API_KEY",
@@ -197,7 +385,7 @@ func (h *BacktestHandler) CompareBacktests(c *gin.Context) {
 	}
 
 	// Fetch data once
-	intervals, err := h.fetchData(req.DataSource, req.APIKey)
+	intervals, quality, err := h.fetchData(c.Request.Context(), req.DataSource, req.APIKey)
 	if err != nil {
 		// Handle Grid Status API errors
 		if gsErr, ok := err.(*data.GridStatusError); ok {
@@ -248,12 +436,23 @@ func (h *BacktestHandler) CompareBacktests(c *gin.Context) {
 			continue // Skip invalid configs
 		}
 		batt.State.SOC = batt.Params.MinSOC
+		available, err := availabilityMask(cfg, intervals)
+		if err != nil {
+			continue // Skip invalid configs
+		}
 
 		// Build strategy
-		strat := h.buildStrategy(cfg, intervals, batt)
+		strat, err := h.buildStrategy(cfg, intervals, batt, nil)
+		if err != nil {
+			continue // Skip invalid strategies
+		}
 
 		// Run backtest
+		engine.Available = available
 		result, err := engine.Run(intervals, batt, strat)
+		if closer, ok := strat.(io.Closer); ok {
+			closer.Close()
+		}
 		if err != nil {
 			continue // Skip failed backtests
 		}
@@ -265,36 +464,145 @@ func (h *BacktestHandler) CompareBacktests(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, models.CompareBacktestResponse{
-		Comparison: comparison,
+		Comparison:  comparison,
+		DataQuality: convertQualityReport(quality),
 	})
 }
 
 // Helper methods
 
-func (h *BacktestHandler) fetchData(ds models.DataSourceConfig, apiKey string) ([]model.LMPInterval, error) {
-	if ds.Type != "gridstatus" {
-		return nil, fmt.Errorf("unsupported data source type: %s", ds.Type)
+// fetchData loads, validates and (per ds.Repair) repairs the interval series,
+// then joins any exogenous series.
+func (h *BacktestHandler) fetchData(ctx context.Context, ds models.DataSourceConfig, apiKey string) ([]model.LMPInterval, *data.QualityReport, error) {
+	policy, err := data.ParseRepairPolicy(ds.Repair)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Check the resample options before fetching anything.
+	var step time.Duration
+	var method data.ResampleMethod
+	if ds.Resample != "" {
+		if step, err = time.ParseDuration(ds.Resample); err != nil {
+			return nil, nil, fmt.Errorf("invalid resample step: %w", err)
+		}
+		if step < data.MinResampleStep {
+			return nil, nil, fmt.Errorf("resample step must be at least %s (got %s)", data.MinResampleStep, step)
+		}
+		if method, err = data.ParseResampleMethod(ds.ResampleMethod); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	// Create a new client with the API key from the request
-	client := data.NewGridStatusClient(apiKey, "")
+	intervals, err := loadIntervals(ctx, ds, apiKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	intervals, quality := data.Repair(intervals, policy, data.ValidateOptions{OutlierMADs: ds.OutlierMADs, RepairOutliers: ds.RepairOutliers})
+	if step > 0 {
+		if intervals, err = data.Resample(intervals, step, method); err != nil {
+			return nil, nil, err
+		}
+	}
 
-	timezone := ds.Timezone
-	if timezone == "" {
-		timezone = "market"
+	if len(ds.AmbientTemperature) > 0 {
+		temps := make([]data.SeriesPoint, len(ds.AmbientTemperature))
+		for i, pt := range ds.AmbientTemperature {
+			temps[i] = data.SeriesPoint{Time: pt.Time, Value: pt.TempC}
+		}
+		sort.Slice(temps, func(i, j int) bool { return temps[i].Time.Before(temps[j].Time) })
+		data.JoinAmbientTemperature(intervals, temps)
 	}
+	if len(ds.MarginalEmissions) > 0 {
+		rates := make([]data.SeriesPoint, len(ds.MarginalEmissions))
+		for i, pt := range ds.MarginalEmissions {
+			rates[i] = data.SeriesPoint{Time: pt.Time, Value: pt.TPerMWh}
+		}
+		sort.Slice(rates, func(i, j int) bool { return rates[i].Time.Before(rates[j].Time) })
+		data.JoinEmissionsRate(intervals, rates)
+	}
+	data.EmissionsFromGHG(intervals, ds.GHGPricePerTCO2)
 
-	resp, err := client.QueryLocationByString(
-		ds.DatasetID,
-		ds.LocationID,
-		ds.StartDate,
-		ds.EndDate,
-	)
+	return intervals, quality, nil
+}
+
+// loadIntervals fetches (gridstatus) or generates (synthetic) the raw series.
+// The result does not alias any cached response.
+func loadIntervals(ctx context.Context, ds models.DataSourceConfig, apiKey string) ([]model.LMPInterval, error) {
+	switch ds.Type {
+	case "gridstatus":
+		if ds.DatasetID == "" || ds.LocationID == "" {
+			return nil, fmt.Errorf("dataset_id and location_id are required for gridstatus data")
+		}
+		// Create a new client with the API key from the request
+		client := data.NewGridStatusClient(apiKey, "")
+		resp, err := client.QueryLocationInZone(
+			ctx,
+			ds.DatasetID,
+			ds.LocationID,
+			ds.StartDate,
+			ds.EndDate,
+			ds.Timezone,
+		)
+		if err != nil {
+			return nil, err
+		}
+		// Joins write into the intervals; keep the (possibly cached) response intact.
+		return append([]model.LMPInterval(nil), resp.Data...), nil
+	case "synthetic":
+		return generateSynthetic(ds)
+	default:
+		return nil, fmt.Errorf("unsupported data source type: %s", ds.Type)
+	}
+}
+
+// generateSynthetic builds a seeded synthetic series over the requested days.
+func generateSynthetic(ds models.DataSourceConfig) ([]model.LMPInterval, error) {
+	start, err := time.Parse("2006-01-02", ds.StartDate)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid start_date format (expected YYYY-MM-DD): %w", err)
 	}
+	end, err := time.Parse("2006-01-02", ds.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end_date format (expected YYYY-MM-DD): %w", err)
+	}
+	p := synthetic.Params{
+		Start:    start,
+		Days:     int(end.Sub(start).Hours() / 24),
+		Location: ds.LocationID,
+		Market:   ds.DatasetID,
+	}
+	if p.Days <= 0 {
+		return nil, fmt.Errorf("end_date must be after start_date")
+	}
+	// A named zone sets the local clock; "market" and "" use the generator default.
+	if ds.Timezone != "" && ds.Timezone != data.TimezoneMarket {
+		p.Timezone = ds.Timezone
+	}
+	if sc := ds.Synthetic; sc != nil {
+		p.Seed = sc.Seed
+		p.Step = time.Duration(sc.StepMinutes) * time.Minute
+		p.BasePrice = sc.BasePrice
+		p.DailyAmplitude = sc.DailyAmplitude
+		p.HourlyShape = sc.HourlyShape
+		p.SeasonalAmplitude = sc.SeasonalAmplitude
+		p.Volatility = sc.Volatility
+		p.MeanReversion = sc.MeanReversion
+		p.SpikesPerDay = sc.SpikesPerDay
+		p.SpikeMean = sc.SpikeMean
+		p.SpikeDecayHours = sc.SpikeDecayHours
+		p.NegativePerDay = sc.NegativePerDay
+		p.NegativePrice = sc.NegativePrice
+		p.NegativeHours = sc.NegativeHours
+	}
+	return synthetic.Generate(p)
+}
 
-	return resp.Data, nil
+// validateSourceAPIKey validates the API key for data sources that need one.
+func validateSourceAPIKey(ds models.DataSourceConfig, apiKey string) error {
+	if ds.Type == "synthetic" {
+		return nil
+	}
+	return validateAPIKey(apiKey)
 }
 
 // validateAPIKey performs basic validation on the API key
@@ -350,11 +658,42 @@ func (h *BacktestHandler) buildConfig(req models.BacktestConfig) (*config.Config
 			MaxSOC:                req.Battery.MaxSOC,
 			InitialSOC:            req.Battery.InitialSOC,
 			DegradationCostPerMWh: req.Battery.DegradationCostPerMWh,
+			MaxChargeMW:           req.Battery.MaxChargeMW,
+			MaxDischargeMW:        req.Battery.MaxDischargeMW,
+			ChargePowerCurve:      toPowerCurveConfig(req.Battery.ChargePowerCurve),
+			DischargePowerCurve:   toPowerCurveConfig(req.Battery.DischargePowerCurve),
+
+			ChargeEfficiencyCurve:    toEfficiencyCurveConfig(req.Battery.ChargeEfficiencyCurve),
+			DischargeEfficiencyCurve: toEfficiencyCurveConfig(req.Battery.DischargeEfficiencyCurve),
+			AuxLoadMW:                req.Battery.AuxLoadMW,
+			Islanded:                 req.Battery.Islanded,
+			SelfDischargePctPerDay:   req.Battery.SelfDischargePctPerDay,
+
+			RampRateMWPerMin:  req.Battery.RampRateMWPerMin,
+			MinModeMinutes:    req.Battery.MinModeMinutes,
+			ModeSwitchCostUSD: req.Battery.ModeSwitchCostUSD,
+			MaxCyclesPerDay:   req.Battery.MaxCyclesPerDay,
+			MaxCyclesPerYear:  req.Battery.MaxCyclesPerYear,
+			Thermal:           toThermalConfig(req.Battery.Thermal),
+			Settlement:        toSettlementConfig(req.Battery.Settlement),
 		},
-		Strategy: config.StrategyConfig{
-			Name:   req.Strategy.Name,
-			Params: req.Strategy.Params,
-		},
+		Strategy:     toStrategyConfig(req.Strategy),
+		Availability: toAvailabilityConfig(req.Availability),
+		Bootstrap:    (*config.BootstrapConfig)(req.Bootstrap),
+	}
+	if a := cfg.Availability; a != nil {
+		if a.Trials > maxTrials {
+			return nil, fmt.Errorf("availability trials must be at most %d", maxTrials)
+		}
+		if _, err := builder.Availability(a); err != nil {
+			return nil, err
+		}
+	}
+	if b := cfg.Bootstrap; b != nil && b.Paths > maxPaths {
+		return nil, fmt.Errorf("bootstrap paths must be at most %d", maxPaths)
+	}
+	if err := cfg.Strategy.Validate(); err != nil {
+		return nil, err
 	}
 
 	// If battery_file is set, load it and merge request overrides onto it
@@ -390,6 +729,83 @@ func (h *BacktestHandler) buildConfig(req models.BacktestConfig) (*config.Config
 	return cfg, nil
 }
 
+func toAvailabilityConfig(a *models.AvailabilityConfig) *config.AvailabilityConfig {
+	if a == nil {
+		return nil
+	}
+	out := &config.AvailabilityConfig{
+		ForcedOutageRate: a.ForcedOutageRate,
+		MTTRHours:        a.MTTRHours,
+		Trials:           a.Trials,
+		Seed:             a.Seed,
+	}
+	for _, w := range a.Maintenance {
+		out.Maintenance = append(out.Maintenance, config.MaintenanceWindow{Start: w.Start, End: w.End})
+	}
+	return out
+}
+
+func toThermalConfig(t *models.ThermalConfig) *config.ThermalConfig {
+	if t == nil {
+		return nil
+	}
+	out := config.ThermalConfig(*t)
+	return &out
+}
+
+func toSettlementConfig(s *models.SettlementConfig) *config.SettlementConfig {
+	if s == nil {
+		return nil
+	}
+	out := config.SettlementConfig(*s)
+	return &out
+}
+
+func toPowerCurveConfig(points []models.PowerCurvePoint) []config.PowerCurvePoint {
+	if points == nil {
+		return nil
+	}
+	out := make([]config.PowerCurvePoint, len(points))
+	for i, pt := range points {
+		out[i] = config.PowerCurvePoint{SOC: pt.SOC, MaxMW: pt.MaxMW}
+	}
+	return out
+}
+
+func toEfficiencyCurveConfig(points []models.EfficiencyPoint) []config.EfficiencyPoint {
+	if points == nil {
+		return nil
+	}
+	out := make([]config.EfficiencyPoint, len(points))
+	for i, pt := range points {
+		out[i] = config.EfficiencyPoint{PowerFraction: pt.PowerFraction, Efficiency: pt.Efficiency}
+	}
+	return out
+}
+
+// toStrategyConfig converts the request strategy tree to config form.
+func toStrategyConfig(req models.StrategyConfig) config.StrategyConfig {
+	out := config.StrategyConfig{
+		Name:   req.Name,
+		Params: req.Params,
+	}
+	if req.When != nil {
+		out.When = &config.ConditionConfig{
+			LMPAbove: req.When.LMPAbove,
+			LMPBelow: req.When.LMPBelow,
+			SOCAbove: req.When.SOCAbove,
+			SOCBelow: req.When.SOCBelow,
+			Months:   req.When.Months,
+			Weekdays: req.When.Weekdays,
+			Hours:    req.When.Hours,
+		}
+	}
+	for _, child := range req.Children {
+		out.Children = append(out.Children, toStrategyConfig(child))
+	}
+	return out
+}
+
 func (h *BacktestHandler) mergeConfig(base, override models.BacktestConfig) models.BacktestConfig {
 	merged := base
 	if override.BatteryFile != "" {
@@ -409,37 +825,16 @@ func (h *BacktestHandler) mergeConfig(base, override models.BacktestConfig) mode
 	return merged
 }
 
-func (h *BacktestHandler) buildStrategy(cfg *config.Config, intervals []model.LMPInterval, batt *model.Battery) strategy.Strategy {
-	switch cfg.Strategy.Name {
-	case "schedule":
-		chargeStart := mustStr(cfg.Strategy.Params, "charge_start", "10:00")
-		dischargeStart := mustStr(cfg.Strategy.Params, "discharge_start", "17:00")
-		chargeEnd := mustStr(cfg.Strategy.Params, "charge_end", dischargeStart)
-		dischargeEnd := mustStr(cfg.Strategy.Params, "discharge_end", "23:59")
-		chargeMW := mustNum(cfg.Strategy.Params, "charge_power_mw", cfg.Battery.PowerCapacityMW)
-		dischargeMW := mustNum(cfg.Strategy.Params, "discharge_power_mw", cfg.Battery.PowerCapacityMW)
-		return &strategy.ScheduleStrategy{Params: strategy.ScheduleParams{
-			ChargeStart:      chargeStart,
-			ChargeEnd:        chargeEnd,
-			DischargeStart:   dischargeStart,
-			DischargeEnd:     dischargeEnd,
-			ChargePowerMW:    chargeMW,
-			DischargePowerMW: dischargeMW,
-		}}
-	case "oracle":
-		socSteps := int(mustNum(cfg.Strategy.Params, "soc_steps", 200))
-		powerSteps := int(mustNum(cfg.Strategy.Params, "power_steps", 10))
-		orc, err := strategy.NewOracleStrategy(intervals, batt.Params, batt.State.SOC, strategy.OracleParams{
-			SocSteps:   socSteps,
-			PowerSteps: powerSteps,
-		})
-		if err != nil {
-			panic(err) // Should be handled better
-		}
-		return orc
-	default:
-		panic(fmt.Errorf("unsupported strategy: %q", cfg.Strategy.Name))
-	}
+// buildStrategy builds cfg's strategy; plans may be nil.
+func (h *BacktestHandler) buildStrategy(cfg *config.Config, intervals []model.LMPInterval, batt *model.Battery, plans *builder.Plans) (strategy.Strategy, error) {
+	return builder.Strategy(cfg, intervals, batt, builder.Options{
+		Plans: plans,
+		// Modules and policies are referenced by ID; arbitrary server paths
+		// are never accepted.
+		ResolveModule: wasmModulePath,
+		ResolvePolicy: qlearnPolicyPath,
+		WasmLimits:    wasmLimits,
+	})
 }
 
 func (h *BacktestHandler) buildResponse(result *backtest.Result, includeLedger bool) models.BacktestResponse {
@@ -451,16 +846,40 @@ func (h *BacktestHandler) buildResponse(result *backtest.Result, includeLedger b
 	if includeLedger {
 		response.Ledger = h.convertLedger(result.Ledger)
 	}
+	response.CycleUsage = convertCycleUsage(result.Cycles)
 
 	return response
 }
 
+func convertCycleUsage(u *backtest.CycleUsage) *models.CycleUsage {
+	if u == nil {
+		return nil
+	}
+	convert := func(periods []backtest.CyclePeriod) []models.CyclePeriod {
+		out := make([]models.CyclePeriod, len(periods))
+		for i, p := range periods {
+			out[i] = models.CyclePeriod{Period: p.Period, Cycles: p.Cycles, Allowance: p.Allowance}
+		}
+		return out
+	}
+	return &models.CycleUsage{
+		TotalCycles:      u.TotalCycles,
+		ClippedIntervals: u.ClippedIntervals,
+		Days:             convert(u.Days),
+		Years:            convert(u.Years),
+	}
+}
+
 func (h *BacktestHandler) buildSummary(result *backtest.Result) models.BacktestSummary {
 	if len(result.Ledger) == 0 {
 		return models.BacktestSummary{
 			TotalPNL:       result.TotalPNL,
 			FinalSOC:       result.FinalSOC,
 			TotalIntervals: 0,
+			Settlement:     models.Settlement(result.Settlement),
+			LMPComponents:  convertComponents(result.Components),
+			Emissions:      models.Emissions(result.Emissions),
+			PriceEvents:    models.PriceEvents(result.PriceEvents),
 		}
 	}
 
@@ -611,11 +1030,42 @@ func (h *BacktestHandler) buildSummary(result *backtest.Result) models.BacktestS
 		EnergyDischargedMWh: dischargeTotal,
 		ChargeWindows:       chargeWindows,
 		DischargeWindows:    dischargeWindows,
+		Settlement:          models.Settlement(result.Settlement),
+		LMPComponents:       convertComponents(result.Components),
+		Emissions:           models.Emissions(result.Emissions),
+		PriceEvents:         models.PriceEvents(result.PriceEvents),
 	}
 
 	return summary
 }
 
+func convertScenarioReport(days []strategy.ScenarioDayReport) []models.ScenarioDayReport {
+	out := make([]models.ScenarioDayReport, len(days))
+	for i, d := range days {
+		out[i] = models.ScenarioDayReport{
+			Day:       d.Day,
+			Scenarios: d.Scenarios,
+			Outcomes:  convertDistribution(d.Outcomes),
+			Realized:  d.Realized,
+		}
+	}
+	return out
+}
+
+func convertDistribution(d analysis.Distribution) models.Distribution {
+	return models.Distribution{
+		Count:  d.Count,
+		Mean:   d.Mean,
+		Std:    d.Std,
+		Min:    d.Min,
+		P10:    d.P10,
+		P50:    d.P50,
+		P90:    d.P90,
+		Max:    d.Max,
+		CVaR10: d.CVaR10,
+	}
+}
+
 func (h *BacktestHandler) convertLedger(ledger []backtest.LedgerRow) []models.LedgerRow {
 	result := make([]models.LedgerRow, len(ledger))
 	for i, row := range ledger {
@@ -628,12 +1078,37 @@ func (h *BacktestHandler) convertLedger(ledger []backtest.LedgerRow) []models.Le
 			Location:           row.Location,
 			Market:             row.Market,
 			LMP:                row.LMP,
+			Filled:             row.Filled,
 			Action:             string(row.Action),
+			BidCurve:           row.BidCurve,
+			ClearedMW:          row.ClearedMW,
 			RequestedPowerMW:   row.RequestedPowerMW,
 			PowerMW:            row.PowerMW,
 			EnergyFromGridMWh:  row.EnergyFromGridMWh,
 			EnergyToGridMWh:    row.EnergyToGridMWh,
 			ThroughputMWh:      row.ThroughputMWh,
+			AuxMWh:             row.AuxMWh,
+			SelfDischargeMWh:   row.SelfDischargeMWh,
+			RampClipped:        row.RampClipped,
+			ModeHeld:           row.ModeHeld,
+			SwitchCostUSD:      row.SwitchCostUSD,
+			CycleClipped:       row.CycleClipped,
+			Outage:             row.Outage,
+			ChargingFeeUSD:     row.ChargingFeeUSD,
+			ThroughputFeeUSD:   row.ThroughputFeeUSD,
+			DemandChargeUSD:    row.DemandChargeUSD,
+			FixedChargeUSD:     row.FixedChargeUSD,
+			SettlementPrice:    row.SettlementPrice,
+			BCRUpliftUSD:       row.BCRUpliftUSD,
+			LMPEnergyUSD:       row.LMPEnergyUSD,
+			LMPCongestionUSD:   row.LMPCongestionUSD,
+			LMPLossUSD:         row.LMPLossUSD,
+			LMPOtherUSD:        row.LMPOtherUSD,
+			EmissionsRate:      row.EmissionsRate,
+			EmissionsT:         row.EmissionsT,
+			AmbientTempC:       row.AmbientTempC,
+			CellTempC:          row.CellTempC,
+			Derate:             row.Derate,
 			SOCStart:           row.SOCStart,
 			SOCEnd:             row.SOCEnd,
 			PNL:                row.PNL,
@@ -642,25 +1117,3 @@ func (h *BacktestHandler) convertLedger(ledger []backtest.LedgerRow) []models.Le
 	}
 	return result
 }
-
-// Helper functions (similar to CLI)
-func mustNum(m map[string]interface{}, key string, def float64) float64 {
-	if v, ok := m[key]; ok && v != nil {
-		switch x := v.(type) {
-		case float64:
-			return x
-		case int:
-			return float64(x)
-		}
-	}
-	return def
-}
-
-func mustStr(m map[string]interface{}, key string, def string) string {
-	if v, ok := m[key]; ok && v != nil {
-		if s, ok := v.(string); ok && s != "" {
-			return s
-		}
-	}
-	return def
-}
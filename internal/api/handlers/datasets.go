@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 
 	"battery-backtest/internal/api/models"
 	"battery-backtest/internal/data"
@@ -28,7 +29,15 @@ func ListDatasets(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"datasets": datasets})
 }
 
-// ListLocations handles GET /api/v1/locations
+// Location list page sizes
+const (
+	defaultLocationPageSize = 100
+	maxLocationPageSize     = 1000
+)
+
+// ListLocations handles GET /api/v1/locations. Optional query parameters: q
+// (prefix, substring or fuzzy match on ID and name), type, zone, page and
+// page_size.
 func ListLocations(c *gin.Context) {
 	datasetID := c.Query("dataset_id")
 	if datasetID == "" {
@@ -40,6 +49,16 @@ func ListLocations(c *gin.Context) {
 		})
 		return
 	}
+	page, err := queryInt(c, "page", 1, 1, 0)
+	if err != nil {
+		invalidParam(c, err)
+		return
+	}
+	pageSize, err := queryInt(c, "page_size", defaultLocationPageSize, 1, maxLocationPageSize)
+	if err != nil {
+		invalidParam(c, err)
+		return
+	}
 
 	// Load locations from static file
 	locationList, err := loadLocationsForDataset(datasetID)
@@ -53,27 +72,68 @@ func ListLocations(c *gin.Context) {
 		return
 	}
 
+	matches := data.SearchLocations(locationList.Locations, data.LocationQuery{
+		Search: c.Query("q"),
+		Type:   c.Query("type"),
+		Zone:   c.Query("zone"),
+	})
+	from := min((page-1)*pageSize, len(matches))
+	to := min(from+pageSize, len(matches))
+
 	// Convert to response format
-	locations := make([]models.LocationInfo, len(locationList.Locations))
-	for i, loc := range locationList.Locations {
-		locations[i] = models.LocationInfo{
-			ID:   loc.ID,
-			Name: loc.Name,
-			Type: loc.Type,
-		}
+	locations := make([]models.LocationInfo, 0, to-from)
+	for _, loc := range matches[from:to] {
+		locations = append(locations, models.LocationInfo{
+			ID:        loc.ID,
+			Name:      loc.Name,
+			Type:      loc.Type,
+			Market:    loc.Market,
+			Zone:      loc.Zone,
+			Latitude:  loc.Latitude,
+			Longitude: loc.Longitude,
+		})
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"locations": locations,
+		"locations":  locations,
 		"updated_at": locationList.UpdatedAt,
-		"count": len(locations),
+		"count":      len(locations),
+		"total":      len(matches),
+		"page":       page,
+		"page_size":  pageSize,
 	})
 }
 
+func invalidParam(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		Error: models.ErrorDetail{
+			Code:    "INVALID_PARAM",
+			Message: err.Error(),
+		},
+	})
+}
+
+// queryInt parses an optional integer query parameter in [lo, hi] (hi 0 =
+// unbounded).
+func queryInt(c *gin.Context, name string, def, lo, hi int) (int, error) {
+	v := c.Query(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < lo || (hi > 0 && n > hi) {
+		if hi > 0 {
+			return 0, fmt.Errorf("%s must be an integer between %d and %d", name, lo, hi)
+		}
+		return 0, fmt.Errorf("%s must be an integer >= %d", name, lo)
+	}
+	return n, nil
+}
+
 // loadLocationsForDataset loads locations from the static file
 func loadLocationsForDataset(datasetID string) (*data.LocationList, error) {
 	filePath := data.GetDefaultLocationsPath()
-	
+
 	locationList, err := data.LoadLocations(filePath)
 	if err != nil {
 		// If file doesn't exist, return empty list (not an error)
@@ -87,16 +147,7 @@ func loadLocationsForDataset(datasetID string) (*data.LocationList, error) {
 	}
 
 	// Filter by dataset_id if specified
-	if datasetID != "" && locationList.DatasetID != datasetID {
-		// Filter locations that match the dataset
-		filtered := []data.Location{}
-		for _, loc := range locationList.Locations {
-			if loc.DatasetID == datasetID || loc.DatasetID == "" {
-				filtered = append(filtered, loc)
-			}
-		}
-		locationList.Locations = filtered
-	}
+	locationList.Locations = locationList.ForDataset(datasetID)
 
 	return locationList, nil
 }
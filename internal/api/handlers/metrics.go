@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"battery-backtest/internal/api/models"
+	"battery-backtest/internal/data"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMetrics handles GET /api/v1/metrics
+func GetMetrics(c *gin.Context) {
+	m := data.Metrics.Snapshot()
+	out := models.GridStatusMetrics{
+		Queries:                m.Queries,
+		Requests:               map[string]int64{},
+		Retries:                m.Retries,
+		CacheHits:              m.CacheHits,
+		StoreHits:              m.StoreHits,
+		RateLimitWaits:         m.RateLimitWaits,
+		RateLimitWaitSeconds:   m.RateLimitWait.Seconds(),
+		LatencyCount:           m.LatencyCount,
+		LatencyMaxMilliseconds: float64(m.LatencyMax.Microseconds()) / 1000,
+	}
+	if m.LatencyCount > 0 {
+		out.LatencyMeanMilliseconds = float64(m.LatencyTotal.Microseconds()) / 1000 / float64(m.LatencyCount)
+	}
+	for status, n := range m.Requests {
+		key := strconv.Itoa(status)
+		if status == 0 {
+			key = "error"
+		}
+		out.Requests[key] = n
+	}
+	for i, n := range m.LatencyBuckets {
+		b := models.LatencyBucket{Count: n}
+		if i < len(data.LatencyBuckets) {
+			b.LEMilliseconds = float64(data.LatencyBuckets[i].Milliseconds())
+		}
+		out.LatencyHistogram = append(out.LatencyHistogram, b)
+	}
+	c.JSON(http.StatusOK, gin.H{"gridstatus": out})
+}
@@ -15,12 +15,14 @@ import (
 )
 
 // RankHandler handles ranking-related requests
-type RankHandler struct{}
+type RankHandler struct {
+	jobs *rankJobs
+}
 
-// NewRankHandler creates a new rank handler
+// NewRankHandler creates a new rank handler, loading persisted rank jobs
 func NewRankHandler(gridStatusClient *data.GridStatusClient) *RankHandler {
 	_ = gridStatusClient // Not used anymore - API key comes from request
-	return &RankHandler{}
+	return &RankHandler{jobs: loadRankJobs(rankJobDir())}
 }
 
 // RankNodes handles GET /api/v1/rank
@@ -84,11 +86,11 @@ func (h *RankHandler) RankNodes(c *gin.Context) {
 
 	// Fetch data for each location
 	byLoc := make(map[string][]model.LMPInterval)
-	
+
 	if len(locationIDs) > 0 {
 		// Fetch specific locations
 		for _, locID := range locationIDs {
-			resp, err := client.QueryLocation(data.QueryLocationParams{
+			resp, err := client.QueryLocationContext(c.Request.Context(), data.QueryLocationParams{
 				DatasetID:  req.DatasetID,
 				LocationID: locID,
 				StartTime:  startTime,
@@ -101,9 +103,9 @@ func (h *RankHandler) RankNodes(c *gin.Context) {
 				if gsErr, ok := err.(*data.GridStatusError); ok {
 					// For ranking, we might want to continue with other locations
 					// but log the error. For now, return error on auth/rate limit issues
-					if gsErr.StatusCode == http.StatusForbidden || 
-					   gsErr.StatusCode == http.StatusUnauthorized ||
-					   gsErr.StatusCode == http.StatusTooManyRequests {
+					if gsErr.StatusCode == http.StatusForbidden ||
+						gsErr.StatusCode == http.StatusUnauthorized ||
+						gsErr.StatusCode == http.StatusTooManyRequests {
 						statusCode := http.StatusBadRequest
 						if gsErr.StatusCode == http.StatusForbidden || gsErr.StatusCode == http.StatusUnauthorized {
 							statusCode = http.StatusUnauthorized
@@ -130,12 +132,11 @@ func (h *RankHandler) RankNodes(c *gin.Context) {
 			byLoc[locID] = resp.Data
 		}
 	} else {
-		// TODO: If no locations specified, we'd need to query all locations
-		// For now, return error suggesting specific locations
+		// Screening every location takes too long for one request
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error: models.ErrorDetail{
 				Code:    "LOCATIONS_REQUIRED",
-				Message: "Please specify location_ids query parameter (comma-separated)",
+				Message: "Please specify location_ids query parameter (comma-separated), or screen every location with POST /api/v1/rank/jobs",
 			},
 		})
 		return
@@ -154,7 +155,11 @@ func (h *RankHandler) RankNodes(c *gin.Context) {
 	}
 	ranked = ranked[:limit]
 
-	// Convert to response format
+	c.JSON(http.StatusOK, models.RankResponse{Rankings: convertRankings(ranked)})
+}
+
+// convertRankings converts ranked potentials to the response format
+func convertRankings(ranked []analysis.RankedPotential) []models.Ranking {
 	rankings := make([]models.Ranking, len(ranked))
 	for i, r := range ranked {
 		rankings[i] = models.Ranking{
@@ -166,10 +171,11 @@ func (h *RankHandler) RankNodes(c *gin.Context) {
 			MinLMP:       r.MinLMP,
 			MaxLMP:       r.MaxLMP,
 			OracleProfit: r.OracleProfit,
+
+			CongestionShare: r.CongestionShare,
 		}
 	}
-
-	c.JSON(http.StatusOK, models.RankResponse{Rankings: rankings})
+	return rankings
 }
 
 // validateAPIKeyForRank performs basic validation on the API key
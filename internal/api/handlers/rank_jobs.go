@@ -0,0 +1,469 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"battery-backtest/internal/analysis"
+	"battery-backtest/internal/api/models"
+	"battery-backtest/internal/data"
+	"battery-backtest/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rank job statuses
+const (
+	rankJobRunning     = "running"
+	rankJobCompleted   = "completed"
+	rankJobFailed      = "failed"
+	rankJobCancelled   = "cancelled"
+	rankJobInterrupted = "interrupted" // the server stopped while it ran
+)
+
+// maxRankJobConcurrency caps the locations one job fetches at a time.
+const maxRankJobConcurrency = 16
+
+// rankJobState is the part of a job persisted to <dir>/<id>.json. Results go
+// to the <dir>/<id>.jsonl checkpoint. The API key is never written.
+type rankJobState struct {
+	ID          string     `json:"id"`
+	DatasetID   string     `json:"dataset_id"`
+	StartDate   string     `json:"start_date"`
+	EndDate     string     `json:"end_date"`
+	Locations   []string   `json:"locations"`
+	Concurrency int        `json:"concurrency"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+type rankJob struct {
+	mu      sync.Mutex
+	state   rankJobState
+	results map[string]analysis.ScreenResult
+	cancel  context.CancelFunc
+}
+
+// rankJobs tracks screening jobs and persists them so they survive restarts.
+type rankJobs struct {
+	dir  string
+	mu   sync.Mutex
+	jobs map[string]*rankJob
+}
+
+// rankJobDir returns where rank jobs are persisted.
+func rankJobDir() string {
+	if dir := os.Getenv("RANK_JOB_DIR"); dir != "" {
+		return dir
+	}
+	return "./data/rank-jobs"
+}
+
+// loadRankJobs reads persisted jobs. Jobs that were running when the server
+// stopped are marked interrupted and can be resumed.
+func loadRankJobs(dir string) *rankJobs {
+	js := &rankJobs{dir: dir, jobs: map[string]*rankJob{}}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("RankHandler: skipping job %s: %v", path, err)
+			continue
+		}
+		var st rankJobState
+		if err := json.Unmarshal(raw, &st); err != nil || st.ID == "" {
+			log.Printf("RankHandler: skipping job %s: invalid state", path)
+			continue
+		}
+		results, err := analysis.LoadScreenCheckpoint(js.checkpointPath(st.ID))
+		if err != nil {
+			log.Printf("RankHandler: skipping job %s: %v", st.ID, err)
+			continue
+		}
+		job := &rankJob{state: st, results: results}
+		if st.Status == rankJobRunning {
+			job.state.Status = rankJobInterrupted
+			js.save(job)
+		}
+		js.jobs[st.ID] = job
+	}
+	if len(js.jobs) > 0 {
+		log.Printf("RankHandler: loaded %d rank jobs from %s", len(js.jobs), dir)
+	}
+	return js
+}
+
+func (js *rankJobs) checkpointPath(id string) string {
+	return filepath.Join(js.dir, id+".jsonl")
+}
+
+func (js *rankJobs) get(id string) *rankJob {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	return js.jobs[id]
+}
+
+// save writes the job state; callers hold job.mu or own the job exclusively.
+func (js *rankJobs) save(job *rankJob) {
+	raw, err := json.MarshalIndent(job.state, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(js.dir, 0o755)
+	}
+	if err == nil {
+		tmp := filepath.Join(js.dir, job.state.ID+".json.tmp")
+		if err = os.WriteFile(tmp, raw, 0o644); err == nil {
+			err = os.Rename(tmp, filepath.Join(js.dir, job.state.ID+".json"))
+		}
+	}
+	if err != nil {
+		log.Printf("RankHandler: failed to save job %s: %v", job.state.ID, err)
+	}
+}
+
+// start runs the job's pending locations in the background. It reports false
+// if the job is already running.
+func (js *rankJobs) start(job *rankJob, apiKey string) bool {
+	job.mu.Lock()
+	if job.state.Status == rankJobRunning {
+		job.mu.Unlock()
+		return false
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	job.cancel = cancel
+	job.state.Status = rankJobRunning
+	job.state.Error = ""
+	job.state.FinishedAt = nil
+	st := job.state
+	pending := analysis.PendingLocations(st.Locations, job.results)
+	js.save(job)
+	job.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		err := js.run(ctx, job, st, apiKey, pending)
+
+		job.mu.Lock()
+		defer job.mu.Unlock()
+		now := time.Now().UTC()
+		job.state.FinishedAt = &now
+		job.cancel = nil
+		switch {
+		case errors.Is(err, context.Canceled):
+			job.state.Status = rankJobCancelled
+		case err != nil:
+			job.state.Status = rankJobFailed
+			job.state.Error = err.Error()
+		default:
+			job.state.Status = rankJobCompleted
+		}
+		js.save(job)
+		log.Printf("RankHandler: job %s %s", st.ID, job.state.Status)
+	}()
+	return true
+}
+
+func (js *rankJobs) run(ctx context.Context, job *rankJob, st rankJobState, apiKey string, pending []string) error {
+	from, err := time.Parse("2006-01-02", st.StartDate)
+	if err != nil {
+		return err
+	}
+	to, err := time.Parse("2006-01-02", st.EndDate)
+	if err != nil {
+		return err
+	}
+	cp, err := analysis.OpenScreenCheckpoint(js.checkpointPath(st.ID))
+	if err != nil {
+		return err
+	}
+	defer cp.Close()
+
+	client := data.NewGridStatusClient(apiKey, "")
+	fetch := func(ctx context.Context, loc string) ([]model.LMPInterval, error) {
+		resp, err := client.QueryLocationContext(ctx, data.QueryLocationParams{
+			DatasetID:  st.DatasetID,
+			LocationID: loc,
+			StartTime:  from,
+			EndTime:    to,
+			Timezone:   data.TimezoneMarket,
+			Download:   true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Data, nil
+	}
+	log.Printf("RankHandler: job %s screening %d of %d locations", st.ID, len(pending), len(st.Locations))
+	return analysis.Screen(ctx, pending, st.Concurrency, fetch, func(r analysis.ScreenResult, err error) error {
+		job.mu.Lock()
+		job.results[r.Location] = r
+		job.mu.Unlock()
+		if werr := cp.Append(r); werr != nil {
+			return werr
+		}
+		if data.IsAuthError(err) {
+			return err
+		}
+		return nil
+	})
+}
+
+// response reports the job with its top limit rankings.
+func (job *rankJob) response(limit int) models.RankJobResponse {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	st := job.state
+	resp := models.RankJobResponse{
+		ID:         st.ID,
+		Status:     st.Status,
+		DatasetID:  st.DatasetID,
+		StartDate:  st.StartDate,
+		EndDate:    st.EndDate,
+		Total:      len(st.Locations),
+		Error:      st.Error,
+		CreatedAt:  st.CreatedAt,
+		FinishedAt: st.FinishedAt,
+	}
+	results := make([]analysis.ScreenResult, 0, len(job.results))
+	for _, id := range st.Locations {
+		r, ok := job.results[id]
+		if !ok {
+			continue
+		}
+		results = append(results, r)
+		if r.Potential != nil {
+			resp.Completed++
+		} else {
+			resp.Failed++
+			resp.Failures = append(resp.Failures, models.RankJobFailure{Location: id, Error: r.Error})
+		}
+	}
+	ranked := analysis.RankScreen(results)
+	if limit < len(ranked) {
+		ranked = ranked[:limit]
+	}
+	resp.Rankings = convertRankings(ranked)
+	return resp
+}
+
+// CreateRankJob handles POST /api/v1/rank/jobs. It screens every location of
+// the dataset in the catalog (or location_ids) in the background and returns
+// 202 with the job; poll GetRankJob for progress and the ranking so far.
+func (h *RankHandler) CreateRankJob(c *gin.Context) {
+	var req models.RankJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+	if err := validateAPIKeyForRank(req.APIKey); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INVALID_API_KEY",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+	from, err1 := time.Parse("2006-01-02", req.StartDate)
+	to, err2 := time.Parse("2006-01-02", req.EndDate)
+	if err1 != nil || err2 != nil || !to.After(from) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INVALID_DATE",
+				Message: "start_date and end_date must be in YYYY-MM-DD format with end_date after start_date",
+			},
+		})
+		return
+	}
+	if req.Concurrency < 0 || req.Concurrency > maxRankJobConcurrency {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: fmt.Sprintf("concurrency must be between 1 and %d", maxRankJobConcurrency),
+			},
+		})
+		return
+	}
+
+	locations := make([]string, 0, len(req.LocationIDs))
+	seen := map[string]bool{}
+	for _, id := range req.LocationIDs {
+		if id = strings.TrimSpace(id); id != "" && !seen[id] {
+			seen[id] = true
+			locations = append(locations, id)
+		}
+	}
+	if len(req.LocationIDs) == 0 {
+		list, err := loadLocationsForDataset(req.DatasetID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "LOCATIONS_LOAD_ERROR",
+					Message: fmt.Sprintf("Failed to load locations: %v", err),
+				},
+			})
+			return
+		}
+		for _, loc := range data.SearchLocations(list.Locations, data.LocationQuery{Type: req.LocationType, Zone: req.Zone}) {
+			locations = append(locations, loc.ID)
+		}
+	}
+	if len(locations) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "NO_LOCATIONS",
+				Message: fmt.Sprintf("No locations to screen for dataset %s; pass location_ids or build the catalog with cmd/update-locations", req.DatasetID),
+			},
+		})
+		return
+	}
+
+	id, err := newRankJobID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+	concurrency := req.Concurrency
+	if concurrency == 0 {
+		concurrency = 4
+	}
+	job := &rankJob{
+		state: rankJobState{
+			ID:          id,
+			DatasetID:   req.DatasetID,
+			StartDate:   req.StartDate,
+			EndDate:     req.EndDate,
+			Locations:   locations,
+			Concurrency: concurrency,
+			CreatedAt:   time.Now().UTC(),
+		},
+		results: map[string]analysis.ScreenResult{},
+	}
+	h.jobs.mu.Lock()
+	h.jobs.jobs[id] = job
+	h.jobs.mu.Unlock()
+	h.jobs.start(job, req.APIKey)
+
+	c.JSON(http.StatusAccepted, job.response(10))
+}
+
+// GetRankJob handles GET /api/v1/rank/jobs/:id. The optional limit query
+// parameter (default 10) caps the rankings returned.
+func (h *RankHandler) GetRankJob(c *gin.Context) {
+	job := h.findJob(c)
+	if job == nil {
+		return
+	}
+	limit := 10
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "INVALID_PARAM",
+					Message: "limit must be a positive integer",
+				},
+			})
+			return
+		}
+		limit = n
+	}
+	c.JSON(http.StatusOK, job.response(limit))
+}
+
+// ResumeRankJob handles POST /api/v1/rank/jobs/:id/resume. It screens the
+// locations the job has not scored yet, including failed ones.
+func (h *RankHandler) ResumeRankJob(c *gin.Context) {
+	var req models.RankJobResumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+	if err := validateAPIKeyForRank(req.APIKey); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INVALID_API_KEY",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+	job := h.findJob(c)
+	if job == nil {
+		return
+	}
+	if !h.jobs.start(job, req.APIKey) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "JOB_RUNNING",
+				Message: "Rank job is already running",
+			},
+		})
+		return
+	}
+	c.JSON(http.StatusAccepted, job.response(10))
+}
+
+// CancelRankJob handles DELETE /api/v1/rank/jobs/:id. Locations in flight
+// finish first; the job can be resumed later.
+func (h *RankHandler) CancelRankJob(c *gin.Context) {
+	job := h.findJob(c)
+	if job == nil {
+		return
+	}
+	job.mu.Lock()
+	if job.cancel != nil {
+		job.cancel()
+	}
+	job.mu.Unlock()
+	c.JSON(http.StatusAccepted, job.response(10))
+}
+
+func (h *RankHandler) findJob(c *gin.Context) *rankJob {
+	job := h.jobs.get(c.Param("id"))
+	if job == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "JOB_NOT_FOUND",
+				Message: "Rank job not found",
+			},
+		})
+	}
+	return job
+}
+
+func newRankJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -1,10 +1,22 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"battery-backtest/internal/api/models"
+	"battery-backtest/internal/builder"
+	"battery-backtest/internal/strategy"
 
 	"github.com/gin-gonic/gin"
 )
@@ -79,6 +91,139 @@ func (h *StrategyHandler) ListStrategies(c *gin.Context) {
 					Description: "Number of power discretization steps",
 					Default:     10,
 				},
+				{
+					Name:        "carbon_price",
+					Type:        "float",
+					Description: "$/tCO2 charged on net emissions in the objective (needs emissions rates; 0 = profit only)",
+					Default:     0.0,
+				},
+			},
+		},
+		{
+			Name:        "stochastic",
+			Description: "Scenario-based optimizer. Builds one non-anticipative schedule per day that maximizes expected profit (or CVaR) over price scenarios taken from earlier analog days.",
+			Parameters: []models.ParameterInfo{
+				{
+					Name:        "scenarios",
+					Type:        "int",
+					Description: "Number of past analog days (same weekday/weekend type) used as scenarios",
+					Default:     10,
+				},
+				{
+					Name:        "objective",
+					Type:        "string",
+					Description: "'expected' or 'cvar'",
+					Default:     "expected",
+				},
+				{
+					Name:        "cvar_alpha",
+					Type:        "float",
+					Description: "Tail fraction for the cvar objective",
+					Default:     0.1,
+				},
+				{
+					Name:        "soc_steps",
+					Type:        "int",
+					Description: "Number of SOC discretization steps",
+					Default:     200,
+				},
+				{
+					Name:        "power_steps",
+					Type:        "int",
+					Description: "Number of power discretization steps",
+					Default:     10,
+				},
+			},
+		},
+		{
+			Name:        "wasm",
+			Description: "User-supplied WebAssembly strategy. Upload a module via POST /api/v1/strategies/wasm and reference the returned ID.",
+			Parameters: []models.ParameterInfo{
+				{
+					Name:        "module",
+					Type:        "string",
+					Description: "Module ID returned by the upload endpoint",
+				},
+				{
+					Name:        "max_memory_pages",
+					Type:        "int",
+					Description: "Linear memory cap in 64 KiB pages",
+					Default:     512,
+				},
+				{
+					Name:        "call_timeout_ms",
+					Type:        "int",
+					Description: "Execution budget per decide call in milliseconds",
+					Default:     100,
+				},
+			},
+		},
+		{
+			Name:        "qlearn",
+			Description: "Tabular Q-learning policy trained offline with `cli train`. Policy files are read from QLEARN_POLICY_DIR.",
+			Parameters: []models.ParameterInfo{
+				{
+					Name:        "policy",
+					Type:        "string",
+					Description: "File name of the trained policy JSON",
+				},
+			},
+		},
+		{
+			Name:        "bidcurve",
+			Description: "Submits a price-quantity bid/offer curve each interval; the engine clears it against the realized LMP",
+			Parameters: []models.ParameterInfo{
+				{
+					Name:        "segments",
+					Type:        "array",
+					Description: "List of {power_mw, price}. Positive MW offers discharge when LMP >= price; negative MW bids to charge when LMP <= price",
+					Default:     nil,
+				},
+			},
+		},
+		{
+			Name:        "constant",
+			Description: "Always requests the same power. Useful as an override inside an overlay.",
+			Parameters: []models.ParameterInfo{
+				{
+					Name:        "power_mw",
+					Type:        "float",
+					Description: "Requested power in MW (positive = discharge, negative = charge)",
+					Default:     0.0,
+				},
+			},
+		},
+		{
+			Name:        "overlay",
+			Description: "Priority overlay. Children are tried in order; the first whose 'when' condition matches (or has none) is used.",
+			Parameters:  []models.ParameterInfo{},
+		},
+		{
+			Name:        "calendar",
+			Description: "Switch by calendar. Like overlay, but child conditions may only use months, weekdays and hours.",
+			Parameters:  []models.ParameterInfo{},
+		},
+		{
+			Name:        "guard",
+			Description: "Veto. Wraps one child; when the guard's 'when' condition matches, requests in the forbidden direction become idle.",
+			Parameters: []models.ParameterInfo{
+				{
+					Name:        "forbid",
+					Type:        "string",
+					Description: "Direction to veto: 'charge', 'discharge' or 'both'",
+					Default:     "both",
+				},
+			},
+		},
+		{
+			Name:        "blend",
+			Description: "Weighted blend. Requests the weighted average of its children's requests.",
+			Parameters: []models.ParameterInfo{
+				{
+					Name:        "weights",
+					Type:        "[]float",
+					Description: "One weight per child (normalized to sum to 1; default equal)",
+				},
 			},
 		},
 	}
@@ -86,3 +231,188 @@ func (h *StrategyHandler) ListStrategies(c *gin.Context) {
 	log.Printf("StrategyHandler: Returning %d strategies", len(strategies))
 	c.JSON(http.StatusOK, gin.H{"strategies": strategies})
 }
+
+// maxWasmUploadBytes caps uploaded module size.
+const maxWasmUploadBytes = 16 << 20
+
+var wasmModuleIDPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// UploadWasmModule handles POST /api/v1/strategies/wasm
+//
+// Accepts either a multipart form with a "module" file field or a raw
+// application/wasm body. Modules are stored content-addressed (sha256) under
+// WASM_MODULE_DIR and validated against the strategy ABI before being accepted.
+func (h *StrategyHandler) UploadWasmModule(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxWasmUploadBytes)
+
+	var raw []byte
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, err := c.FormFile("module")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "INVALID_REQUEST",
+					Message: "multipart upload requires a \"module\" file field",
+				},
+			})
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "INVALID_REQUEST",
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+		defer f.Close()
+		raw, err = io.ReadAll(f)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "INVALID_REQUEST",
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+	} else {
+		var err error
+		raw, err = io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+				Error: models.ErrorDetail{
+					Code:    "MODULE_TOO_LARGE",
+					Message: fmt.Sprintf("module must be at most %d bytes", maxWasmUploadBytes),
+				},
+			})
+			return
+		}
+	}
+	if len(raw) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "module body is empty",
+			},
+		})
+		return
+	}
+
+	if err := strategy.ValidateWasmModule(raw); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "INVALID_WASM_MODULE",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	sum := sha256.Sum256(raw)
+	id := hex.EncodeToString(sum[:])
+	dir := wasmModuleDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "MODULE_STORE_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+	if err := os.WriteFile(filepath.Join(dir, id+".wasm"), raw, 0o644); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: models.ErrorDetail{
+				Code:    "MODULE_STORE_ERROR",
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	log.Printf("StrategyHandler: Stored wasm module %s (%d bytes)", id, len(raw))
+	c.JSON(http.StatusCreated, models.WasmModuleResponse{
+		Module:    id,
+		SizeBytes: len(raw),
+	})
+}
+
+// wasmModuleDir returns where uploaded modules are stored.
+func wasmModuleDir() string {
+	if dir := os.Getenv("WASM_MODULE_DIR"); dir != "" {
+		return dir
+	}
+	return "./data/wasm"
+}
+
+// Ceilings for the per-request WASM sandbox limits. WASM_MAX_MEMORY_PAGES and
+// WASM_MAX_CALL_TIMEOUT_MS may lower them, never raise them.
+const (
+	wasmMemoryPagesCeiling   = 512
+	wasmCallTimeoutMsCeiling = 100
+)
+
+// wasmLimits reads params.max_memory_pages and params.call_timeout_ms. Unset
+// values get the server maximum; values above it are rejected.
+func wasmLimits(params map[string]any) (strategy.WasmLimits, error) {
+	maxPages := envIntCapped("WASM_MAX_MEMORY_PAGES", wasmMemoryPagesCeiling)
+	maxTimeoutMs := envIntCapped("WASM_MAX_CALL_TIMEOUT_MS", wasmCallTimeoutMsCeiling)
+
+	pages := builder.Num(params, "max_memory_pages", float64(maxPages))
+	if pages < 1 || pages > float64(maxPages) {
+		return strategy.WasmLimits{}, fmt.Errorf("max_memory_pages must be between 1 and %d", maxPages)
+	}
+	timeoutMs := builder.Num(params, "call_timeout_ms", float64(maxTimeoutMs))
+	if timeoutMs < 1 || timeoutMs > float64(maxTimeoutMs) {
+		return strategy.WasmLimits{}, fmt.Errorf("call_timeout_ms must be between 1 and %d", maxTimeoutMs)
+	}
+	return strategy.WasmLimits{
+		MaxMemoryPages: uint32(pages),
+		CallTimeout:    time.Duration(timeoutMs * float64(time.Millisecond)),
+	}, nil
+}
+
+// envIntCapped reads a positive integer from the environment, falling back to
+// ceiling when it is unset, invalid or larger.
+func envIntCapped(name string, ceiling int) int {
+	n, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || n < 1 || n > ceiling {
+		return ceiling
+	}
+	return n
+}
+
+// wasmModulePath resolves an uploaded module ID to its file.
+func wasmModulePath(id string) (string, error) {
+	if id == "" {
+		return "", fmt.Errorf("wasm strategy requires params.module")
+	}
+	if !wasmModuleIDPattern.MatchString(id) {
+		return "", fmt.Errorf("invalid wasm module id %q", id)
+	}
+	path := filepath.Join(wasmModuleDir(), id+".wasm")
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("wasm module %s not found; upload it via POST /api/v1/strategies/wasm", id)
+	}
+	return path, nil
+}
+
+// qlearnPolicyPath resolves a policy file name (as written by `cli train`)
+// under QLEARN_POLICY_DIR.
+func qlearnPolicyPath(name string) (string, error) {
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid qlearn policy %q: expected a file name in the policy directory", name)
+	}
+	dir := os.Getenv("QLEARN_POLICY_DIR")
+	if dir == "" {
+		dir = "./data/policies"
+	}
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("qlearn policy %s not found", name)
+	}
+	return path, nil
+}
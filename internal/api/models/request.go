@@ -1,8 +1,10 @@
 package models
 
+import "time"
+
 // BacktestRequest represents the request body for running a backtest
 type BacktestRequest struct {
-	APIKey     string           `json:"api_key" binding:"required"` // Grid Status API key
+	APIKey     string           `json:"api_key"` // Grid Status API key (not needed for synthetic data)
 	DataSource DataSourceConfig `json:"data_source" binding:"required"`
 	Config     BacktestConfig   `json:"config" binding:"required"`
 	Options    BacktestOptions  `json:"options,omitempty"`
@@ -10,38 +12,195 @@ type BacktestRequest struct {
 
 // DataSourceConfig defines how to fetch market data
 type DataSourceConfig struct {
-	Type       string `json:"type" binding:"required"` // "gridstatus"
-	DatasetID  string `json:"dataset_id" binding:"required"`
-	LocationID string `json:"location_id" binding:"required"`
+	Type       string `json:"type" binding:"required"`       // "gridstatus" or "synthetic"
+	DatasetID  string `json:"dataset_id"`                    // Required for gridstatus
+	LocationID string `json:"location_id"`                   // Required for gridstatus
 	StartDate  string `json:"start_date" binding:"required"` // YYYY-MM-DD
 	EndDate    string `json:"end_date" binding:"required"`   // YYYY-MM-DD
-	Timezone   string `json:"timezone,omitempty"`             // default: "market"
+	Timezone   string `json:"timezone,omitempty"`            // default: "market"
+
+	// AmbientTemperature is an optional series joined to the intervals for the thermal model
+	AmbientTemperature []TemperaturePoint `json:"ambient_temperature,omitempty"`
+
+	// MarginalEmissions is an optional tCO2/MWh series joined to the intervals.
+	// Intervals it does not cover fall back to GHG / GHGPricePerTCO2 when set.
+	MarginalEmissions []EmissionsPoint `json:"marginal_emissions,omitempty"`
+	GHGPricePerTCO2   float64          `json:"ghg_price_per_tco2,omitempty"`
+
+	// Repair is the data repair policy: none (default), drop, ffill, interpolate or unavailable
+	Repair         string  `json:"repair,omitempty"`
+	OutlierMADs    float64 `json:"outlier_mads,omitempty"`    // Outlier threshold in scaled MADs (default: 15, negative disables)
+	RepairOutliers bool    `json:"repair_outliers,omitempty"` // Also repair outliers per Repair (default: report only)
+
+	// Resample converts the series to a fixed step (Go duration, e.g. "1h", "15m")
+	Resample       string `json:"resample,omitempty"`
+	ResampleMethod string `json:"resample_method,omitempty"` // mean, time_weighted (default), min or max
+
+	// Synthetic configures the generated series when Type is "synthetic"
+	Synthetic *SyntheticConfig `json:"synthetic,omitempty"`
+}
+
+// SyntheticConfig configures a seeded synthetic price series over
+// start_date..end_date. For rates and sizes 0 takes the default and a
+// negative value turns the component off (see synthetic.Params).
+type SyntheticConfig struct {
+	Seed              int64     `json:"seed"`
+	StepMinutes       int       `json:"step_minutes,omitempty"`       // default: 5
+	BasePrice         float64   `json:"base_price,omitempty"`         // default: 40
+	DailyAmplitude    float64   `json:"daily_amplitude,omitempty"`    // default: 25
+	HourlyShape       []float64 `json:"hourly_shape,omitempty"`       // 24 values in [-1, 1]
+	SeasonalAmplitude float64   `json:"seasonal_amplitude,omitempty"` // default: 0.25
+	Volatility        float64   `json:"volatility,omitempty"`         // default: 6
+	MeanReversion     float64   `json:"mean_reversion,omitempty"`     // per hour, default: 0.5
+	SpikesPerDay      float64   `json:"spikes_per_day,omitempty"`     // default: 0.3
+	SpikeMean         float64   `json:"spike_mean,omitempty"`         // default: 300
+	SpikeDecayHours   float64   `json:"spike_decay_hours,omitempty"`  // default: 0.5
+	NegativePerDay    float64   `json:"negative_per_day,omitempty"`   // default: 0.1
+	NegativePrice     float64   `json:"negative_price,omitempty"`     // default: -25
+	NegativeHours     float64   `json:"negative_hours,omitempty"`     // default: 2
+}
+
+// EmissionsPoint is one marginal emissions rate observation
+type EmissionsPoint struct {
+	Time    time.Time `json:"time"` // RFC3339
+	TPerMWh float64   `json:"t_per_mwh"`
+}
+
+// TemperaturePoint is one ambient temperature observation
+type TemperaturePoint struct {
+	Time  time.Time `json:"time"` // RFC3339
+	TempC float64   `json:"temp_c"`
 }
 
 // BacktestConfig contains battery and strategy configuration
 type BacktestConfig struct {
-	BatteryFile string                 `json:"battery_file,omitempty"`
-	Battery     BatteryConfig          `json:"battery,omitempty"`
-	Strategy    StrategyConfig         `json:"strategy" binding:"required"`
+	BatteryFile string         `json:"battery_file,omitempty"`
+	Battery     BatteryConfig  `json:"battery,omitempty"`
+	Strategy    StrategyConfig `json:"strategy" binding:"required"`
+
+	// Availability enables outage sampling and Monte Carlo trials
+	Availability *AvailabilityConfig `json:"availability,omitempty"`
+
+	// Bootstrap reruns the backtest on price paths resampled from the history
+	Bootstrap *BootstrapConfig `json:"bootstrap,omitempty"`
+}
+
+// BootstrapConfig defines block-bootstrap price paths
+type BootstrapConfig struct {
+	Paths     int   `json:"paths,omitempty"`      // default: 20, max: 1000
+	BlockDays int   `json:"block_days,omitempty"` // Consecutive days drawn together (default: 1)
+	Seed      int64 `json:"seed,omitempty"`
+}
+
+// AvailabilityConfig defines forced outages, planned maintenance and trials
+type AvailabilityConfig struct {
+	ForcedOutageRate float64             `json:"forced_outage_rate"` // Long-run fraction of time on forced outage
+	MTTRHours        float64             `json:"mttr_hours"`         // Mean time to repair
+	Maintenance      []MaintenanceWindow `json:"maintenance,omitempty"`
+	Trials           int                 `json:"trials,omitempty"` // default: 20, max: 1000
+	Seed             int64               `json:"seed,omitempty"`
+}
+
+// MaintenanceWindow is a planned outage
+type MaintenanceWindow struct {
+	Start time.Time `json:"start"` // RFC3339
+	End   time.Time `json:"end"`   // RFC3339
 }
 
 // BatteryConfig defines battery parameters
 type BatteryConfig struct {
-	Name                 string  `json:"name,omitempty"`
-	EnergyCapacityMWh    float64 `json:"energy_capacity_mwh"`
-	PowerCapacityMW      float64 `json:"power_capacity_mw"`
-	ChargeEfficiency     float64 `json:"charge_efficiency"`
-	DischargeEfficiency  float64 `json:"discharge_efficiency"`
-	MinSOC               float64 `json:"min_soc"`
-	MaxSOC               float64 `json:"max_soc"`
-	InitialSOC           float64 `json:"initial_soc,omitempty"`
+	Name                  string  `json:"name,omitempty"`
+	EnergyCapacityMWh     float64 `json:"energy_capacity_mwh"`
+	PowerCapacityMW       float64 `json:"power_capacity_mw"`
+	ChargeEfficiency      float64 `json:"charge_efficiency"`
+	DischargeEfficiency   float64 `json:"discharge_efficiency"`
+	MinSOC                float64 `json:"min_soc"`
+	MaxSOC                float64 `json:"max_soc"`
+	InitialSOC            float64 `json:"initial_soc,omitempty"`
 	DegradationCostPerMWh float64 `json:"degradation_cost_per_mwh,omitempty"`
+
+	MaxChargeMW         float64           `json:"max_charge_mw,omitempty"`
+	MaxDischargeMW      float64           `json:"max_discharge_mw,omitempty"`
+	ChargePowerCurve    []PowerCurvePoint `json:"charge_power_curve,omitempty"`
+	DischargePowerCurve []PowerCurvePoint `json:"discharge_power_curve,omitempty"`
+
+	ChargeEfficiencyCurve    []EfficiencyPoint `json:"charge_efficiency_curve,omitempty"`
+	DischargeEfficiencyCurve []EfficiencyPoint `json:"discharge_efficiency_curve,omitempty"`
+	AuxLoadMW                float64           `json:"aux_load_mw,omitempty"`
+	Islanded                 bool              `json:"islanded,omitempty"`
+	SelfDischargePctPerDay   float64           `json:"self_discharge_pct_per_day,omitempty"`
+
+	RampRateMWPerMin  float64 `json:"ramp_rate_mw_per_min,omitempty"`
+	MinModeMinutes    float64 `json:"min_mode_minutes,omitempty"`
+	ModeSwitchCostUSD float64 `json:"mode_switch_cost_usd,omitempty"`
+
+	MaxCyclesPerDay  float64 `json:"max_cycles_per_day,omitempty"`
+	MaxCyclesPerYear float64 `json:"max_cycles_per_year,omitempty"`
+
+	Thermal *ThermalConfig `json:"thermal,omitempty"`
+
+	Settlement *SettlementConfig `json:"settlement,omitempty"`
+}
+
+// SettlementConfig defines fees and charges beyond the LMP
+type SettlementConfig struct {
+	ChargingFeePerMWh      float64 `json:"charging_fee_per_mwh"`       // On charging energy
+	ThroughputFeePerMWh    float64 `json:"throughput_fee_per_mwh"`     // On charge + discharge energy
+	DemandChargePerMWMonth float64 `json:"demand_charge_per_mw_month"` // On the monthly peak grid draw
+	FixedChargePerMWMonth  float64 `json:"fixed_charge_per_mw_month"`  // On power capacity, prorated
+
+	PriceFloor      *float64 `json:"price_floor,omitempty"`       // Settlement price floor ($/MWh)
+	PriceCap        *float64 `json:"price_cap,omitempty"`         // Settlement price cap ($/MWh)
+	ScarcityPrice   float64  `json:"scarcity_price,omitempty"`    // Scarcity event threshold (default: price_cap)
+	BidCostRecovery bool     `json:"bid_cost_recovery,omitempty"` // Daily make-whole on discharge
+	BidCostPerMWh   float64  `json:"bid_cost_per_mwh,omitempty"`  // default: degradation_cost_per_mwh
+}
+
+// ThermalConfig configures the lumped cell temperature model
+type ThermalConfig struct {
+	HeatCapacityMWhPerC float64 `json:"heat_capacity_mwh_per_c"`
+	ConductanceMWPerC   float64 `json:"conductance_mw_per_c"`
+	HVACSetpointC       float64 `json:"hvac_setpoint_c"`
+	HVACCoolingMW       float64 `json:"hvac_cooling_mw"`
+	HVACCOP             float64 `json:"hvac_cop"`
+	DefaultAmbientC     float64 `json:"default_ambient_c"`
+	DerateStartC        float64 `json:"derate_start_c"`
+	DerateEndC          float64 `json:"derate_end_c"`
+	MinDerate           float64 `json:"min_derate"`
+	ReferenceTempC      float64 `json:"reference_temp_c"`
+	EfficiencyLossPerC  float64 `json:"efficiency_loss_per_c"`
 }
 
-// StrategyConfig defines strategy and its parameters
+// EfficiencyPoint is one point of an efficiency-vs-power-fraction curve
+type EfficiencyPoint struct {
+	PowerFraction float64 `json:"power_fraction"`
+	Efficiency    float64 `json:"efficiency"`
+}
+
+// PowerCurvePoint is one point of a SOC-to-max-power taper curve
+type PowerCurvePoint struct {
+	SOC   float64 `json:"soc"`
+	MaxMW float64 `json:"max_mw"`
+}
+
+// StrategyConfig defines strategy and its parameters.
+// Combinators (overlay, calendar, guard, blend) nest other strategies in Children.
 type StrategyConfig struct {
-	Name   string                 `json:"name" binding:"required"`
-	Params map[string]interface{} `json:"params,omitempty"`
+	Name     string                 `json:"name" binding:"required"`
+	Params   map[string]interface{} `json:"params,omitempty"`
+	When     *ConditionConfig       `json:"when,omitempty"`
+	Children []StrategyConfig       `json:"children,omitempty"`
+}
+
+// ConditionConfig gates a child strategy (see config.ConditionConfig)
+type ConditionConfig struct {
+	LMPAbove *float64 `json:"lmp_above,omitempty"`
+	LMPBelow *float64 `json:"lmp_below,omitempty"`
+	SOCAbove *float64 `json:"soc_above,omitempty"`
+	SOCBelow *float64 `json:"soc_below,omitempty"`
+	Months   []int    `json:"months,omitempty"`
+	Weekdays []string `json:"weekdays,omitempty"`
+	Hours    []int    `json:"hours,omitempty"`
 }
 
 // BacktestOptions contains optional backtest parameters
@@ -52,24 +211,41 @@ type BacktestOptions struct {
 
 // CompareBacktestRequest represents a request to compare multiple backtests
 type CompareBacktestRequest struct {
-	APIKey     string                 `json:"api_key" binding:"required"` // Grid Status API key
-	DataSource DataSourceConfig      `json:"data_source" binding:"required"`
-	BaseConfig BacktestConfig        `json:"base_config" binding:"required"`
-	Variations []BacktestVariation   `json:"variations" binding:"required"`
+	APIKey     string              `json:"api_key"` // Grid Status API key (not needed for synthetic data)
+	DataSource DataSourceConfig    `json:"data_source" binding:"required"`
+	BaseConfig BacktestConfig      `json:"base_config" binding:"required"`
+	Variations []BacktestVariation `json:"variations" binding:"required"`
 }
 
 // BacktestVariation defines a variation to test
 type BacktestVariation struct {
-	Name    string          `json:"name" binding:"required"`
-	Config  BacktestConfig  `json:"config" binding:"required"`
+	Name   string         `json:"name" binding:"required"`
+	Config BacktestConfig `json:"config" binding:"required"`
 }
 
 // RankRequest represents a request to rank nodes
 type RankRequest struct {
-	APIKey     string  `form:"api_key" binding:"required"` // Grid Status API key
-	DatasetID  string  `form:"dataset_id" binding:"required"`
-	StartDate  string  `form:"start_date" binding:"required"`
-	EndDate    string  `form:"end_date" binding:"required"`
-	LocationIDs string  `form:"location_ids,omitempty"` // comma-separated
-	Limit      int      `form:"limit,omitempty"`       // default: 10
+	APIKey      string `form:"api_key" binding:"required"` // Grid Status API key
+	DatasetID   string `form:"dataset_id" binding:"required"`
+	StartDate   string `form:"start_date" binding:"required"`
+	EndDate     string `form:"end_date" binding:"required"`
+	LocationIDs string `form:"location_ids,omitempty"` // comma-separated
+	Limit       int    `form:"limit,omitempty"`        // default: 10
+}
+
+// RankJobRequest starts an asynchronous screen of many locations
+type RankJobRequest struct {
+	APIKey       string   `json:"api_key" binding:"required"` // Grid Status API key (used while the job runs, never stored)
+	DatasetID    string   `json:"dataset_id" binding:"required"`
+	StartDate    string   `json:"start_date" binding:"required"`
+	EndDate      string   `json:"end_date" binding:"required"`
+	LocationIDs  []string `json:"location_ids,omitempty"`  // default: every location of the dataset in the catalog
+	LocationType string   `json:"location_type,omitempty"` // optional catalog filter
+	Zone         string   `json:"zone,omitempty"`          // optional catalog filter
+	Concurrency  int      `json:"concurrency,omitempty"`   // locations fetched at a time (default: 4, max: 16)
+}
+
+// RankJobResumeRequest resumes a stopped rank job
+type RankJobResumeRequest struct {
+	APIKey string `json:"api_key" binding:"required"`
 }
@@ -4,22 +4,183 @@ import "time"
 
 // BacktestResponse represents the response from a backtest run
 type BacktestResponse struct {
-	ID      string           `json:"id,omitempty"`
-	Status  string           `json:"status"`
-	Summary BacktestSummary  `json:"summary"`
-	Ledger  []LedgerRow      `json:"ledger,omitempty"`
+	ID      string          `json:"id,omitempty"`
+	Status  string          `json:"status"`
+	Summary BacktestSummary `json:"summary"`
+	Ledger  []LedgerRow     `json:"ledger,omitempty"`
+
+	// Scenarios is set for the stochastic strategy: per-day outcome distributions.
+	Scenarios []ScenarioDayReport `json:"scenarios,omitempty"`
+
+	// CycleUsage reports equivalent full cycles against warranty caps.
+	CycleUsage *CycleUsage `json:"cycle_usage,omitempty"`
+
+	// MonteCarlo is set when availability is configured; summary/ledger are trial 0.
+	MonteCarlo *MonteCarloResult `json:"monte_carlo,omitempty"`
+
+	// DataQuality is the validation report of the input series and any repairs.
+	DataQuality *DataQualityReport `json:"data_quality,omitempty"`
+
+	// Bootstrap is set when bootstrap is configured: PnL over resampled price paths.
+	Bootstrap *BootstrapResult `json:"bootstrap,omitempty"`
+}
+
+// BootstrapResult summarizes backtests over block-bootstrapped price paths
+type BootstrapResult struct {
+	BlockDays int             `json:"block_days"`
+	PNL       Distribution    `json:"pnl"`
+	Paths     []BootstrapPath `json:"paths"`
+}
+
+// BootstrapPath is the backtest of one resampled price path
+type BootstrapPath struct {
+	Path     int     `json:"path"`
+	TotalPNL float64 `json:"total_pnl"`
+}
+
+// DataQualityReport describes issues found in the interval series
+type DataQualityReport struct {
+	Intervals        int            `json:"intervals"`
+	IntervalMinutes  float64        `json:"interval_minutes"` // Most common interval length
+	Counts           map[string]int `json:"counts"`           // Issues by kind
+	Issues           []DataIssue    `json:"issues,omitempty"` // First 100 issues
+	MissingIntervals int            `json:"missing_intervals"`
+	Policy           string         `json:"policy"`
+	Dropped          int            `json:"dropped"`
+	Filled           int            `json:"filled"`
+	Unavailable      int            `json:"unavailable"`
+}
+
+// DataIssue is one data-quality finding; index refers to the fetched order
+type DataIssue struct {
+	Kind   string    `json:"kind"`
+	Index  int       `json:"index"`
+	Time   time.Time `json:"time"`
+	Detail string    `json:"detail"`
+}
+
+// MonteCarloResult summarizes availability trials
+type MonteCarloResult struct {
+	PNL          Distribution      `json:"pnl"`
+	Availability Distribution      `json:"availability"` // Fraction of intervals available
+	Trials       []MonteCarloTrial `json:"trials"`
+}
+
+// MonteCarloTrial is one sampled outage timeline
+type MonteCarloTrial struct {
+	Seed         int64   `json:"seed"`
+	TotalPNL     float64 `json:"total_pnl"`
+	Availability float64 `json:"availability"`
+}
+
+// CycleUsage reports cycle usage per local day and year
+type CycleUsage struct {
+	TotalCycles      float64       `json:"total_cycles"`
+	ClippedIntervals int           `json:"clipped_intervals"` // Discharge cut to stay within the allowance
+	Days             []CyclePeriod `json:"days"`
+	Years            []CyclePeriod `json:"years"`
+}
+
+// CyclePeriod is cycle usage for one day or year (allowance 0 = no cap)
+type CyclePeriod struct {
+	Period    string  `json:"period"`
+	Cycles    float64 `json:"cycles"`
+	Allowance float64 `json:"allowance"`
+}
+
+// ScenarioDayReport describes one day's scenario-optimized schedule
+type ScenarioDayReport struct {
+	Day       string       `json:"day"`
+	Scenarios int          `json:"scenarios"`
+	Outcomes  Distribution `json:"outcomes"` // Profit of the schedule across scenarios
+	Realized  float64      `json:"realized"` // Profit of the schedule on actual prices
+}
+
+// Distribution summarizes a sample of outcomes
+type Distribution struct {
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	Std    float64 `json:"std"`
+	Min    float64 `json:"min"`
+	P10    float64 `json:"p10"`
+	P50    float64 `json:"p50"`
+	P90    float64 `json:"p90"`
+	Max    float64 `json:"max"`
+	CVaR10 float64 `json:"cvar10"` // Mean of the worst 10%
 }
 
 // BacktestSummary contains aggregated backtest results
 type BacktestSummary struct {
-	TotalPNL        float64         `json:"total_pnl"`
-	FinalSOC        float64         `json:"final_soc"`
-	TotalIntervals  int             `json:"total_intervals"`
-	BacktestWindow  TimeWindow      `json:"backtest_window"`
-	EnergyChargedMWh float64        `json:"energy_charged_mwh"`
-	EnergyDischargedMWh float64     `json:"energy_discharged_mwh"`
-	ChargeWindows   []ChargeWindow    `json:"charge_windows,omitempty"`    // Per-day charge windows
-	DischargeWindows []DischargeWindow    `json:"discharge_windows,omitempty"` // Per-day discharge windows
+	TotalPNL            float64           `json:"total_pnl"`
+	FinalSOC            float64           `json:"final_soc"`
+	TotalIntervals      int               `json:"total_intervals"`
+	BacktestWindow      TimeWindow        `json:"backtest_window"`
+	EnergyChargedMWh    float64           `json:"energy_charged_mwh"`
+	EnergyDischargedMWh float64           `json:"energy_discharged_mwh"`
+	ChargeWindows       []ChargeWindow    `json:"charge_windows,omitempty"`    // Per-day charge windows
+	DischargeWindows    []DischargeWindow `json:"discharge_windows,omitempty"` // Per-day discharge windows
+	Settlement          Settlement        `json:"settlement"`                  // PnL breakdown
+	LMPComponents       LMPComponents     `json:"lmp_components"`              // Energy value by LMP component
+	Emissions           Emissions         `json:"emissions"`                   // tCO2 at the marginal emissions rate
+	PriceEvents         PriceEvents       `json:"price_events"`                // Negative prices, scarcity, floor/cap
+}
+
+// Emissions reports induced vs. avoided emissions; intervals without a rate count as zero
+type Emissions struct {
+	ChargingT            float64 `json:"charging_t"`              // Induced by grid draw
+	AvoidedT             float64 `json:"avoided_t"`               // Avoided by discharge
+	NetT                 float64 `json:"net_t"`                   // charging_t - avoided_t
+	AbatementCostPerTCO2 float64 `json:"abatement_cost_per_tco2"` // -total_pnl per tonne abated (0 without net abatement)
+	RatedIntervals       int     `json:"rated_intervals"`
+}
+
+// LMPComponents attributes energy revenue and cost at LMP to its components
+type LMPComponents struct {
+	Energy          ComponentValue `json:"energy"`
+	Congestion      ComponentValue `json:"congestion"`
+	Loss            ComponentValue `json:"loss"`
+	Other           ComponentValue `json:"other"`            // GHG and any remainder
+	CongestionShare float64        `json:"congestion_share"` // congestion net_usd / total net_usd
+}
+
+// ComponentValue is revenue and cost at one LMP component
+type ComponentValue struct {
+	RevenueUSD float64 `json:"revenue_usd"`
+	CostUSD    float64 `json:"cost_usd"`
+	NetUSD     float64 `json:"net_usd"`
+}
+
+// Settlement breaks total PnL into invoice lines; net_usd equals total_pnl
+type Settlement struct {
+	EnergyRevenueUSD   float64 `json:"energy_revenue_usd"`
+	EnergyCostUSD      float64 `json:"energy_cost_usd"` // Charging and grid auxiliary energy at LMP
+	DegradationUSD     float64 `json:"degradation_usd"`
+	ChargingFeesUSD    float64 `json:"charging_fees_usd"`
+	ThroughputFeesUSD  float64 `json:"throughput_fees_usd"`
+	DemandChargesUSD   float64 `json:"demand_charges_usd"`
+	FixedChargesUSD    float64 `json:"fixed_charges_usd"`
+	SwitchCostsUSD     float64 `json:"switch_costs_usd"`
+	BidCostRecoveryUSD float64 `json:"bid_cost_recovery_usd"` // Make-whole uplift received
+	NetUSD             float64 `json:"net_usd"`
+}
+
+// PriceEvents reports negative-price, scarcity and floor/cap activity
+type PriceEvents struct {
+	NegativeChargeIntervals    int     `json:"negative_charge_intervals"`
+	NegativeChargeMWh          float64 `json:"negative_charge_mwh"`
+	NegativeChargeValueUSD     float64 `json:"negative_charge_value_usd"` // Paid to the battery to charge
+	NegativeDischargeIntervals int     `json:"negative_discharge_intervals"`
+	NegativeDischargeMWh       float64 `json:"negative_discharge_mwh"`
+	NegativeDischargeCostUSD   float64 `json:"negative_discharge_cost_usd"`
+	ScarcityIntervals          int     `json:"scarcity_intervals"`
+	ScarcityDischargeIntervals int     `json:"scarcity_discharge_intervals"`
+	ScarcityDischargeMWh       float64 `json:"scarcity_discharge_mwh"`
+	ScarcityRevenueUSD         float64 `json:"scarcity_revenue_usd"`
+	FloorIntervals             int     `json:"floor_intervals"`
+	CapIntervals               int     `json:"cap_intervals"`
+	FloorCapValueUSD           float64 `json:"floor_cap_value_usd"` // PnL vs. settling at LMP
+	BidCostRecoveryUSD         float64 `json:"bid_cost_recovery_usd"`
+	UpliftDays                 int     `json:"uplift_days"`
 }
 
 // TimeWindow represents a time range
@@ -32,14 +193,14 @@ type TimeWindow struct {
 type ChargeWindow struct {
 	TimeWindow
 	AverageCostPerMWh float64 `json:"average_cost_per_mwh"` // Weighted average LMP during charging
-	EnergyMWh          float64 `json:"energy_mwh"`           // Total energy charged in this window
+	EnergyMWh         float64 `json:"energy_mwh"`           // Total energy charged in this window
 }
 
 // DischargeWindow represents a discharge window with average price
 type DischargeWindow struct {
 	TimeWindow
 	AveragePricePerMWh float64 `json:"average_price_per_mwh"` // Weighted average LMP during discharging
-	EnergyMWh          float64 `json:"energy_mwh"`             // Total energy discharged in this window
+	EnergyMWh          float64 `json:"energy_mwh"`            // Total energy discharged in this window
 }
 
 // LedgerRow represents one interval in the backtest ledger
@@ -52,12 +213,37 @@ type LedgerRow struct {
 	Location           string    `json:"location"`
 	Market             string    `json:"market"`
 	LMP                float64   `json:"lmp"`
-	Action             string    `json:"action"` // "CHARGING", "DISCHARGING", "IDLE"
+	Filled             bool      `json:"filled"`
+	Action             string    `json:"action"`              // "CHARGING", "DISCHARGING", "IDLE"
+	BidCurve           string    `json:"bid_curve,omitempty"` // Submitted curve for bidding strategies
+	ClearedMW          float64   `json:"cleared_mw"`
 	RequestedPowerMW   float64   `json:"requested_power_mw"`
 	PowerMW            float64   `json:"power_mw"`
 	EnergyFromGridMWh  float64   `json:"energy_from_grid_mwh"`
 	EnergyToGridMWh    float64   `json:"energy_to_grid_mwh"`
 	ThroughputMWh      float64   `json:"throughput_mwh"`
+	AuxMWh             float64   `json:"aux_mwh"`
+	SelfDischargeMWh   float64   `json:"self_discharge_mwh"`
+	RampClipped        bool      `json:"ramp_clipped"`
+	ModeHeld           bool      `json:"mode_held"`
+	SwitchCostUSD      float64   `json:"switch_cost_usd"`
+	CycleClipped       bool      `json:"cycle_clipped"`
+	Outage             bool      `json:"outage"`
+	ChargingFeeUSD     float64   `json:"charging_fee_usd"`
+	ThroughputFeeUSD   float64   `json:"throughput_fee_usd"`
+	DemandChargeUSD    float64   `json:"demand_charge_usd"`
+	FixedChargeUSD     float64   `json:"fixed_charge_usd"`
+	SettlementPrice    float64   `json:"settlement_price"`
+	BCRUpliftUSD       float64   `json:"bcr_uplift_usd"`
+	LMPEnergyUSD       float64   `json:"lmp_energy_usd"`
+	LMPCongestionUSD   float64   `json:"lmp_congestion_usd"`
+	LMPLossUSD         float64   `json:"lmp_loss_usd"`
+	LMPOtherUSD        float64   `json:"lmp_other_usd"`
+	EmissionsRate      *float64  `json:"emissions_t_per_mwh,omitempty"`
+	EmissionsT         float64   `json:"emissions_t"`
+	AmbientTempC       *float64  `json:"ambient_temp_c,omitempty"`
+	CellTempC          float64   `json:"cell_temp_c"`
+	Derate             float64   `json:"derate"`
 	SOCStart           float64   `json:"soc_start"`
 	SOCEnd             float64   `json:"soc_end"`
 	PNL                float64   `json:"pnl"`
@@ -66,7 +252,8 @@ type LedgerRow struct {
 
 // CompareBacktestResponse represents the response from a comparison
 type CompareBacktestResponse struct {
-	Comparison []ComparisonResult `json:"comparison"`
+	Comparison  []ComparisonResult `json:"comparison"`
+	DataQuality *DataQualityReport `json:"data_quality,omitempty"`
 }
 
 // ComparisonResult contains results for one variation
@@ -80,6 +267,29 @@ type RankResponse struct {
 	Rankings []Ranking `json:"rankings"`
 }
 
+// RankJobResponse reports the progress and current ranking of a rank job
+type RankJobResponse struct {
+	ID         string           `json:"id"`
+	Status     string           `json:"status"` // running, completed, failed, cancelled or interrupted
+	DatasetID  string           `json:"dataset_id"`
+	StartDate  string           `json:"start_date"`
+	EndDate    string           `json:"end_date"`
+	Total      int              `json:"total"`     // Locations to screen
+	Completed  int              `json:"completed"` // Locations scored
+	Failed     int              `json:"failed"`    // Locations whose fetch failed (retried on resume)
+	Error      string           `json:"error,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+	Rankings   []Ranking        `json:"rankings"` // Best locations so far
+	Failures   []RankJobFailure `json:"failures,omitempty"`
+}
+
+// RankJobFailure is a location a rank job could not score
+type RankJobFailure struct {
+	Location string `json:"location"`
+	Error    string `json:"error"`
+}
+
 // Ranking represents one ranked location
 type Ranking struct {
 	Rank         int     `json:"rank"`
@@ -90,13 +300,15 @@ type Ranking struct {
 	MinLMP       float64 `json:"min_lmp"`
 	MaxLMP       float64 `json:"max_lmp"`
 	OracleProfit float64 `json:"oracle_profit"`
+
+	CongestionShare float64 `json:"congestion_share"` // Share of oracle_profit from the congestion component
 }
 
 // BatteryInfo represents information about a battery preset
 type BatteryInfo struct {
-	ID   string      `json:"id"`
-	Name string      `json:"name"`
-	File string      `json:"file"`
+	ID    string       `json:"id"`
+	Name  string       `json:"name"`
+	File  string       `json:"file"`
 	Specs BatterySpecs `json:"specs"`
 }
 
@@ -104,13 +316,15 @@ type BatteryInfo struct {
 type BatterySpecs struct {
 	EnergyCapacityMWh float64 `json:"energy_capacity_mwh"`
 	PowerCapacityMW   float64 `json:"power_capacity_mw"`
+	MaxChargeMW       float64 `json:"max_charge_mw,omitempty"`
+	MaxDischargeMW    float64 `json:"max_discharge_mw,omitempty"`
 }
 
 // StrategyInfo represents information about a strategy
 type StrategyInfo struct {
-	Name        string           `json:"name"`
-	Description string           `json:"description"`
-	Parameters  []ParameterInfo  `json:"parameters"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  []ParameterInfo `json:"parameters"`
 }
 
 // ParameterInfo describes a strategy parameter
@@ -121,6 +335,12 @@ type ParameterInfo struct {
 	Default     interface{} `json:"default,omitempty"`
 }
 
+// WasmModuleResponse is returned after uploading a WebAssembly strategy module
+type WasmModuleResponse struct {
+	Module    string `json:"module"` // ID to pass as strategy params.module
+	SizeBytes int    `json:"size_bytes"`
+}
+
 // DatasetInfo represents information about a Grid Status dataset
 type DatasetInfo struct {
 	ID         string `json:"id"`
@@ -131,9 +351,13 @@ type DatasetInfo struct {
 
 // LocationInfo represents information about a location
 type LocationInfo struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
-	Type string `json:"type"`
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Market    string   `json:"market,omitempty"`
+	Zone      string   `json:"zone,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
 }
 
 // ErrorResponse represents an error response
@@ -147,3 +371,24 @@ type ErrorDetail struct {
 	Message string                 `json:"message"`
 	Details map[string]interface{} `json:"details,omitempty"`
 }
+
+// GridStatusMetrics reports Grid Status client activity since server start
+type GridStatusMetrics struct {
+	Queries                 int64            `json:"queries"`  // Location queries, including store and cache hits
+	Requests                map[string]int64 `json:"requests"` // HTTP attempts by status code ("error" = transport failure)
+	Retries                 int64            `json:"retries"`
+	CacheHits               int64            `json:"cache_hits"`
+	StoreHits               int64            `json:"store_hits"`
+	RateLimitWaits          int64            `json:"rate_limit_waits"` // Requests delayed by the client-side limiter
+	RateLimitWaitSeconds    float64          `json:"rate_limit_wait_seconds"`
+	LatencyCount            int64            `json:"latency_count"`
+	LatencyMeanMilliseconds float64          `json:"latency_mean_ms"`
+	LatencyMaxMilliseconds  float64          `json:"latency_max_ms"`
+	LatencyHistogram        []LatencyBucket  `json:"latency_histogram"`
+}
+
+// LatencyBucket counts requests at or below an upper bound (0 = overflow)
+type LatencyBucket struct {
+	LEMilliseconds float64 `json:"le_ms"`
+	Count          int64   `json:"count"`
+}
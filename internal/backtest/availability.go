@@ -0,0 +1,174 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+
+	"battery-backtest/internal/analysis"
+	"battery-backtest/internal/model"
+	"battery-backtest/internal/strategy"
+)
+
+// MaintenanceWindow is a planned outage [Start, End).
+type MaintenanceWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Availability describes forced and planned outages.
+//
+// Forced outages follow a two-state (up/down) Markov process: repair times are
+// exponential with mean MTTRHours, and times to failure exponential with the
+// mean that makes the long-run fraction of time down equal ForcedOutageRate.
+type Availability struct {
+	ForcedOutageRate float64 // 0..1
+	MTTRHours        float64
+	Maintenance      []MaintenanceWindow
+}
+
+func (a Availability) Validate() error {
+	if a.ForcedOutageRate < 0 || a.ForcedOutageRate >= 1 {
+		return errors.New("forced outage rate must be in [0, 1)")
+	}
+	if a.ForcedOutageRate > 0 && a.MTTRHours <= 0 {
+		return errors.New("mttr hours must be > 0 when forced outage rate is set")
+	}
+	for _, w := range a.Maintenance {
+		if !w.End.After(w.Start) {
+			return errors.New("maintenance window end must be after start")
+		}
+	}
+	return nil
+}
+
+// Sample draws one availability timeline: available[i] is false when any part
+// of interval i falls in a forced outage or maintenance window.
+func (a Availability) Sample(intervals []model.LMPInterval, rng *rand.Rand) []bool {
+	available := make([]bool, len(intervals))
+	for i := range available {
+		available[i] = true
+	}
+	if len(intervals) == 0 {
+		return available
+	}
+	outages := append([]MaintenanceWindow(nil), a.Maintenance...)
+	if a.ForcedOutageRate > 0 {
+		outages = append(outages, a.forcedOutages(intervals[0].IntervalStartUTC, intervals[len(intervals)-1].IntervalEndUTC, rng)...)
+	}
+	for i, it := range intervals {
+		for _, o := range outages {
+			if o.Start.Before(it.IntervalEndUTC) && o.End.After(it.IntervalStartUTC) {
+				available[i] = false
+				break
+			}
+		}
+	}
+	return available
+}
+
+func (a Availability) forcedOutages(start, end time.Time, rng *rand.Rand) []MaintenanceWindow {
+	mttr := a.MTTRHours
+	mttf := mttr * (1 - a.ForcedOutageRate) / a.ForcedOutageRate
+	hours := func(mean float64) time.Duration {
+		return time.Duration(rng.ExpFloat64() * mean * float64(time.Hour))
+	}
+
+	var out []MaintenanceWindow
+	t := start
+	// Start in the stationary distribution; exponential times are memoryless.
+	if rng.Float64() < a.ForcedOutageRate {
+		repair := t.Add(hours(mttr))
+		out = append(out, MaintenanceWindow{Start: t, End: repair})
+		t = repair
+	}
+	for t.Before(end) {
+		fail := t.Add(hours(mttf))
+		if !fail.Before(end) {
+			break
+		}
+		repair := fail.Add(hours(mttr))
+		out = append(out, MaintenanceWindow{Start: fail, End: repair})
+		t = repair
+	}
+	return out
+}
+
+// Trial is one Monte Carlo sample.
+type Trial struct {
+	Seed         int64
+	TotalPNL     float64
+	Availability float64 // fraction of intervals available
+}
+
+// MonteCarloResult summarizes N availability trials.
+type MonteCarloResult struct {
+	Trials       []Trial
+	PNL          analysis.Distribution
+	Availability analysis.Distribution
+}
+
+// Setup builds a fresh battery and strategy for one trial. Strategies may be
+// stateful, so trials share only read-only ones (see builder.Plans).
+type Setup func() (*model.Battery, strategy.Strategy, error)
+
+// RunMonteCarlo runs trials backtests, each with an outage timeline sampled
+// from seed+i, in parallel. It stops starting trials once ctx is done and
+// then returns ctx's error.
+func RunMonteCarlo(ctx context.Context, intervals []model.LMPInterval, setup Setup, avail Availability, trials int, seed int64) (*MonteCarloResult, error) {
+	if trials <= 0 {
+		return nil, errors.New("trials must be > 0")
+	}
+	if err := avail.Validate(); err != nil {
+		return nil, err
+	}
+
+	results := make([]Trial, trials)
+	errs := parallel(ctx, trials, func(i int) (err error) {
+		results[i], err = runTrial(intervals, setup, avail, seed+int64(i))
+		return err
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("monte carlo: %w", err)
+	}
+	pnl := make([]float64, trials)
+	availability := make([]float64, trials)
+	for i, r := range results {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		pnl[i] = r.TotalPNL
+		availability[i] = r.Availability
+	}
+	return &MonteCarloResult{
+		Trials:       results,
+		PNL:          analysis.Summarize(pnl),
+		Availability: analysis.Summarize(availability),
+	}, nil
+}
+
+func runTrial(intervals []model.LMPInterval, setup Setup, avail Availability, seed int64) (Trial, error) {
+	batt, strat, err := setup()
+	if err != nil {
+		return Trial{}, err
+	}
+	if c, ok := strat.(io.Closer); ok {
+		defer c.Close()
+	}
+	e := &Engine{Available: avail.Sample(intervals, rand.New(rand.NewSource(seed)))}
+	res, err := e.Run(intervals, batt, strat)
+	if err != nil {
+		return Trial{}, err
+	}
+	up := 0
+	for _, ok := range e.Available {
+		if ok {
+			up++
+		}
+	}
+	return Trial{Seed: seed, TotalPNL: res.TotalPNL, Availability: float64(up) / float64(len(intervals))}, nil
+}
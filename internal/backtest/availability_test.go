@@ -0,0 +1,46 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"battery-backtest/internal/model"
+	"battery-backtest/internal/strategy"
+)
+
+func TestRunMonteCarloStopsWhenContextDone(t *testing.T) {
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	intervals := make([]model.LMPInterval, 12)
+	for i := range intervals {
+		s := start.Add(time.Duration(i) * 5 * time.Minute)
+		intervals[i] = model.LMPInterval{IntervalStartUTC: s, IntervalEndUTC: s.Add(5 * time.Minute), IntervalStartLocal: s, IntervalEndLocal: s.Add(5 * time.Minute), LMP: 30}
+	}
+	params := model.BatteryParams{EnergyCapacityMWh: 4, PowerCapacityMW: 1, ChargeEfficiency: 0.95, DischargeEfficiency: 0.95, MinSOC: 0.1, MaxSOC: 0.9}
+	var setups atomic.Int32
+	setup := func() (*model.Battery, strategy.Strategy, error) {
+		setups.Add(1)
+		b, err := model.NewBattery(params, params.MinSOC)
+		return b, &strategy.ConstantStrategy{}, err
+	}
+	avail := Availability{ForcedOutageRate: 0.1, MTTRHours: 1}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := RunMonteCarlo(ctx, intervals, setup, avail, 50, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if n := setups.Load(); n != 0 {
+		t.Errorf("%d trials started after cancellation", n)
+	}
+
+	mc, err := RunMonteCarlo(context.Background(), intervals, setup, avail, 5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mc.Trials) != 5 {
+		t.Errorf("got %d trials, want 5", len(mc.Trials))
+	}
+}
@@ -0,0 +1,44 @@
+package backtest
+
+import "battery-backtest/internal/model"
+
+// ComponentValue is the value of energy sold (revenue) and bought (cost) at
+// one LMP component.
+type ComponentValue struct {
+	RevenueUSD float64
+	CostUSD    float64
+	NetUSD     float64
+}
+
+// ComponentAttribution attributes energy revenue and cost at LMP to the LMP
+// components. The NetUSD values sum to EnergyRevenueUSD - EnergyCostUSD.
+type ComponentAttribution struct {
+	Energy     ComponentValue
+	Congestion ComponentValue
+	Loss       ComponentValue
+	Other      ComponentValue // GHG adders, settlement floor/cap adjustments and any remainder
+
+	// CongestionShare is Congestion.NetUSD over the total net value
+	// (0 when the total is not positive).
+	CongestionShare float64
+}
+
+// add attributes one interval settled at price and returns its net value per
+// component.
+func (a *ComponentAttribution) add(it model.LMPInterval, price, soldMWh, boughtMWh float64) (energy, congestion, loss, other float64) {
+	e, c, l, o := it.Components()
+	o += price - it.LMP
+	book := func(v *ComponentValue, price float64) float64 {
+		v.RevenueUSD += price * soldMWh
+		v.CostUSD += price * boughtMWh
+		v.NetUSD += price * (soldMWh - boughtMWh)
+		return price * (soldMWh - boughtMWh)
+	}
+	return book(&a.Energy, e), book(&a.Congestion, c), book(&a.Loss, l), book(&a.Other, o)
+}
+
+func (a *ComponentAttribution) finish() {
+	if total := a.Energy.NetUSD + a.Congestion.NetUSD + a.Loss.NetUSD + a.Other.NetUSD; total > 0 {
+		a.CongestionShare = a.Congestion.NetUSD / total
+	}
+}
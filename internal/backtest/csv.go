@@ -26,12 +26,37 @@ func WriteLedgerCSV(path string, ledger []LedgerRow) error {
 		"location",
 		"market",
 		"lmp",
+		"filled",
 		"action",
+		"bid_curve",
+		"cleared_mw",
 		"requested_power_mw",
 		"power_mw",
 		"energy_from_grid_mwh",
 		"energy_to_grid_mwh",
 		"throughput_mwh",
+		"aux_mwh",
+		"self_discharge_mwh",
+		"ramp_clipped",
+		"mode_held",
+		"switch_cost_usd",
+		"cycle_clipped",
+		"outage",
+		"charging_fee_usd",
+		"throughput_fee_usd",
+		"demand_charge_usd",
+		"fixed_charge_usd",
+		"settlement_price",
+		"bcr_uplift_usd",
+		"lmp_energy_usd",
+		"lmp_congestion_usd",
+		"lmp_loss_usd",
+		"lmp_other_usd",
+		"emissions_t_per_mwh",
+		"emissions_t",
+		"ambient_temp_c",
+		"cell_temp_c",
+		"derate",
 		"soc_start",
 		"soc_end",
 		"pnl",
@@ -51,12 +76,37 @@ func WriteLedgerCSV(path string, ledger []LedgerRow) error {
 			r.Location,
 			r.Market,
 			fmtFloat(r.LMP),
+			strconv.FormatBool(r.Filled),
 			string(r.Action),
+			r.BidCurve,
+			fmtFloat(r.ClearedMW),
 			fmtFloat(r.RequestedPowerMW),
 			fmtFloat(r.PowerMW),
 			fmtFloat(r.EnergyFromGridMWh),
 			fmtFloat(r.EnergyToGridMWh),
 			fmtFloat(r.ThroughputMWh),
+			fmtFloat(r.AuxMWh),
+			fmtFloat(r.SelfDischargeMWh),
+			strconv.FormatBool(r.RampClipped),
+			strconv.FormatBool(r.ModeHeld),
+			fmtFloat(r.SwitchCostUSD),
+			strconv.FormatBool(r.CycleClipped),
+			strconv.FormatBool(r.Outage),
+			fmtFloat(r.ChargingFeeUSD),
+			fmtFloat(r.ThroughputFeeUSD),
+			fmtFloat(r.DemandChargeUSD),
+			fmtFloat(r.FixedChargeUSD),
+			fmtFloat(r.SettlementPrice),
+			fmtFloat(r.BCRUpliftUSD),
+			fmtFloat(r.LMPEnergyUSD),
+			fmtFloat(r.LMPCongestionUSD),
+			fmtFloat(r.LMPLossUSD),
+			fmtFloat(r.LMPOtherUSD),
+			fmtOptFloat(r.EmissionsRate),
+			fmtFloat(r.EmissionsT),
+			fmtOptFloat(r.AmbientTempC),
+			fmtFloat(r.CellTempC),
+			fmtFloat(r.Derate),
 			fmtFloat(r.SOCStart),
 			fmtFloat(r.SOCEnd),
 			fmtFloat(r.PNL),
@@ -81,3 +131,10 @@ func fmtFloat(x float64) string {
 	return strconv.FormatFloat(x, 'f', 6, 64)
 }
 
+// fmtOptFloat formats an optional value, empty when nil.
+func fmtOptFloat(x *float64) string {
+	if x == nil {
+		return ""
+	}
+	return fmtFloat(*x)
+}
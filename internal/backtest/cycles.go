@@ -0,0 +1,86 @@
+package backtest
+
+import (
+	"math"
+	"sort"
+
+	"battery-backtest/internal/model"
+)
+
+// CyclePeriod is cycle usage for one local calendar day or year.
+// Allowance is 0 when the period has no cap.
+type CyclePeriod struct {
+	Period    string
+	Cycles    float64
+	Allowance float64
+}
+
+// CycleUsage reports equivalent full cycles against the warranty allowance.
+type CycleUsage struct {
+	Days  []CyclePeriod
+	Years []CyclePeriod
+
+	TotalCycles float64
+
+	// ClippedIntervals counts intervals where a discharge request was cut to
+	// stay within the remaining allowance.
+	ClippedIntervals int
+}
+
+// cycleTracker accumulates discharge throughput per local day and year and
+// answers how much discharge is still allowed.
+type cycleTracker struct {
+	p      model.BatteryParams
+	day    map[string]float64 // MWh to grid
+	year   map[string]float64
+	dayKey string
+	yrKey  string
+}
+
+func newCycleTracker(p model.BatteryParams) *cycleTracker {
+	return &cycleTracker{p: p, day: map[string]float64{}, year: map[string]float64{}}
+}
+
+// cap returns the discharge cap for the interval, or nil when unlimited.
+func (c *cycleTracker) cap(it model.LMPInterval, dtH float64) *float64 {
+	c.dayKey = it.IntervalStartLocal.Format("2006-01-02")
+	c.yrKey = it.IntervalStartLocal.Format("2006")
+	remaining := math.Inf(1)
+	if c.p.MaxCyclesPerDay > 0 {
+		remaining = math.Min(remaining, c.p.CycleCapMWh(c.p.MaxCyclesPerDay)-c.day[c.dayKey])
+	}
+	if c.p.MaxCyclesPerYear > 0 {
+		remaining = math.Min(remaining, c.p.CycleCapMWh(c.p.MaxCyclesPerYear)-c.year[c.yrKey])
+	}
+	if math.IsInf(remaining, 1) || dtH <= 0 {
+		return nil
+	}
+	mw := math.Max(0, remaining) / dtH
+	return &mw
+}
+
+func (c *cycleTracker) record(energyToGridMWh float64) {
+	c.day[c.dayKey] += energyToGridMWh
+	c.year[c.yrKey] += energyToGridMWh
+}
+
+func (c *cycleTracker) usage(clipped int) *CycleUsage {
+	cycles := func(mwh float64) float64 { return mwh / c.p.EnergyCapacityMWh }
+	periods := func(m map[string]float64, allowance float64) []CyclePeriod {
+		out := make([]CyclePeriod, 0, len(m))
+		for k, v := range m {
+			out = append(out, CyclePeriod{Period: k, Cycles: cycles(v), Allowance: allowance})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Period < out[j].Period })
+		return out
+	}
+	u := &CycleUsage{
+		Days:             periods(c.day, c.p.MaxCyclesPerDay),
+		Years:            periods(c.year, c.p.MaxCyclesPerYear),
+		ClippedIntervals: clipped,
+	}
+	for _, y := range u.Years {
+		u.TotalCycles += y.Cycles
+	}
+	return u
+}
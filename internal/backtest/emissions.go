@@ -0,0 +1,38 @@
+package backtest
+
+import "battery-backtest/internal/model"
+
+// EmissionsSummary reports emissions induced by charging (grid draw) and
+// avoided by discharging, at each interval's marginal emissions rate.
+// Intervals without a rate count as zero.
+type EmissionsSummary struct {
+	ChargingT float64 // tCO2 induced by grid draw (charging and grid aux)
+	AvoidedT  float64 // tCO2 avoided by energy delivered to the grid
+	NetT      float64 // ChargingT - AvoidedT; negative means net abatement
+
+	// AbatementCostPerTCO2 is the cost per tonne abated, -TotalPNL / -NetT
+	// (negative when abatement is profitable). 0 without net abatement.
+	AbatementCostPerTCO2 float64
+
+	// RatedIntervals counts intervals with an emissions rate.
+	RatedIntervals int
+}
+
+// add books one interval and returns its net emissions (tCO2).
+func (e *EmissionsSummary) add(it model.LMPInterval, soldMWh, boughtMWh float64) float64 {
+	if it.EmissionsRate == nil {
+		return 0
+	}
+	rate := *it.EmissionsRate
+	e.RatedIntervals++
+	e.ChargingT += rate * boughtMWh
+	e.AvoidedT += rate * soldMWh
+	return rate * (boughtMWh - soldMWh)
+}
+
+func (e *EmissionsSummary) finish(totalPNL float64) {
+	e.NetT = e.ChargingT - e.AvoidedT
+	if e.NetT < 0 {
+		e.AbatementCostPerTCO2 = totalPNL / e.NetT
+	}
+}
@@ -7,7 +7,11 @@ import (
 	"battery-backtest/internal/strategy"
 )
 
-type Engine struct{}
+type Engine struct {
+	// Available optionally marks intervals the battery can operate (see
+	// Availability.Sample). During an outage dispatch is forced to zero.
+	Available []bool
+}
 
 func New() *Engine { return &Engine{} }
 
@@ -25,20 +29,85 @@ func (e *Engine) Run(intervals []model.LMPInterval, batt *model.Battery, strat s
 
 	ledger := make([]LedgerRow, 0, len(intervals))
 	cum := 0.0
+	cycles := newCycleTracker(batt.Params)
+	cycleClipped := 0
+	monthly := &monthlyCharges{p: batt.Params}
+	var settlement SettlementSummary
+	var components ComponentAttribution
+	var emissions EmissionsSummary
+	var events PriceEvents
+	bcr := &bidCostRecovery{p: batt.Params}
 
 	for idx, it := range intervals {
 		dtH := it.DurationHours()
-		req := strat.Decide(strategy.Context{
+		ctx := strategy.Context{
 			Index:    idx,
 			Interval: it,
 			Battery:  batt,
-		})
+		}
+
+		// Bidding strategies submit a curve that is cleared against the realized LMP.
+		var curve model.BidCurve
+		var req model.Dispatch
+		var cleared float64
+		if bidder, ok := strat.(strategy.Bidder); ok {
+			curve = bidder.Bid(ctx)
+			cleared = curve.Clear(it.LMP)
+			req = model.Dispatch{PowerMW: cleared}
+		} else {
+			req = strat.Decide(ctx)
+		}
+		if f, ok := strat.(strategy.Failer); ok {
+			if err := f.Err(); err != nil {
+				return nil, fmt.Errorf("interval %d strategy %s: %w", idx, strat.Name(), err)
+			}
+		}
 
-		res, err := batt.ApplyDispatch(it.LMP, req, dtH)
+		// Warranty cycle caps bound discharge to the remaining allowance.
+		applied := req
+		outage := it.Unavailable || (e.Available != nil && idx < len(e.Available) && !e.Available[idx])
+		applied.Outage = outage
+		applied.DischargeCapMW = cycles.cap(it, dtH)
+		clipped := applied.DischargeCapMW != nil && req.PowerMW > *applied.DischargeCapMW
+		if clipped {
+			cycleClipped++
+		}
+
+		batt.SetAmbient(it.AmbientTempC)
+		res, err := batt.ApplyDispatch(it.LMP, applied, dtH)
 		if err != nil {
 			return nil, fmt.Errorf("interval %d apply dispatch: %w", idx, err)
 		}
-		cum += res.PNL
+		cycles.record(res.EnergyToGridMWh)
+		settlement.add(res)
+		// Grid draw covers charging and any auxiliary load bought from the grid.
+		lmpEnergy, lmpCongestion, lmpLoss, lmpOther := components.add(it, res.SettlementPrice, res.EnergyToGridMWh, res.GridDrawMW*dtH)
+		emissionsT := emissions.add(it, res.EnergyToGridMWh, res.GridDrawMW*dtH)
+
+		// Monthly per-MW charges are booked on the last interval of the month.
+		pnl := res.PNL
+		monthly.record(res, dtH)
+		var demand, fixed float64
+		if periodEnds(intervals, idx, "2006-01") {
+			demand, fixed = monthly.close(it.IntervalStartLocal)
+			settlement.DemandChargesUSD += demand
+			settlement.FixedChargesUSD += fixed
+			pnl -= demand + fixed
+		}
+		// Bid cost recovery is a daily make-whole paid on the last interval of the day.
+		events.add(batt.Params, it, res, dtH)
+		bcr.record(res)
+		var uplift float64
+		if periodEnds(intervals, idx, "2006-01-02") {
+			uplift = bcr.close()
+			if uplift > 0 {
+				events.BidCostRecoveryUSD += uplift
+				events.UpliftDays++
+				settlement.BidCostRecoveryUSD += uplift
+				pnl += uplift
+			}
+		}
+		cum += pnl
 
 		row := LedgerRow{
 			Index: idx,
@@ -51,29 +120,67 @@ func (e *Engine) Run(intervals []model.LMPInterval, batt *model.Battery, strat s
 			Location: it.Location,
 			Market:   it.Market,
 			LMP:      it.LMP,
+			Filled:   it.Filled,
 
 			Action: model.ActionFromPowerMW(res.PowerMW),
 
+			BidCurve:  curve.String(),
+			ClearedMW: cleared,
+
 			RequestedPowerMW: req.PowerMW,
 			PowerMW:          res.PowerMW,
 
 			EnergyFromGridMWh: res.EnergyFromGridMWh,
 			EnergyToGridMWh:   res.EnergyToGridMWh,
 			ThroughputMWh:     res.ThroughputMWh,
+			AuxMWh:            res.AuxMWh,
+			SelfDischargeMWh:  res.SelfDischargeMWh,
+
+			RampClipped:   res.RampClipped,
+			ModeHeld:      res.ModeHeld,
+			SwitchCostUSD: res.SwitchCostUSD,
+			CycleClipped:  clipped,
+			Outage:        outage,
+
+			ChargingFeeUSD:   res.ChargingFeeUSD,
+			ThroughputFeeUSD: res.ThroughputFeeUSD,
+			DemandChargeUSD:  demand,
+			FixedChargeUSD:   fixed,
+			SettlementPrice:  res.SettlementPrice,
+			BCRUpliftUSD:     uplift,
+
+			LMPEnergyUSD:     lmpEnergy,
+			LMPCongestionUSD: lmpCongestion,
+			LMPLossUSD:       lmpLoss,
+			LMPOtherUSD:      lmpOther,
+
+			EmissionsRate: it.EmissionsRate,
+			EmissionsT:    emissionsT,
+
+			AmbientTempC: it.AmbientTempC,
+			CellTempC:    res.CellTempC,
+			Derate:       res.Derate,
 
 			SOCStart: res.SOCStart,
 			SOCEnd:   res.SOCEnd,
 
-			PNL:    res.PNL,
+			PNL:    pnl,
 			CumPNL: cum,
 		}
 		ledger = append(ledger, row)
 	}
 
+	settlement.NetUSD = cum
+	components.finish()
+	emissions.finish(cum)
 	return &Result{
-		Ledger:   ledger,
-		TotalPNL: cum,
-		FinalSOC: batt.State.SOC,
+		Settlement:  settlement,
+		Components:  components,
+		Emissions:   emissions,
+		PriceEvents: events,
+		Ledger:      ledger,
+		TotalPNL:    cum,
+		FinalSOC:    batt.State.SOC,
+		Cycles:      cycles.usage(cycleClipped),
 	}, nil
 }
-
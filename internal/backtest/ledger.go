@@ -19,16 +19,63 @@ type LedgerRow struct {
 	Location string
 	Market   string
 
-	LMP float64
+	LMP    float64
+	Filled bool // interval synthesized or re-priced by data repair
 
 	Action model.Action
 
+	// BidCurve is the submitted curve (e.g. "-50@20;50@80") for bidding
+	// strategies, empty otherwise. ClearedMW is the quantity it cleared at LMP
+	// (0 for strategies that do not bid).
+	BidCurve  string
+	ClearedMW float64
+
 	RequestedPowerMW float64
 	PowerMW          float64
 
 	EnergyFromGridMWh float64
 	EnergyToGridMWh   float64
 	ThroughputMWh     float64
+	AuxMWh            float64
+	SelfDischargeMWh  float64
+
+	// RampClipped/ModeHeld flag requests changed by ramp limits or minimum mode
+	// time; SwitchCostUSD is the mode-switch penalty included in PNL.
+	// CycleClipped flags discharge cut by a warranty cycle cap.
+	RampClipped   bool
+	ModeHeld      bool
+	SwitchCostUSD float64
+	CycleClipped  bool
+	Outage        bool // forced/planned outage or unavailable data: dispatch forced to zero
+
+	// Settlement costs included in PNL. Monthly demand and fixed charges are
+	// booked on the last interval of each local month, the bid cost recovery
+	// uplift on the last interval of each local day. SettlementPrice is the
+	// LMP after any floor/cap.
+	ChargingFeeUSD   float64
+	ThroughputFeeUSD float64
+	DemandChargeUSD  float64
+	FixedChargeUSD   float64
+	SettlementPrice  float64
+	BCRUpliftUSD     float64
+
+	// Net energy value (revenue - cost) at each LMP component; they sum to the
+	// energy value at LMP.
+	LMPEnergyUSD     float64
+	LMPCongestionUSD float64
+	LMPLossUSD       float64
+	LMPOtherUSD      float64
+
+	// Marginal emissions rate (tCO2/MWh, nil if none) and the interval's net
+	// emissions: grid draw minus delivered energy, times the rate.
+	EmissionsRate *float64
+	EmissionsT    float64
+
+	// Thermal model outputs: ambient input (nil if none), cell temperature at
+	// the end of the interval and the power derate factor applied.
+	AmbientTempC *float64
+	CellTempC    float64
+	Derate       float64
 
 	SOCStart float64
 	SOCEnd   float64
@@ -38,8 +85,22 @@ type LedgerRow struct {
 }
 
 type Result struct {
-	Ledger []LedgerRow
+	Ledger   []LedgerRow
 	TotalPNL float64
 	FinalSOC float64
-}
 
+	// Cycles is equivalent-full-cycle usage against the warranty caps.
+	Cycles *CycleUsage
+
+	// Settlement breaks TotalPNL into revenue, energy cost, fees and charges.
+	Settlement SettlementSummary
+
+	// Components attributes energy revenue and cost to the LMP components.
+	Components ComponentAttribution
+
+	// Emissions is induced vs. avoided tCO2 at the marginal emissions rate.
+	Emissions EmissionsSummary
+
+	// PriceEvents reports negative-price, scarcity and floor/cap activity.
+	PriceEvents PriceEvents
+}
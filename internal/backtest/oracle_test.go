@@ -0,0 +1,67 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"battery-backtest/internal/data/synthetic"
+	"battery-backtest/internal/model"
+	"battery-backtest/internal/strategy"
+)
+
+// TestOracleFollowsRampAndModeLimits checks that the oracle only plans moves
+// the engine realizes as requested.
+func TestOracleFollowsRampAndModeLimits(t *testing.T) {
+	p := synthetic.DefaultParams(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+	p.Days = 2
+	p.Seed = 1
+	intervals, err := synthetic.Generate(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := model.BatteryParams{
+		EnergyCapacityMWh:     100,
+		PowerCapacityMW:       100,
+		ChargeEfficiency:      0.94,
+		DischargeEfficiency:   0.94,
+		MinSOC:                0.1,
+		MaxSOC:                0.9,
+		DegradationCostPerMWh: 3,
+	}
+	tests := []struct {
+		name          string
+		ramp, minMode float64
+	}{
+		{"ramp", 5, 0},
+		{"min mode", 0, 30},
+		{"ramp and min mode", 5, 30},
+		{"ramp off the grid", 3, 20},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			bp := base
+			bp.RampRateMWPerMin, bp.MinModeMinutes = tc.ramp, tc.minMode
+			batt, err := model.NewBattery(bp, bp.MinSOC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			oracle, err := strategy.NewOracleStrategy(intervals, bp, bp.MinSOC, strategy.OracleParams{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := New().Run(intervals, batt, oracle)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range res.Ledger {
+				if row.RampClipped || row.ModeHeld {
+					t.Fatalf("%s: requested %.2f MW, realized %.2f MW (ramp_clipped=%v, mode_held=%v)",
+						row.IntervalStartLocal.Format("2006-01-02 15:04"), row.RequestedPowerMW, row.PowerMW, row.RampClipped, row.ModeHeld)
+				}
+			}
+			if res.TotalPNL <= 0 {
+				t.Errorf("total PnL %.2f, want > 0", res.TotalPNL)
+			}
+		})
+	}
+}
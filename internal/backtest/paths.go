@@ -0,0 +1,108 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+
+	"battery-backtest/internal/analysis"
+	"battery-backtest/internal/model"
+	"battery-backtest/internal/strategy"
+)
+
+// PathRun is the backtest of one alternative price path.
+type PathRun struct {
+	Path     int
+	TotalPNL float64
+}
+
+// PathsResult summarizes backtests over a set of price paths.
+type PathsResult struct {
+	Runs []PathRun
+	PNL  analysis.Distribution
+}
+
+// PathSetup builds a fresh battery and strategy for one price path; strategies
+// with foresight (e.g. the oracle) must be built from that path.
+type PathSetup func(intervals []model.LMPInterval) (*model.Battery, strategy.Strategy, error)
+
+// RunPaths runs one backtest per price path, in parallel.
+func RunPaths(paths [][]model.LMPInterval, setup PathSetup) (*PathsResult, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no price paths")
+	}
+	runs := make([]PathRun, len(paths))
+	errs := parallel(context.Background(), len(paths), func(i int) (err error) {
+		runs[i], err = runPath(i, paths[i], setup)
+		return err
+	})
+
+	pnl := make([]float64, len(runs))
+	for i, r := range runs {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		pnl[i] = r.TotalPNL
+	}
+	return &PathsResult{Runs: runs, PNL: analysis.Summarize(pnl)}, nil
+}
+
+func runPath(i int, intervals []model.LMPInterval, setup PathSetup) (PathRun, error) {
+	batt, strat, err := setup(intervals)
+	if err != nil {
+		return PathRun{}, err
+	}
+	if c, ok := strat.(io.Closer); ok {
+		defer c.Close()
+	}
+	res, err := New().Run(intervals, batt, strat)
+	if err != nil {
+		return PathRun{}, err
+	}
+	return PathRun{Path: i, TotalPNL: res.TotalPNL}, nil
+}
+
+// parallel calls fn(0..n-1) on up to NumCPU workers, waits for them and
+// returns each call's error. A panic in fn is returned as that call's error
+// instead of taking down the process (the API runs backtests in-process).
+// Calls not yet started when ctx is done are skipped with ctx's error.
+func parallel(ctx context.Context, n int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	workers := runtime.NumCPU()
+	if n < workers {
+		workers = n
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				errs[i] = recoverCall(i, fn)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return errs
+}
+
+func recoverCall(i int, fn func(i int) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("run %d panicked: %v", i, r)
+		}
+	}()
+	return fn(i)
+}
@@ -0,0 +1,96 @@
+package backtest
+
+import (
+	"math"
+
+	"battery-backtest/internal/model"
+)
+
+// PriceEvents reports activity at negative prices, during scarcity/cap events
+// and under the settlement floor/cap rules.
+type PriceEvents struct {
+	// Charging at negative prices: intervals, MWh and the amount paid to the
+	// battery for taking that energy.
+	NegativeChargeIntervals int
+	NegativeChargeMWh       float64
+	NegativeChargeValueUSD  float64
+
+	// Discharging at negative prices: intervals, MWh and the amount paid.
+	NegativeDischargeIntervals int
+	NegativeDischargeMWh       float64
+	NegativeDischargeCostUSD   float64
+
+	// Scarcity/cap events (model.SettlementParams.Scarcity): intervals in the
+	// data, intervals discharged, MWh and revenue earned.
+	ScarcityIntervals          int
+	ScarcityDischargeIntervals int
+	ScarcityDischargeMWh       float64
+	ScarcityRevenueUSD         float64
+
+	// Intervals where the settlement price was raised to the floor or cut to
+	// the cap, and the resulting PnL difference vs. settling at LMP.
+	FloorIntervals   int
+	CapIntervals     int
+	FloorCapValueUSD float64
+
+	// BidCostRecoveryUSD is the total make-whole uplift paid, over
+	// UpliftDays trading days.
+	BidCostRecoveryUSD float64
+	UpliftDays         int
+}
+
+func (e *PriceEvents) add(p model.BatteryParams, it model.LMPInterval, res model.IntervalResult, dtH float64) {
+	bought := res.GridDrawMW * dtH
+	sold := res.EnergyToGridMWh
+	if it.LMP < 0 && res.EnergyFromGridMWh > 0 {
+		e.NegativeChargeIntervals++
+		e.NegativeChargeMWh += res.EnergyFromGridMWh
+		e.NegativeChargeValueUSD -= res.SettlementPrice * res.EnergyFromGridMWh
+	}
+	if it.LMP < 0 && sold > 0 {
+		e.NegativeDischargeIntervals++
+		e.NegativeDischargeMWh += sold
+		e.NegativeDischargeCostUSD -= res.SettlementPrice * sold
+	}
+	if p.Settlement.Scarcity(it.LMP) {
+		e.ScarcityIntervals++
+		if sold > 0 {
+			e.ScarcityDischargeIntervals++
+			e.ScarcityDischargeMWh += sold
+			e.ScarcityRevenueUSD += res.SettlementPrice * sold
+		}
+	}
+	switch {
+	case res.SettlementPrice > it.LMP:
+		e.FloorIntervals++
+	case res.SettlementPrice < it.LMP:
+		e.CapIntervals++
+	}
+	e.FloorCapValueUSD += (res.SettlementPrice - it.LMP) * (sold - bought)
+}
+
+// bidCostRecovery accumulates the discharge shortfall against bid cost over a
+// local trading day. Shortfalls net against surpluses within the day.
+type bidCostRecovery struct {
+	p         model.BatteryParams
+	shortfall float64
+}
+
+func (b *bidCostRecovery) record(res model.IntervalResult) {
+	s := b.p.Settlement
+	if s == nil || !s.BidCostRecovery || res.EnergyToGridMWh <= 0 {
+		return
+	}
+	bidCost := s.BidCostPerMWh
+	if bidCost == 0 {
+		bidCost = b.p.DegradationCostPerMWh
+	}
+	b.shortfall += (bidCost - res.SettlementPrice) * res.EnergyToGridMWh
+}
+
+// close returns the day's uplift and resets the tracker.
+func (b *bidCostRecovery) close() float64 {
+	uplift := math.Max(0, b.shortfall)
+	b.shortfall = 0
+	return uplift
+}
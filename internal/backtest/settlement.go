@@ -0,0 +1,68 @@
+package backtest
+
+import (
+	"math"
+	"time"
+
+	"battery-backtest/internal/model"
+)
+
+// SettlementSummary breaks the total PnL into the lines of a settlement
+// invoice. NetUSD equals Result.TotalPNL.
+type SettlementSummary struct {
+	EnergyRevenueUSD   float64
+	EnergyCostUSD      float64 // charging and auxiliary energy bought at the settlement price
+	DegradationUSD     float64
+	ChargingFeesUSD    float64
+	ThroughputFeesUSD  float64
+	DemandChargesUSD   float64
+	FixedChargesUSD    float64
+	SwitchCostsUSD     float64
+	BidCostRecoveryUSD float64 // make-whole uplift received
+	NetUSD             float64
+}
+
+func (s *SettlementSummary) add(res model.IntervalResult) {
+	s.EnergyRevenueUSD += res.EnergyRevenueUSD
+	s.EnergyCostUSD += res.EnergyCostUSD
+	s.DegradationUSD += res.DegradationUSD
+	s.ChargingFeesUSD += res.ChargingFeeUSD
+	s.ThroughputFeesUSD += res.ThroughputFeeUSD
+	s.SwitchCostsUSD += res.SwitchCostUSD
+}
+
+// monthlyCharges accumulates the charging-meter peak and covered hours of the
+// current local month for the per-MW monthly charges.
+type monthlyCharges struct {
+	p      model.BatteryParams
+	peakMW float64
+	hours  float64
+}
+
+func (m *monthlyCharges) record(res model.IntervalResult, dtH float64) {
+	m.peakMW = math.Max(m.peakMW, res.GridDrawMW)
+	m.hours += dtH
+}
+
+// close returns the demand and fixed charges for the month starting at start
+// and resets the tracker.
+func (m *monthlyCharges) close(start time.Time) (demand, fixed float64) {
+	if s := m.p.Settlement; s != nil {
+		y, mo, _ := start.Date()
+		first := time.Date(y, mo, 1, 0, 0, 0, 0, start.Location())
+		monthHours := first.AddDate(0, 1, 0).Sub(first).Hours()
+		demand = s.DemandChargePerMWMonth * m.peakMW
+		fixed = s.FixedChargePerMWMonth * m.p.PowerCapacityMW * math.Min(1, m.hours/monthHours)
+	}
+	m.peakMW, m.hours = 0, 0
+	return demand, fixed
+}
+
+// periodEnds reports whether interval idx is the last of its local period,
+// given as a time layout ("2006-01" for months, "2006-01-02" for days).
+func periodEnds(intervals []model.LMPInterval, idx int, layout string) bool {
+	if idx == len(intervals)-1 {
+		return true
+	}
+	return intervals[idx].IntervalStartLocal.Format(layout) != intervals[idx+1].IntervalStartLocal.Format(layout)
+}
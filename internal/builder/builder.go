@@ -0,0 +1,327 @@
+// Package builder turns a loaded config into engine inputs (strategies,
+// conditions, the outage model, bootstrap parameters). The CLI and the API both build them here so
+// the two cannot drift apart, and config stays plain data.
+package builder
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"battery-backtest/internal/backtest"
+	"battery-backtest/internal/config"
+	"battery-backtest/internal/data/synthetic"
+	"battery-backtest/internal/model"
+	"battery-backtest/internal/strategy"
+)
+
+// Options adapts strategy building to the caller. The zero value treats file
+// references as local paths and takes WASM limits from params as given, which
+// suits the CLI.
+type Options struct {
+	// ResolveModule maps a wasm node's params.module to a file.
+	ResolveModule func(ref string) (string, error)
+	// ResolvePolicy maps a qlearn node's params.policy to a file.
+	ResolvePolicy func(ref string) (string, error)
+	// WasmLimits reads the sandbox limits of a wasm node.
+	WasmLimits func(params map[string]any) (strategy.WasmLimits, error)
+	// Plans, when set, shares planned strategies between builds.
+	Plans *Plans
+}
+
+// Plans shares planned strategies (oracle, stochastic) between builds of one
+// config over the same intervals, such as the main run and the trials of an
+// availability Monte Carlo run, so each plan is solved once. Planned
+// strategies are read-only once built, so concurrent backtests may share
+// them. A Plans is safe for concurrent use.
+type Plans struct {
+	mu    sync.Mutex
+	plans map[string]*plan
+}
+
+type plan struct {
+	once sync.Once
+	s    strategy.Strategy
+	err  error
+}
+
+// NewPlans returns an empty plan cache.
+func NewPlans() *Plans {
+	return &Plans{plans: map[string]*plan{}}
+}
+
+// get returns the strategy built for key, building it on first use.
+func (p *Plans) get(key string, build func() (strategy.Strategy, error)) (strategy.Strategy, error) {
+	if p == nil {
+		return build()
+	}
+	p.mu.Lock()
+	e := p.plans[key]
+	if e == nil {
+		e = &plan{}
+		p.plans[key] = e
+	}
+	p.mu.Unlock()
+	e.once.Do(func() { e.s, e.err = build() })
+	return e.s, e.err
+}
+
+// Strategy builds the strategy tree of cfg for a backtest over intervals.
+// Strategies that hold resources implement io.Closer.
+func Strategy(cfg *config.Config, intervals []model.LMPInterval, batt *model.Battery, opts Options) (strategy.Strategy, error) {
+	return node("strategy", cfg.Strategy, cfg, intervals, batt, opts)
+}
+
+// node builds one node of the strategy tree, recursing into children for
+// combinators. Children already built are closed if a later one fails. path
+// identifies the node within the tree.
+func node(path string, sc config.StrategyConfig, cfg *config.Config, intervals []model.LMPInterval, batt *model.Battery, opts Options) (strategy.Strategy, error) {
+	// Plans depend on the starting SOC as well as the node.
+	planKey := fmt.Sprintf("%s@%g", path, batt.State.SOC)
+	switch sc.Name {
+	case "schedule":
+		chargeStart := Str(sc.Params, "charge_start", "10:00")
+		dischargeStart := Str(sc.Params, "discharge_start", "17:00")
+		chargeEnd := Str(sc.Params, "charge_end", dischargeStart)
+		dischargeEnd := Str(sc.Params, "discharge_end", "23:59")
+		params := cfg.Battery.ToModelParams()
+		chargeMW := Num(sc.Params, "charge_power_mw", params.ChargeRatingMW())
+		dischargeMW := Num(sc.Params, "discharge_power_mw", params.DischargeRatingMW())
+		return &strategy.ScheduleStrategy{Params: strategy.ScheduleParams{
+			ChargeStart:      chargeStart,
+			ChargeEnd:        chargeEnd,
+			DischargeStart:   dischargeStart,
+			DischargeEnd:     dischargeEnd,
+			ChargePowerMW:    chargeMW,
+			DischargePowerMW: dischargeMW,
+		}}, nil
+	case "oracle":
+		return opts.Plans.get(planKey, func() (strategy.Strategy, error) {
+			return strategy.NewOracleStrategy(intervals, batt.Params, batt.State.SOC, strategy.OracleParams{
+				SocSteps:           int(Num(sc.Params, "soc_steps", 200)),
+				PowerSteps:         int(Num(sc.Params, "power_steps", 10)),
+				CarbonPricePerTCO2: Num(sc.Params, "carbon_price", 0),
+			})
+		})
+	case "wasm":
+		path, err := resolve(opts.ResolveModule, Str(sc.Params, "module", ""))
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			return nil, fmt.Errorf("wasm strategy requires params.module")
+		}
+		limits := strategy.WasmLimits{
+			MaxMemoryPages: uint32(Num(sc.Params, "max_memory_pages", 0)),
+			CallTimeout:    time.Duration(Num(sc.Params, "call_timeout_ms", 0)) * time.Millisecond,
+		}
+		if opts.WasmLimits != nil {
+			if limits, err = opts.WasmLimits(sc.Params); err != nil {
+				return nil, err
+			}
+		}
+		return strategy.NewWasmStrategyFromFile(path, limits)
+	case "stochastic":
+		return opts.Plans.get(planKey, func() (strategy.Strategy, error) {
+			return strategy.NewScenarioStrategy(intervals, batt.Params, batt.State.SOC, strategy.ScenarioParams{
+				SocSteps:   int(Num(sc.Params, "soc_steps", 200)),
+				PowerSteps: int(Num(sc.Params, "power_steps", 10)),
+				Objective:  Str(sc.Params, "objective", "expected"),
+				CVaRAlpha:  Num(sc.Params, "cvar_alpha", 0.1),
+				Source:     strategy.AnalogDays{K: int(Num(sc.Params, "scenarios", 10)), SameDayType: true},
+			})
+		})
+	case "qlearn":
+		path, err := resolve(opts.ResolvePolicy, Str(sc.Params, "policy", ""))
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			return nil, fmt.Errorf("qlearn strategy requires params.policy (train one with `cli train`)")
+		}
+		policy, err := strategy.LoadQPolicy(path)
+		if err != nil {
+			return nil, err
+		}
+		return strategy.NewQLearnStrategy(policy, intervals)
+	case "bidcurve":
+		segments, err := strategy.BidSegmentsFromParams(sc.Params["segments"])
+		if err != nil {
+			return nil, err
+		}
+		return strategy.NewBidCurveStrategy(segments)
+	case "constant":
+		return &strategy.ConstantStrategy{PowerMW: Num(sc.Params, "power_mw", 0)}, nil
+	case "overlay", "calendar":
+		layers := make([]strategy.Layer, 0, len(sc.Children))
+		built := make([]strategy.Strategy, 0, len(sc.Children))
+		for i, child := range sc.Children {
+			s, err := node(fmt.Sprintf("%s/%d", path, i), child, cfg, intervals, batt, opts)
+			if err != nil {
+				return nil, closeOnError(built, err)
+			}
+			built = append(built, s)
+			layer := strategy.Layer{Strategy: s}
+			// A guard's when is its own veto condition, not a gate.
+			if child.When != nil && child.Name != "guard" {
+				cond, err := Condition(*child.When)
+				if err != nil {
+					return nil, closeOnError(built, err)
+				}
+				layer.When = &cond
+			}
+			layers = append(layers, layer)
+		}
+		newFn := strategy.NewOverlayStrategy
+		if sc.Name == "calendar" {
+			newFn = strategy.NewCalendarStrategy
+		}
+		s, err := newFn(layers)
+		if err != nil {
+			return nil, closeOnError(built, err)
+		}
+		return s, nil
+	case "guard":
+		if len(sc.Children) != 1 || sc.When == nil {
+			return nil, fmt.Errorf("guard requires exactly one child and a when condition")
+		}
+		cond, err := Condition(*sc.When)
+		if err != nil {
+			return nil, err
+		}
+		inner, err := node(path+"/0", sc.Children[0], cfg, intervals, batt, opts)
+		if err != nil {
+			return nil, err
+		}
+		s, err := strategy.NewGuardStrategy(inner, cond, strategy.Direction(Str(sc.Params, "forbid", "both")))
+		if err != nil {
+			return nil, closeOnError([]strategy.Strategy{inner}, err)
+		}
+		return s, nil
+	case "blend":
+		children := make([]strategy.Strategy, 0, len(sc.Children))
+		for i, child := range sc.Children {
+			s, err := node(fmt.Sprintf("%s/%d", path, i), child, cfg, intervals, batt, opts)
+			if err != nil {
+				return nil, closeOnError(children, err)
+			}
+			children = append(children, s)
+		}
+		weights, err := Nums(sc.Params, "weights")
+		if err != nil {
+			return nil, closeOnError(children, err)
+		}
+		s, err := strategy.NewBlendStrategy(children, weights)
+		if err != nil {
+			return nil, closeOnError(children, err)
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unsupported strategy: %q", sc.Name)
+	}
+}
+
+// resolve maps a file reference through fn; an empty ref stays empty.
+func resolve(fn func(string) (string, error), ref string) (string, error) {
+	if fn == nil || ref == "" {
+		return ref, nil
+	}
+	return fn(ref)
+}
+
+// closeOnError releases the children built before err, so a failed build
+// does not leak WASM runtimes.
+func closeOnError(built []strategy.Strategy, err error) error {
+	if cerr := strategy.CloseAll(built); cerr != nil {
+		return fmt.Errorf("%w (closing built children: %v)", err, cerr)
+	}
+	return err
+}
+
+// Condition converts a YAML condition to a strategy.Condition.
+func Condition(c config.ConditionConfig) (strategy.Condition, error) {
+	if err := c.Validate(); err != nil {
+		return strategy.Condition{}, err
+	}
+	out := strategy.Condition{
+		LMPAbove: c.LMPAbove,
+		LMPBelow: c.LMPBelow,
+		SOCAbove: c.SOCAbove,
+		SOCBelow: c.SOCBelow,
+		Hours:    c.Hours,
+	}
+	for _, m := range c.Months {
+		out.Months = append(out.Months, time.Month(m))
+	}
+	days, err := c.ParseWeekdays()
+	if err != nil {
+		return strategy.Condition{}, err
+	}
+	out.Weekdays = days
+	return out, nil
+}
+
+// Availability converts and validates the outage model.
+func Availability(a *config.AvailabilityConfig) (backtest.Availability, error) {
+	out := backtest.Availability{ForcedOutageRate: a.ForcedOutageRate, MTTRHours: a.MTTRHours}
+	for _, w := range a.Maintenance {
+		out.Maintenance = append(out.Maintenance, backtest.MaintenanceWindow{Start: w.Start, End: w.End})
+	}
+	if err := out.Validate(); err != nil {
+		return backtest.Availability{}, fmt.Errorf("availability config invalid: %w", err)
+	}
+	return out, nil
+}
+
+// Bootstrap converts the bootstrap config to the generator's parameters.
+func Bootstrap(b *config.BootstrapConfig) synthetic.BootstrapParams {
+	return synthetic.BootstrapParams{Paths: b.Paths, BlockDays: b.BlockDays, Seed: b.Seed}
+}
+
+// Num reads a number param, or def if it is absent.
+func Num(m map[string]any, key string, def float64) float64 {
+	if v, ok := m[key]; ok && v != nil {
+		switch x := v.(type) {
+		case float64:
+			return x
+		case int:
+			return float64(x)
+		}
+	}
+	return def
+}
+
+// Nums reads a list of numbers; nil if the key is absent.
+func Nums(m map[string]any, key string) ([]float64, error) {
+	v, ok := m[key]
+	if !ok || v == nil {
+		return nil, nil
+	}
+	xs, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a list of numbers", key)
+	}
+	out := make([]float64, 0, len(xs))
+	for _, x := range xs {
+		switch n := x.(type) {
+		case float64:
+			out = append(out, n)
+		case int:
+			out = append(out, float64(n))
+		default:
+			return nil, fmt.Errorf("%s must be a list of numbers", key)
+		}
+	}
+	return out, nil
+}
+
+// Str reads a non-blank string param, or def.
+func Str(m map[string]any, key string, def string) string {
+	if v, ok := m[key]; ok && v != nil {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			return s
+		}
+	}
+	return def
+}
@@ -0,0 +1,70 @@
+package builder
+
+import (
+	"testing"
+	"time"
+
+	"battery-backtest/internal/config"
+	"battery-backtest/internal/data/synthetic"
+	"battery-backtest/internal/model"
+	"battery-backtest/internal/strategy"
+)
+
+func TestPlansShareOracle(t *testing.T) {
+	p := synthetic.DefaultParams(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+	p.Days = 1
+	intervals, err := synthetic.Generate(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Battery: config.BatteryConfig{
+			EnergyCapacityMWh:   4,
+			PowerCapacityMW:     1,
+			ChargeEfficiency:    0.95,
+			DischargeEfficiency: 0.95,
+			MinSOC:              0.1,
+			MaxSOC:              0.9,
+		},
+		Strategy: config.StrategyConfig{
+			Name: "overlay",
+			Children: []config.StrategyConfig{
+				{Name: "oracle", Params: map[string]any{"soc_steps": 20}},
+				{Name: "oracle", Params: map[string]any{"soc_steps": 40}},
+			},
+		},
+	}
+	build := func(plans *Plans, soc float64) []strategy.Strategy {
+		t.Helper()
+		batt, err := model.NewBattery(cfg.Battery.ToModelParams(), soc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := Strategy(cfg, intervals, batt, Options{Plans: plans})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []strategy.Strategy
+		for _, l := range s.(*strategy.OverlayStrategy).Layers {
+			out = append(out, l.Strategy)
+		}
+		return out
+	}
+
+	plans := NewPlans()
+	a, b := build(plans, 0.1), build(plans, 0.1)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Errorf("child %d: plan was solved twice", i)
+		}
+	}
+	if a[0] == a[1] {
+		t.Error("different nodes share a plan")
+	}
+	if c := build(plans, 0.5); c[0] == a[0] {
+		t.Error("a different starting SOC reused the plan")
+	}
+	if c := build(nil, 0.1); c[0] == a[0] {
+		t.Error("nil plans reused a plan")
+	}
+}
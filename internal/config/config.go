@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"battery-backtest/internal/model"
 
@@ -18,6 +20,47 @@ type Config struct {
 	BatteryFile string         `yaml:"battery_file"`
 	Battery     BatteryConfig  `yaml:"battery"`
 	Strategy    StrategyConfig `yaml:"strategy"`
+
+	// Availability enables outage sampling and Monte Carlo trials.
+	Availability *AvailabilityConfig `yaml:"availability"`
+
+	// Bootstrap reruns the backtest on price paths resampled from the history.
+	Bootstrap *BootstrapConfig `yaml:"bootstrap"`
+}
+
+// BootstrapConfig configures block-bootstrap price paths (see
+// synthetic.Bootstrap).
+type BootstrapConfig struct {
+	Paths     int   `yaml:"paths"`      // default 20
+	BlockDays int   `yaml:"block_days"` // default 1
+	Seed      int64 `yaml:"seed"`
+}
+
+// AvailabilityConfig configures forced/planned outages (see backtest.Availability
+// and builder.Availability).
+type AvailabilityConfig struct {
+	ForcedOutageRate float64             `yaml:"forced_outage_rate"`
+	MTTRHours        float64             `yaml:"mttr_hours"`
+	Maintenance      []MaintenanceWindow `yaml:"maintenance"`
+
+	// Trials is the number of Monte Carlo trials (default 20); Seed seeds trial
+	// i with Seed+i. The reported ledger is trial 0.
+	Trials int   `yaml:"trials"`
+	Seed   int64 `yaml:"seed"`
+}
+
+// MaintenanceWindow is a planned outage with RFC3339 bounds.
+type MaintenanceWindow struct {
+	Start time.Time `yaml:"start"`
+	End   time.Time `yaml:"end"`
+}
+
+// TrialCount returns Trials or the default.
+func (a *AvailabilityConfig) TrialCount() int {
+	if a.Trials > 0 {
+		return a.Trials
+	}
+	return 20
 }
 
 type BatteryConfig struct {
@@ -30,11 +73,264 @@ type BatteryConfig struct {
 	MaxSOC                float64 `yaml:"max_soc"`
 	InitialSOC            float64 `yaml:"initial_soc"`
 	DegradationCostPerMWh float64 `yaml:"degradation_cost_per_mwh"`
+
+	// Optional direction-specific ratings (default: power_capacity_mw) and
+	// SOC-dependent tapers.
+	MaxChargeMW         float64           `yaml:"max_charge_mw"`
+	MaxDischargeMW      float64           `yaml:"max_discharge_mw"`
+	ChargePowerCurve    []PowerCurvePoint `yaml:"charge_power_curve"`
+	DischargePowerCurve []PowerCurvePoint `yaml:"discharge_power_curve"`
+
+	// Optional efficiency-vs-power curves (default: the constant efficiencies),
+	// auxiliary load and self-discharge.
+	ChargeEfficiencyCurve    []EfficiencyPoint `yaml:"charge_efficiency_curve"`
+	DischargeEfficiencyCurve []EfficiencyPoint `yaml:"discharge_efficiency_curve"`
+	AuxLoadMW                float64           `yaml:"aux_load_mw"`
+	Islanded                 bool              `yaml:"islanded"`
+	SelfDischargePctPerDay   float64           `yaml:"self_discharge_pct_per_day"`
+
+	// Optional operating constraints (0 = off).
+	RampRateMWPerMin  float64 `yaml:"ramp_rate_mw_per_min"`
+	MinModeMinutes    float64 `yaml:"min_mode_minutes"`
+	ModeSwitchCostUSD float64 `yaml:"mode_switch_cost_usd"`
+
+	// Optional warranty caps in equivalent full cycles (0 = unlimited).
+	MaxCyclesPerDay  float64 `yaml:"max_cycles_per_day"`
+	MaxCyclesPerYear float64 `yaml:"max_cycles_per_year"`
+
+	// Optional lumped thermal model (see model.ThermalParams).
+	Thermal *ThermalConfig `yaml:"thermal"`
+
+	// Optional settlement cost stack (see model.SettlementParams).
+	Settlement *SettlementConfig `yaml:"settlement"`
 }
 
+// SettlementConfig configures fees and charges beyond the LMP.
+type SettlementConfig struct {
+	ChargingFeePerMWh      float64 `yaml:"charging_fee_per_mwh"`
+	ThroughputFeePerMWh    float64 `yaml:"throughput_fee_per_mwh"`
+	DemandChargePerMWMonth float64 `yaml:"demand_charge_per_mw_month"`
+	FixedChargePerMWMonth  float64 `yaml:"fixed_charge_per_mw_month"`
+
+	// Optional settlement price floor/cap, scarcity reporting threshold and
+	// bid cost recovery.
+	PriceFloor      *float64 `yaml:"price_floor"`
+	PriceCap        *float64 `yaml:"price_cap"`
+	ScarcityPrice   float64  `yaml:"scarcity_price"`
+	BidCostRecovery bool     `yaml:"bid_cost_recovery"`
+	BidCostPerMWh   float64  `yaml:"bid_cost_per_mwh"`
+}
+
+func (s *SettlementConfig) toModel() *model.SettlementParams {
+	if s == nil {
+		return nil
+	}
+	return &model.SettlementParams{
+		ChargingFeePerMWh:      s.ChargingFeePerMWh,
+		ThroughputFeePerMWh:    s.ThroughputFeePerMWh,
+		DemandChargePerMWMonth: s.DemandChargePerMWMonth,
+		FixedChargePerMWMonth:  s.FixedChargePerMWMonth,
+		PriceFloor:             s.PriceFloor,
+		PriceCap:               s.PriceCap,
+		ScarcityPrice:          s.ScarcityPrice,
+		BidCostRecovery:        s.BidCostRecovery,
+		BidCostPerMWh:          s.BidCostPerMWh,
+	}
+}
+
+// ThermalConfig configures the cell temperature model.
+type ThermalConfig struct {
+	HeatCapacityMWhPerC float64 `yaml:"heat_capacity_mwh_per_c"`
+	ConductanceMWPerC   float64 `yaml:"conductance_mw_per_c"`
+	HVACSetpointC       float64 `yaml:"hvac_setpoint_c"`
+	HVACCoolingMW       float64 `yaml:"hvac_cooling_mw"`
+	HVACCOP             float64 `yaml:"hvac_cop"`
+	DefaultAmbientC     float64 `yaml:"default_ambient_c"`
+	DerateStartC        float64 `yaml:"derate_start_c"`
+	DerateEndC          float64 `yaml:"derate_end_c"`
+	MinDerate           float64 `yaml:"min_derate"`
+	ReferenceTempC      float64 `yaml:"reference_temp_c"`
+	EfficiencyLossPerC  float64 `yaml:"efficiency_loss_per_c"`
+}
+
+func (t *ThermalConfig) toModel() *model.ThermalParams {
+	if t == nil {
+		return nil
+	}
+	return &model.ThermalParams{
+		HeatCapacityMWhPerC: t.HeatCapacityMWhPerC,
+		ConductanceMWPerC:   t.ConductanceMWPerC,
+		HVACSetpointC:       t.HVACSetpointC,
+		HVACCoolingMW:       t.HVACCoolingMW,
+		HVACCOP:             t.HVACCOP,
+		DefaultAmbient:      t.DefaultAmbientC,
+		DerateStartC:        t.DerateStartC,
+		DerateEndC:          t.DerateEndC,
+		MinDerate:           t.MinDerate,
+		ReferenceTempC:      t.ReferenceTempC,
+		EfficiencyLossPerC:  t.EfficiencyLossPerC,
+	}
+}
+
+// EfficiencyPoint is one point of an efficiency curve; power_fraction is
+// |power| divided by the direction's rating.
+type EfficiencyPoint struct {
+	PowerFraction float64 `yaml:"power_fraction"`
+	Efficiency    float64 `yaml:"efficiency"`
+}
+
+func toEfficiencyCurve(points []EfficiencyPoint) model.EfficiencyCurve {
+	if len(points) == 0 {
+		return nil
+	}
+	out := make(model.EfficiencyCurve, len(points))
+	for i, pt := range points {
+		out[i] = model.EfficiencyPoint{PowerFraction: pt.PowerFraction, Efficiency: pt.Efficiency}
+	}
+	return out
+}
+
+// PowerCurvePoint is one point of a piecewise-linear SOC-to-max-power curve.
+type PowerCurvePoint struct {
+	SOC   float64 `yaml:"soc"`
+	MaxMW float64 `yaml:"max_mw"`
+}
+
+func toPowerCurve(points []PowerCurvePoint) model.PowerCurve {
+	if len(points) == 0 {
+		return nil
+	}
+	out := make(model.PowerCurve, len(points))
+	for i, pt := range points {
+		out[i] = model.PowerCurvePoint{SOC: pt.SOC, MaxMW: pt.MaxMW}
+	}
+	return out
+}
+
+// StrategyConfig is a node in a (possibly nested) strategy tree.
+// Leaf strategies (schedule, oracle, ...) use Params only; combinators
+// (overlay, calendar, guard, blend) wrap Children.
 type StrategyConfig struct {
 	Name   string         `yaml:"name"`
 	Params map[string]any `yaml:"params"`
+
+	// When gates this node inside an overlay/calendar. For a guard it is
+	// the veto condition instead (guards are never gated).
+	When     *ConditionConfig `yaml:"when,omitempty"`
+	Children []StrategyConfig `yaml:"children,omitempty"`
+}
+
+// ConditionConfig is the YAML shape of strategy.Condition (see builder.Condition).
+type ConditionConfig struct {
+	LMPAbove *float64 `yaml:"lmp_above"`
+	LMPBelow *float64 `yaml:"lmp_below"`
+	SOCAbove *float64 `yaml:"soc_above"`
+	SOCBelow *float64 `yaml:"soc_below"`
+	Months   []int    `yaml:"months"`   // 1-12
+	Weekdays []string `yaml:"weekdays"` // mon..sun, or "weekday"/"weekend"
+	Hours    []int    `yaml:"hours"`    // local hour of interval start, 0-23
+}
+
+// compositeStrategies lists the strategy names that wrap children.
+var compositeStrategies = map[string]bool{
+	"overlay":  true,
+	"calendar": true,
+	"guard":    true,
+	"blend":    true,
+}
+
+// IsComposite reports whether the node is a combinator.
+func (s StrategyConfig) IsComposite() bool {
+	return compositeStrategies[s.Name]
+}
+
+// Validate checks the tree shape. Strategy-specific params are checked when
+// the strategy is built.
+func (s StrategyConfig) Validate() error {
+	if s.Name == "" {
+		return errors.New("strategy.name is required")
+	}
+	if !s.IsComposite() && len(s.Children) > 0 {
+		return fmt.Errorf("strategy %q does not take children", s.Name)
+	}
+	switch s.Name {
+	case "overlay", "calendar", "blend":
+		if len(s.Children) == 0 {
+			return fmt.Errorf("strategy %q requires at least one child", s.Name)
+		}
+	case "guard":
+		if len(s.Children) != 1 {
+			return errors.New("strategy \"guard\" requires exactly one child")
+		}
+		if s.When == nil {
+			return errors.New("strategy \"guard\" requires a when condition")
+		}
+	}
+	if s.When != nil {
+		if err := s.When.Validate(); err != nil {
+			return err
+		}
+	}
+	for i, child := range s.Children {
+		if err := child.Validate(); err != nil {
+			return fmt.Errorf("%s.children[%d]: %w", s.Name, i, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the month, hour and weekday values.
+func (c ConditionConfig) Validate() error {
+	for _, m := range c.Months {
+		if m < 1 || m > 12 {
+			return fmt.Errorf("invalid month %d (expected 1-12)", m)
+		}
+	}
+	for _, h := range c.Hours {
+		if h < 0 || h > 23 {
+			return fmt.Errorf("invalid hour %d (expected 0-23)", h)
+		}
+	}
+	_, err := c.ParseWeekdays()
+	return err
+}
+
+// ParseWeekdays expands the weekday names, including "weekday" and "weekend".
+func (c ConditionConfig) ParseWeekdays() ([]time.Weekday, error) {
+	var out []time.Weekday
+	for _, d := range c.Weekdays {
+		days, err := parseWeekday(d)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, days...)
+	}
+	return out, nil
+}
+
+func parseWeekday(s string) ([]time.Weekday, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "sun", "sunday":
+		return []time.Weekday{time.Sunday}, nil
+	case "mon", "monday":
+		return []time.Weekday{time.Monday}, nil
+	case "tue", "tuesday":
+		return []time.Weekday{time.Tuesday}, nil
+	case "wed", "wednesday":
+		return []time.Weekday{time.Wednesday}, nil
+	case "thu", "thursday":
+		return []time.Weekday{time.Thursday}, nil
+	case "fri", "friday":
+		return []time.Weekday{time.Friday}, nil
+	case "sat", "saturday":
+		return []time.Weekday{time.Saturday}, nil
+	case "weekday", "weekdays":
+		return []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, nil
+	case "weekend", "weekends":
+		return []time.Weekday{time.Saturday, time.Sunday}, nil
+	default:
+		return nil, fmt.Errorf("invalid weekday %q", s)
+	}
 }
 
 func Load(path string) (*Config, error) {
@@ -88,8 +384,11 @@ func (c *Config) Validate() error {
 	if c == nil {
 		return errors.New("config is nil")
 	}
-	if c.Strategy.Name == "" {
-		return errors.New("strategy.name is required")
+	if err := c.Strategy.Validate(); err != nil {
+		return err
+	}
+	if b := c.Bootstrap; b != nil && (b.Paths < 0 || b.BlockDays < 0) {
+		return errors.New("bootstrap paths and block_days must be >= 0")
 	}
 	// Validate battery params by constructing a model.Battery.
 	params := c.Battery.ToModelParams()
@@ -109,6 +408,24 @@ func (b BatteryConfig) ToModelParams() model.BatteryParams {
 		MinSOC:                b.MinSOC,
 		MaxSOC:                b.MaxSOC,
 		DegradationCostPerMWh: b.DegradationCostPerMWh,
+		MaxChargeMW:           b.MaxChargeMW,
+		MaxDischargeMW:        b.MaxDischargeMW,
+		ChargeCurve:           toPowerCurve(b.ChargePowerCurve),
+		DischargeCurve:        toPowerCurve(b.DischargePowerCurve),
+
+		ChargeEfficiencyCurve:    toEfficiencyCurve(b.ChargeEfficiencyCurve),
+		DischargeEfficiencyCurve: toEfficiencyCurve(b.DischargeEfficiencyCurve),
+		AuxLoadMW:                b.AuxLoadMW,
+		Islanded:                 b.Islanded,
+		SelfDischargePctPerDay:   b.SelfDischargePctPerDay,
+
+		RampRateMWPerMin:  b.RampRateMWPerMin,
+		MinModeMinutes:    b.MinModeMinutes,
+		ModeSwitchCostUSD: b.ModeSwitchCostUSD,
+		MaxCyclesPerDay:   b.MaxCyclesPerDay,
+		MaxCyclesPerYear:  b.MaxCyclesPerYear,
+		Thermal:           b.Thermal.toModel(),
+		Settlement:        b.Settlement.toModel(),
 	}
 }
 
@@ -160,5 +477,53 @@ func MergeBattery(base, override BatteryConfig) BatteryConfig {
 	if override.DegradationCostPerMWh != 0 {
 		out.DegradationCostPerMWh = override.DegradationCostPerMWh
 	}
+	if override.MaxChargeMW != 0 {
+		out.MaxChargeMW = override.MaxChargeMW
+	}
+	if override.MaxDischargeMW != 0 {
+		out.MaxDischargeMW = override.MaxDischargeMW
+	}
+	if override.ChargePowerCurve != nil {
+		out.ChargePowerCurve = override.ChargePowerCurve
+	}
+	if override.DischargePowerCurve != nil {
+		out.DischargePowerCurve = override.DischargePowerCurve
+	}
+	if override.ChargeEfficiencyCurve != nil {
+		out.ChargeEfficiencyCurve = override.ChargeEfficiencyCurve
+	}
+	if override.DischargeEfficiencyCurve != nil {
+		out.DischargeEfficiencyCurve = override.DischargeEfficiencyCurve
+	}
+	if override.AuxLoadMW != 0 {
+		out.AuxLoadMW = override.AuxLoadMW
+	}
+	if override.Islanded {
+		out.Islanded = true
+	}
+	if override.SelfDischargePctPerDay != 0 {
+		out.SelfDischargePctPerDay = override.SelfDischargePctPerDay
+	}
+	if override.RampRateMWPerMin != 0 {
+		out.RampRateMWPerMin = override.RampRateMWPerMin
+	}
+	if override.MinModeMinutes != 0 {
+		out.MinModeMinutes = override.MinModeMinutes
+	}
+	if override.ModeSwitchCostUSD != 0 {
+		out.ModeSwitchCostUSD = override.ModeSwitchCostUSD
+	}
+	if override.MaxCyclesPerDay != 0 {
+		out.MaxCyclesPerDay = override.MaxCyclesPerDay
+	}
+	if override.MaxCyclesPerYear != 0 {
+		out.MaxCyclesPerYear = override.MaxCyclesPerYear
+	}
+	if override.Thermal != nil {
+		out.Thermal = override.Thermal
+	}
+	if override.Settlement != nil {
+		out.Settlement = override.Settlement
+	}
 	return out
 }
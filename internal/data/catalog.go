@@ -0,0 +1,148 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CatalogPageSize is the page size requested when listing locations.
+const CatalogPageSize = 1000
+
+// catalogPage is one page of the provider's location listing:
+//
+//	GET /v1/datasets/{dataset_id}/locations?page=N&page_size=M
+type catalogPage struct {
+	Data []struct {
+		Location     string   `json:"location"`
+		Name         string   `json:"name"`
+		LocationType string   `json:"location_type"`
+		Market       string   `json:"market"`
+		Zone         string   `json:"zone"`
+		Latitude     *float64 `json:"latitude"`
+		Longitude    *float64 `json:"longitude"`
+	} `json:"data"`
+	Meta struct {
+		Page        int  `json:"page"`
+		HasNextPage bool `json:"hasNextPage"`
+	} `json:"meta"`
+}
+
+// ListLocations discovers every location of a dataset from the provider's
+// listing API, following pagination.
+func (c *GridStatusClient) ListLocations(ctx context.Context, datasetID string) ([]Location, error) {
+	if err := c.validateAPIKey(); err != nil {
+		return nil, err
+	}
+	if datasetID == "" {
+		return nil, fmt.Errorf("dataset_id is required")
+	}
+	var out []Location
+	for page := 1; ; page++ {
+		u, err := url.Parse(fmt.Sprintf("%s/v1/datasets/%s/locations", c.BaseURL, datasetID))
+		if err != nil {
+			return nil, fmt.Errorf("invalid base URL: %w", err)
+		}
+		q := u.Query()
+		q.Set("page", strconv.Itoa(page))
+		q.Set("page_size", strconv.Itoa(CatalogPageSize))
+		u.RawQuery = q.Encode()
+		log.Printf("[GridStatus] Request: GET %s (dataset=%s, page=%d)", u.Path, datasetID, page)
+
+		var resp catalogPage
+		if err := c.getJSON(ctx, u, fmt.Sprintf("dataset=%s, page=%d", datasetID, page), &resp); err != nil {
+			return nil, err
+		}
+		for _, d := range resp.Data {
+			out = append(out, Location{
+				ID:        d.Location,
+				Name:      d.Name,
+				Type:      d.LocationType,
+				Market:    d.Market,
+				DatasetID: datasetID,
+				Zone:      d.Zone,
+				Latitude:  d.Latitude,
+				Longitude: d.Longitude,
+			})
+		}
+		if !resp.Meta.HasNextPage || len(resp.Data) == 0 {
+			return out, nil
+		}
+	}
+}
+
+// LocationQuery filters and searches a location catalog.
+type LocationQuery struct {
+	Search string // matched against ID and name, case-insensitively
+	Type   string // exact location type (case-insensitive)
+	Zone   string // exact zone (case-insensitive)
+}
+
+// SearchLocations returns the locations matching q, best matches first:
+// exact ID or name, then prefix, then substring, then fuzzy (the search
+// characters appear in order, e.g. "mossps" matches MOSSLD_2_PSP1). Ties and
+// an empty search keep ID order.
+func SearchLocations(locations []Location, q LocationQuery) []Location {
+	search := strings.ToLower(strings.TrimSpace(q.Search))
+	type scored struct {
+		loc   Location
+		score int
+	}
+	var matches []scored
+	for _, loc := range locations {
+		if q.Type != "" && !strings.EqualFold(loc.Type, q.Type) {
+			continue
+		}
+		if q.Zone != "" && !strings.EqualFold(loc.Zone, q.Zone) {
+			continue
+		}
+		score := 0
+		if search != "" {
+			score = max(matchScore(strings.ToLower(loc.ID), search), matchScore(strings.ToLower(loc.Name), search))
+			if score == 0 {
+				continue
+			}
+		}
+		matches = append(matches, scored{loc, score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].loc.ID < matches[j].loc.ID
+	})
+	out := make([]Location, len(matches))
+	for i, m := range matches {
+		out[i] = m.loc
+	}
+	return out
+}
+
+// matchScore ranks how well s matches search (0 = no match).
+func matchScore(s, search string) int {
+	switch {
+	case s == "":
+		return 0
+	case s == search:
+		return 4
+	case strings.HasPrefix(s, search):
+		return 3
+	case strings.Contains(s, search):
+		return 2
+	}
+	// Fuzzy: every search character appears in s, in order.
+	i := 0
+	for _, r := range s {
+		if i < len(search) && rune(search[i]) == r {
+			i++
+		}
+	}
+	if i == len(search) {
+		return 1
+	}
+	return 0
+}
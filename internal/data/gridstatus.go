@@ -1,11 +1,14 @@
 package data
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
+	"os"
 	"time"
 
 	"battery-backtest/internal/model"
@@ -16,11 +19,24 @@ type GridStatusClient struct {
 	APIKey  string
 	BaseURL string
 	Client  *http.Client
+
+	// Store, when set, serves queries for fully synced days before any live
+	// request (default: the store at PRICE_STORE_DIR, if set).
+	Store *Store
+
+	// Retry controls retries of rate-limited and failed requests.
+	Retry RetryPolicy
+	// Limiter, when set, paces requests (default: shared per API key, see LimiterFor).
+	Limiter *RateLimiter
 }
 
 // NewGridStatusClient creates a new Grid Status API client.
-// If baseURL is empty, defaults to "https://api.gridstatus.io".
+// If baseURL is empty, defaults to GRIDSTATUS_BASE_URL (e.g. a local
+// cmd/gridstatus-mock server), then "https://api.gridstatus.io".
 func NewGridStatusClient(apiKey string, baseURL string) *GridStatusClient {
+	if baseURL == "" {
+		baseURL = os.Getenv("GRIDSTATUS_BASE_URL")
+	}
 	if baseURL == "" {
 		baseURL = "https://api.gridstatus.io"
 	}
@@ -30,6 +46,9 @@ func NewGridStatusClient(apiKey string, baseURL string) *GridStatusClient {
 		Client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		Store:   GetStore(),
+		Retry:   DefaultRetryPolicy,
+		Limiter: LimiterFor(apiKey),
 	}
 }
 
@@ -55,12 +74,50 @@ func (e *GridStatusError) Error() string {
 	return e.Message
 }
 
+// IsAuthError reports whether err is a missing, malformed or rejected API key,
+// which will fail every request made with that key.
+func IsAuthError(err error) bool {
+	var gsErr *GridStatusError
+	if !errors.As(err, &gsErr) {
+		return false
+	}
+	switch gsErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return true
+	case 0:
+		return gsErr.Code == "MISSING_API_KEY" || gsErr.Code == "INVALID_API_KEY_FORMAT"
+	}
+	return false
+}
+
 // QueryLocation fetches LMP data for a specific location from Grid Status API.
-// 
+// It is QueryLocationContext without cancellation.
+//
 // WARNING: If caching is enabled (ENABLE_GRIDSTATUS_CACHE=true), responses may be cached.
 // Caching is ONLY for LOCAL DEVELOPMENT. Check Grid Status Terms of Use before enabling
 // in any production-like environment. Caching API responses may violate their terms.
 func (c *GridStatusClient) QueryLocation(params QueryLocationParams) (*model.GridStatusLMPResponse, error) {
+	return c.QueryLocationContext(context.Background(), params)
+}
+
+// QueryLocationContext fetches LMP data for a specific location. Requests wait
+// for the client's rate limiter, and 429, 5xx and transport failures are
+// retried per c.Retry (honoring Retry-After). Cancelling ctx aborts waits,
+// retries and the request in flight.
+func (c *GridStatusClient) QueryLocationContext(ctx context.Context, params QueryLocationParams) (*model.GridStatusLMPResponse, error) {
+	Metrics.update(func(m *MetricsSnapshot) { m.Queries++ })
+
+	// Serve synced days from the local price store; no API key is needed.
+	if c.Store != nil {
+		if rows, ok := c.Store.Lookup(params); ok {
+			Metrics.update(func(m *MetricsSnapshot) { m.StoreHits++ })
+			log.Printf("[GridStatus] Store hit: %d intervals (dataset=%s, location=%s, start=%s, end=%s)",
+				len(rows), params.DatasetID, params.LocationID,
+				params.StartTime.Format("2006-01-02"), params.EndTime.Format("2006-01-02"))
+			return &model.GridStatusLMPResponse{StatusCode: http.StatusOK, Data: rows}, nil
+		}
+	}
+
 	// Validate API key before making request
 	if err := c.validateAPIKey(); err != nil {
 		return nil, err
@@ -72,6 +129,7 @@ func (c *GridStatusClient) QueryLocation(params QueryLocationParams) (*model.Gri
 		cacheKey := GenerateCacheKey(params)
 		if cached, found := cache.Get(cacheKey); found {
 			// Return cached response
+			Metrics.update(func(m *MetricsSnapshot) { m.CacheHits++ })
 			dataCount := 0
 			if cached.Data != nil {
 				dataCount = len(cached.Data)
@@ -125,10 +183,70 @@ func (c *GridStatusClient) QueryLocation(params QueryLocationParams) (*model.Gri
 		params.EndTime.Format("2006-01-02"),
 		q.Get("timezone"))
 
+	var result model.GridStatusLMPResponse
+	label := fmt.Sprintf("dataset=%s, location=%s", params.DatasetID, params.LocationID)
+	if err := c.getJSON(ctx, u, label, &result); err != nil {
+		return nil, err
+	}
+
+	// Log successful response with data count
+	dataCount := 0
+	if result.Data != nil {
+		dataCount = len(result.Data)
+	}
+	log.Printf("[GridStatus] Success: Received %d intervals (%s)", dataCount, label)
+
+	// Cache the response if caching is enabled (development only)
+	if cache := GetCache(); cache != nil {
+		cacheKey := GenerateCacheKey(params)
+		cache.Set(cacheKey, &result)
+		log.Printf("[GridStatus] Cached response (dataset=%s, location=%s)", params.DatasetID, params.LocationID)
+	}
+
+	return &result, nil
+}
+
+// getJSON GETs u into out. Requests wait for the rate limiter, and 429, 5xx
+// and transport failures are retried per c.Retry. label identifies the
+// request in logs.
+func (c *GridStatusClient) getJSON(ctx context.Context, u *url.URL, label string, out interface{}) error {
+	for attempt := 0; ; attempt++ {
+		if c.Limiter != nil {
+			waited, err := c.Limiter.Wait(ctx)
+			if waited > 0 {
+				Metrics.update(func(m *MetricsSnapshot) { m.RateLimitWaits++; m.RateLimitWait += waited })
+			}
+			if err != nil {
+				return err
+			}
+		}
+		err := c.getOnce(ctx, u, label, out)
+		if err == nil {
+			return nil
+		}
+		delay, retry := c.Retry.delay(attempt, err)
+		if !retry || ctx.Err() != nil {
+			return err
+		}
+		Metrics.update(func(m *MetricsSnapshot) { m.Retries++ })
+		log.Printf("[GridStatus] Retrying in %v (attempt %d of %d): %v (%s)",
+			delay, attempt+2, c.Retry.maxAttempts(), err, label)
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// getOnce makes one HTTP attempt.
+func (c *GridStatusClient) getOnce(ctx context.Context, u *url.URL, label string, out interface{}) error {
 	// Create HTTP request
-	req, err := http.NewRequest("GET", u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set API key header
@@ -140,18 +258,15 @@ func (c *GridStatusClient) QueryLocation(params QueryLocationParams) (*model.Gri
 	resp, err := c.Client.Do(req)
 	duration := time.Since(startTime)
 	if err != nil {
+		Metrics.request(0, duration)
 		log.Printf("[GridStatus] Request failed: %v (duration: %v)", err, duration)
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer resp.Body.Close()
+	Metrics.request(resp.StatusCode, duration)
 
 	// Log the response
-	log.Printf("[GridStatus] Response: %d %s (duration: %v, dataset=%s, location=%s)",
-		resp.StatusCode,
-		resp.Status,
-		duration,
-		params.DatasetID,
-		params.LocationID)
+	log.Printf("[GridStatus] Response: %d %s (duration: %v, %s)", resp.StatusCode, resp.Status, duration, label)
 
 	// Check status code and handle specific errors
 	switch resp.StatusCode {
@@ -159,9 +274,8 @@ func (c *GridStatusClient) QueryLocation(params QueryLocationParams) (*model.Gri
 		// Success, continue
 	case http.StatusForbidden:
 		// 403: Invalid API key or insufficient permissions
-		log.Printf("[GridStatus] Error: 403 Forbidden - Invalid API key or insufficient permissions (dataset=%s, location=%s)",
-			params.DatasetID, params.LocationID)
-		return nil, &GridStatusError{
+		log.Printf("[GridStatus] Error: 403 Forbidden - Invalid API key or insufficient permissions (%s)", label)
+		return &GridStatusError{
 			StatusCode: resp.StatusCode,
 			Code:       "INVALID_API_KEY",
 			Message:    "Invalid API key or insufficient permissions",
@@ -169,9 +283,9 @@ func (c *GridStatusClient) QueryLocation(params QueryLocationParams) (*model.Gri
 	case http.StatusTooManyRequests:
 		// 429: Rate limit exceeded
 		retryAfter := resp.Header.Get("Retry-After")
-		log.Printf("[GridStatus] Error: 429 Rate Limit Exceeded - Retry after: %s (dataset=%s, location=%s)",
-			retryAfter, params.DatasetID, params.LocationID)
-		return nil, &GridStatusError{
+		log.Printf("[GridStatus] Error: 429 Rate Limit Exceeded - Retry after: %s (%s)",
+			retryAfter, label)
+		return &GridStatusError{
 			StatusCode: resp.StatusCode,
 			Code:       "RATE_LIMIT_EXCEEDED",
 			Message:    fmt.Sprintf("Rate limit exceeded. Retry after: %s", retryAfter),
@@ -179,47 +293,30 @@ func (c *GridStatusClient) QueryLocation(params QueryLocationParams) (*model.Gri
 		}
 	case http.StatusUnauthorized:
 		// 401: Unauthorized (bad API key)
-		log.Printf("[GridStatus] Error: 401 Unauthorized - Invalid API key (dataset=%s, location=%s)",
-			params.DatasetID, params.LocationID)
-		return nil, &GridStatusError{
+		log.Printf("[GridStatus] Error: 401 Unauthorized - Invalid API key (%s)", label)
+		return &GridStatusError{
 			StatusCode: resp.StatusCode,
 			Code:       "UNAUTHORIZED",
 			Message:    "Unauthorized: Invalid API key",
 		}
 	default:
 		// Other errors
-		log.Printf("[GridStatus] Error: %d %s (dataset=%s, location=%s)",
-			resp.StatusCode, resp.Status, params.DatasetID, params.LocationID)
-		return nil, &GridStatusError{
+		log.Printf("[GridStatus] Error: %d %s (%s)",
+			resp.StatusCode, resp.Status, label)
+		return &GridStatusError{
 			StatusCode: resp.StatusCode,
 			Code:       "API_ERROR",
 			Message:    fmt.Sprintf("API returned status %d: %s", resp.StatusCode, resp.Status),
+			RetryAfter: resp.Header.Get("Retry-After"),
 		}
 	}
 
 	// Parse JSON response
-	var result model.GridStatusLMPResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		log.Printf("[GridStatus] Error decoding response: %v (dataset=%s, location=%s)", err, params.DatasetID, params.LocationID)
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	// Log successful response with data count
-	dataCount := 0
-	if result.Data != nil {
-		dataCount = len(result.Data)
-	}
-	log.Printf("[GridStatus] Success: Received %d intervals (dataset=%s, location=%s)",
-		dataCount, params.DatasetID, params.LocationID)
-
-	// Cache the response if caching is enabled (development only)
-	if cache := GetCache(); cache != nil {
-		cacheKey := GenerateCacheKey(params)
-		cache.Set(cacheKey, &result)
-		log.Printf("[GridStatus] Cached response (dataset=%s, location=%s)", params.DatasetID, params.LocationID)
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		log.Printf("[GridStatus] Error decoding response: %v (%s)", err, label)
+		return fmt.Errorf("failed to decode response: %w", err)
 	}
-
-	return &result, nil
+	return nil
 }
 
 // validateAPIKey validates that the API key is present and not obviously invalid
@@ -247,6 +344,17 @@ func (c *GridStatusClient) validateAPIKey() error {
 // QueryLocationByString is a convenience method that parses date strings.
 // startDate and endDate should be in "YYYY-MM-DD" format.
 func (c *GridStatusClient) QueryLocationByString(datasetID, locationID, startDate, endDate string) (*model.GridStatusLMPResponse, error) {
+	return c.QueryLocationInZone(context.Background(), datasetID, locationID, startDate, endDate, TimezoneMarket)
+}
+
+// QueryLocationInZone is like QueryLocationByString under a timezone policy
+// (see LoadTimezone). For a named zone the dates are local days in that zone
+// and the local timestamps of the returned intervals are in that zone.
+func (c *GridStatusClient) QueryLocationInZone(ctx context.Context, datasetID, locationID, startDate, endDate, timezone string) (*model.GridStatusLMPResponse, error) {
+	loc, err := LoadTimezone(timezone)
+	if err != nil {
+		return nil, err
+	}
 	startTime, err := time.Parse("2006-01-02", startDate)
 	if err != nil {
 		return nil, fmt.Errorf("invalid start_date format (expected YYYY-MM-DD): %w", err)
@@ -256,12 +364,38 @@ func (c *GridStatusClient) QueryLocationByString(datasetID, locationID, startDat
 		return nil, fmt.Errorf("invalid end_date format (expected YYYY-MM-DD): %w", err)
 	}
 
-	return c.QueryLocation(QueryLocationParams{
+	params := QueryLocationParams{
 		DatasetID:  datasetID,
 		LocationID: locationID,
 		StartTime:  startTime,
 		EndTime:    endTime,
-		Timezone:   "market",
+		Timezone:   TimezoneMarket,
 		Download:   true,
-	})
+	}
+	switch {
+	case loc == time.UTC:
+		params.Timezone = TimezoneUTC
+	case loc != nil:
+		// The provider only knows market and UTC days: pad the market-day
+		// window by a day on each side, then trim to the local days in loc.
+		params.StartTime = startTime.AddDate(0, 0, -1)
+		params.EndTime = endTime.AddDate(0, 0, 1)
+	}
+	resp, err := c.QueryLocationContext(ctx, params)
+	if err != nil || loc == nil {
+		return resp, err
+	}
+
+	// Responses may be cached; convert a copy.
+	from := time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, 0, 0, 0, loc)
+	to := time.Date(endTime.Year(), endTime.Month(), endTime.Day(), 0, 0, 0, 0, loc)
+	out := *resp
+	out.Data = nil
+	for _, it := range resp.Data {
+		if !it.IntervalStartUTC.Before(from) && it.IntervalStartUTC.Before(to) {
+			out.Data = append(out.Data, it)
+		}
+	}
+	ApplyTimezone(out.Data, loc)
+	return &out, nil
 }
@@ -0,0 +1,46 @@
+package data_test
+
+import (
+	"context"
+	"testing"
+
+	"battery-backtest/internal/data"
+	"battery-backtest/internal/data/gridstatusmock"
+)
+
+func TestQueryLocationInZoneDSTDays(t *testing.T) {
+	srv := gridstatusmock.NewServer(gridstatusmock.Options{Synthetic: true})
+	defer srv.Close()
+	client := data.NewGridStatusClient("mock-key-0123456789", srv.URL)
+	client.Store = nil
+	client.Limiter = nil
+
+	tests := []struct {
+		name       string
+		start, end string
+		intervals  int
+	}{
+		{"spring forward", "2024-03-10", "2024-03-11", 276},
+		{"fall back", "2024-11-03", "2024-11-04", 300},
+		{"across spring forward", "2024-03-09", "2024-03-12", 288 + 276 + 288},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := client.QueryLocationInZone(context.Background(), "caiso_lmp_real_time_5_min", "NODE", tc.start, tc.end, "America/Los_Angeles")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Data) != tc.intervals {
+				t.Fatalf("got %d intervals, want %d", len(resp.Data), tc.intervals)
+			}
+			for _, it := range resp.Data {
+				if it.IntervalStartLocal.Location().String() != "America/Los_Angeles" {
+					t.Fatalf("local start %s not in America/Los_Angeles", it.IntervalStartLocal)
+				}
+				if d := it.IntervalStartLocal.Format("2006-01-02"); d < tc.start || d >= tc.end {
+					t.Fatalf("interval on %s outside [%s, %s)", d, tc.start, tc.end)
+				}
+			}
+		})
+	}
+}
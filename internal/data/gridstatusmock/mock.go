@@ -0,0 +1,346 @@
+// Package gridstatusmock is a local stand-in for the Grid Status API, for
+// offline development and tests. It serves
+//
+//	GET /v1/datasets/{dataset_id}/query/location/{location_id}
+//	GET /v1/datasets/{dataset_id}/locations?page=N&page_size=M
+//
+// from local Grid Status JSON files or the synthetic generator, and reproduces
+// the API's 401/403/429 error responses.
+package gridstatusmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"battery-backtest/internal/data"
+	"battery-backtest/internal/data/synthetic"
+	"battery-backtest/internal/model"
+)
+
+// Options configures a mock server.
+type Options struct {
+	// DataDir holds Grid Status JSON files, looked up as
+	// <DataDir>/<dataset_id>/<location_id>.json, then <DataDir>/<location_id>.json.
+	DataDir string
+
+	// Synthetic serves generated prices (seeded by dataset and location) for
+	// locations without a file; otherwise they are 404.
+	Synthetic bool
+
+	// CatalogSize adds that many synthetic nodes (SYNTH_0001, ...) to each
+	// dataset's location listing when Synthetic is set.
+	CatalogSize int
+
+	// APIKeys, when set, are the only keys accepted; others get 403. A
+	// missing key always gets 401.
+	APIKeys []string
+
+	// RateLimit is the number of requests allowed per key per RateWindow
+	// (0 = unlimited); further requests get 429 with Retry-After.
+	RateLimit  int
+	RateWindow time.Duration // default: 1m
+}
+
+// Server handles Grid Status API requests.
+type Server struct {
+	opts Options
+
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// NewHandler returns a handler serving the mock API.
+func NewHandler(opts Options) *Server {
+	if opts.RateWindow <= 0 {
+		opts.RateWindow = time.Minute
+	}
+	return &Server{opts: opts, windows: map[string]*rateWindow{}}
+}
+
+// NewServer starts an in-process mock server; point a client at its URL:
+//
+//	srv := gridstatusmock.NewServer(gridstatusmock.Options{Synthetic: true})
+//	defer srv.Close()
+//	client := data.NewGridStatusClient("any-test-key", srv.URL)
+func NewServer(opts Options) *httptest.Server {
+	return httptest.NewServer(NewHandler(opts))
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if r.Method != http.MethodGet || len(parts) < 4 || parts[0] != "v1" || parts[1] != "datasets" {
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	switch {
+	case len(parts) == 4 && parts[3] == "locations":
+		// /v1/datasets/{dataset_id}/locations
+		if s.authorize(w, r) {
+			s.serveLocations(w, r, parts[2])
+		}
+	case len(parts) == 6 && parts[3] == "query" && parts[4] == "location":
+		// /v1/datasets/{dataset_id}/query/location/{location_id}
+		if s.authorize(w, r) {
+			s.serveQuery(w, r, parts[2], parts[5])
+		}
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func (s *Server) serveQuery(w http.ResponseWriter, r *http.Request, dataset, location string) {
+	q := r.URL.Query()
+	start, err1 := time.Parse("2006-01-02", q.Get("start_time"))
+	end, err2 := time.Parse("2006-01-02", q.Get("end_time"))
+	if err1 != nil || err2 != nil || !end.After(start) {
+		writeError(w, http.StatusBadRequest, "start_time and end_time must be YYYY-MM-DD with end_time after start_time")
+		return
+	}
+	timezone := q.Get("timezone")
+	if timezone == "" {
+		timezone = data.TimezoneMarket
+	}
+	if timezone != data.TimezoneMarket && timezone != data.TimezoneUTC {
+		writeError(w, http.StatusBadRequest, "timezone must be market or UTC")
+		return
+	}
+
+	rows, ok, err := s.load(dataset, location, start, end)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "Unknown dataset or location")
+		return
+	}
+	rows = window(rows, start, end, timezone == data.TimezoneUTC)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(model.GridStatusLMPResponse{StatusCode: http.StatusOK, Data: rows})
+}
+
+// catalogEntry is one row of the location listing.
+type catalogEntry struct {
+	Location     string   `json:"location"`
+	Name         string   `json:"name"`
+	LocationType string   `json:"location_type"`
+	Market       string   `json:"market"`
+	Zone         string   `json:"zone,omitempty"`
+	Latitude     *float64 `json:"latitude,omitempty"`
+	Longitude    *float64 `json:"longitude,omitempty"`
+}
+
+// serveLocations lists the dataset's locations (files in DataDir, then the
+// synthetic catalog), page_size (default 100) at a time.
+func (s *Server) serveLocations(w http.ResponseWriter, r *http.Request, dataset string) {
+	q := r.URL.Query()
+	page, pageSize := 1, 100
+	if v := q.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "page must be a positive integer")
+			return
+		}
+		page = n
+	}
+	if v := q.Get("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 10000 {
+			writeError(w, http.StatusBadRequest, "page_size must be between 1 and 10000")
+			return
+		}
+		pageSize = n
+	}
+
+	entries, err := s.catalog(dataset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	from := min((page-1)*pageSize, len(entries))
+	to := min(from+pageSize, len(entries))
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"status_code": http.StatusOK,
+		"data":        entries[from:to],
+		"meta": map[string]interface{}{
+			"page":        page,
+			"page_size":   pageSize,
+			"hasNextPage": to < len(entries),
+		},
+	})
+}
+
+// catalog returns the dataset's locations sorted by ID.
+func (s *Server) catalog(dataset string) ([]catalogEntry, error) {
+	seen := map[string]bool{}
+	entries := []catalogEntry{}
+	if s.opts.DataDir != "" {
+		for _, dir := range []string{filepath.Join(s.opts.DataDir, dataset), s.opts.DataDir} {
+			paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+			for _, path := range paths {
+				id := strings.TrimSuffix(filepath.Base(path), ".json")
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				resp, err := data.LoadGridStatusJSON(path)
+				if err != nil {
+					return nil, err
+				}
+				e := catalogEntry{Location: id, Name: id, Market: dataset}
+				if len(resp.Data) > 0 {
+					e.LocationType, e.Market = resp.Data[0].LocationType, resp.Data[0].Market
+				}
+				entries = append(entries, e)
+			}
+		}
+	}
+	if s.opts.Synthetic {
+		types := []string{"Node", "Node", "Node", "Aggregate", "Trading Hub"}
+		zones := []string{"NORTH", "SOUTH", "EAST", "WEST"}
+		for i := 1; i <= s.opts.CatalogSize; i++ {
+			id := fmt.Sprintf("SYNTH_%04d", i)
+			if seen[id] {
+				continue
+			}
+			h := fnv.New64a()
+			h.Write([]byte(dataset + "/" + id))
+			v := h.Sum64()
+			lat := 32 + float64(v%1000)/100
+			lon := -124 + float64((v/1000)%1000)/100
+			entries = append(entries, catalogEntry{
+				Location:     id,
+				Name:         fmt.Sprintf("Synthetic node %d", i),
+				LocationType: types[i%len(types)],
+				Market:       dataset,
+				Zone:         zones[i%len(zones)],
+				Latitude:     &lat,
+				Longitude:    &lon,
+			})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Location < entries[j].Location })
+	return entries, nil
+}
+
+// authorize writes the 401/403/429 responses and reports whether to proceed.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) bool {
+	key := r.Header.Get("x-api-key")
+	if key == "" {
+		writeError(w, http.StatusUnauthorized, "Missing API key")
+		return false
+	}
+	if len(s.opts.APIKeys) > 0 {
+		known := false
+		for _, k := range s.opts.APIKeys {
+			known = known || k == key
+		}
+		if !known {
+			writeError(w, http.StatusForbidden, "Invalid API key")
+			return false
+		}
+	}
+	if s.opts.RateLimit <= 0 {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	win := s.windows[key]
+	if win == nil || now.Sub(win.start) >= s.opts.RateWindow {
+		win = &rateWindow{start: now}
+		s.windows[key] = win
+	}
+	win.count++
+	if win.count > s.opts.RateLimit {
+		retry := win.start.Add(s.opts.RateWindow).Sub(now)
+		w.Header().Set("Retry-After", strconv.Itoa(int(retry.Seconds()+0.999)))
+		writeError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+		return false
+	}
+	return true
+}
+
+// load returns the rows for a location from a file or the generator, padded
+// by a day on each side so either timezone's window can be cut from them.
+func (s *Server) load(dataset, location string, start, end time.Time) ([]model.LMPInterval, bool, error) {
+	if s.opts.DataDir != "" {
+		for _, path := range []string{
+			filepath.Join(s.opts.DataDir, dataset, location+".json"),
+			filepath.Join(s.opts.DataDir, location+".json"),
+		} {
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			resp, err := data.LoadGridStatusJSON(path)
+			if err != nil {
+				return nil, false, err
+			}
+			return resp.Data, true, nil
+		}
+	}
+	if !s.opts.Synthetic {
+		return nil, false, nil
+	}
+	h := fnv.New64a()
+	h.Write([]byte(dataset + "/" + location))
+	rows, err := synthetic.Generate(synthetic.Params{
+		Start:    start.AddDate(0, 0, -1),
+		Days:     int(end.Sub(start).Hours()/24) + 2,
+		Seed:     int64(h.Sum64() >> 1),
+		Market:   dataset,
+		Location: location,
+	})
+	return rows, err == nil, err
+}
+
+// window keeps rows whose start falls on a day in [start, end): market-local
+// days, or UTC days with local times rewritten to UTC.
+func window(rows []model.LMPInterval, start, end time.Time, utc bool) []model.LMPInterval {
+	out := []model.LMPInterval{}
+	for _, it := range rows {
+		t := it.IntervalStartLocal
+		if utc {
+			t = it.IntervalStartUTC
+		}
+		y, m, d := t.Date()
+		day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		if day.Before(start) || !day.Before(end) {
+			continue
+		}
+		if utc {
+			it.IntervalStartLocal, it.IntervalEndLocal = it.IntervalStartUTC, it.IntervalEndUTC
+		}
+		out = append(out, it)
+	}
+	return out
+}
+
+func writeError(w http.ResponseWriter, status int, detail string) {
+	if status >= 500 {
+		log.Printf("[gridstatus-mock] %d: %s", status, detail)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"detail": detail})
+}
@@ -19,6 +19,16 @@ func LoadGridStatusJSON(path string) (*model.GridStatusLMPResponse, error) {
 	return &resp, nil
 }
 
+// WriteGridStatusJSON writes a response in the Grid Status JSON format read by
+// LoadGridStatusJSON.
+func WriteGridStatusJSON(path string, resp *model.GridStatusLMPResponse) error {
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, raw, 0o644)
+}
+
 // GroupByLocation splits a response into location-keyed slices.
 func GroupByLocation(resp *model.GridStatusLMPResponse) map[string][]model.LMPInterval {
 	out := map[string][]model.LMPInterval{}
@@ -30,4 +40,3 @@ func GroupByLocation(resp *model.GridStatusLMPResponse) map[string][]model.LMPIn
 	}
 	return out
 }
-
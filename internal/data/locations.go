@@ -9,11 +9,16 @@ import (
 
 // Location represents a location/node from Grid Status
 type Location struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Type        string `json:"type"`        // e.g., "GNODE", "LNODE"
-	Market      string `json:"market"`      // e.g., "CAISO"
-	DatasetID   string `json:"dataset_id"`  // Dataset this location belongs to
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`       // e.g., "GNODE", "LNODE"
+	Market    string `json:"market"`     // e.g., "CAISO"
+	DatasetID string `json:"dataset_id"` // Dataset this location belongs to
+
+	// Optional metadata from catalog discovery.
+	Zone      string   `json:"zone,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
 }
 
 // LocationList represents a collection of locations
@@ -23,6 +28,21 @@ type LocationList struct {
 	Locations []Location `json:"locations"`
 }
 
+// ForDataset returns the locations belonging to datasetID: all of them when
+// the list is for that dataset, otherwise those tagged with it or untagged.
+func (l *LocationList) ForDataset(datasetID string) []Location {
+	if datasetID == "" || l.DatasetID == datasetID {
+		return l.Locations
+	}
+	filtered := []Location{}
+	for _, loc := range l.Locations {
+		if loc.DatasetID == datasetID || loc.DatasetID == "" {
+			filtered = append(filtered, loc)
+		}
+	}
+	return filtered
+}
+
 // LoadLocations loads locations from a JSON file
 func LoadLocations(filePath string) (*LocationList, error) {
 	raw, err := os.ReadFile(filePath)
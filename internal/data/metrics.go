@@ -0,0 +1,84 @@
+package data
+
+import (
+	"sync"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of the request latency histogram.
+var LatencyBuckets = []time.Duration{
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+}
+
+// ClientMetrics counts Grid Status requests across all clients in the process.
+type ClientMetrics struct {
+	mu sync.Mutex
+	m  MetricsSnapshot
+}
+
+// MetricsSnapshot is a copy of the counters.
+type MetricsSnapshot struct {
+	Queries   int64         // QueryLocation calls
+	Requests  map[int]int64 // HTTP attempts by status code (0 = transport error)
+	Retries   int64
+	CacheHits int64
+	StoreHits int64
+
+	RateLimitWaits int64         // requests delayed by the client-side limiter
+	RateLimitWait  time.Duration // total time spent waiting for it
+
+	LatencyCount   int64
+	LatencyTotal   time.Duration
+	LatencyMax     time.Duration
+	LatencyBuckets []int64 // counts per LatencyBuckets bound, plus one overflow bucket
+}
+
+// Metrics is the process-wide Grid Status client metrics.
+var Metrics = &ClientMetrics{}
+
+// Snapshot returns a copy of the counters.
+func (c *ClientMetrics) Snapshot() MetricsSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.m
+	s.Requests = map[int]int64{}
+	for k, v := range c.m.Requests {
+		s.Requests[k] = v
+	}
+	s.LatencyBuckets = make([]int64, len(LatencyBuckets)+1)
+	copy(s.LatencyBuckets, c.m.LatencyBuckets)
+	return s
+}
+
+func (c *ClientMetrics) update(f func(m *MetricsSnapshot)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f(&c.m)
+}
+
+func (c *ClientMetrics) request(status int, latency time.Duration) {
+	c.update(func(m *MetricsSnapshot) {
+		if m.Requests == nil {
+			m.Requests = map[int]int64{}
+			m.LatencyBuckets = make([]int64, len(LatencyBuckets)+1)
+		}
+		m.Requests[status]++
+		m.LatencyCount++
+		m.LatencyTotal += latency
+		if latency > m.LatencyMax {
+			m.LatencyMax = latency
+		}
+		b := 0
+		for b < len(LatencyBuckets) && latency > LatencyBuckets[b] {
+			b++
+		}
+		m.LatencyBuckets[b]++
+	})
+}
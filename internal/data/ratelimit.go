@@ -0,0 +1,127 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket: tokens accrue at Rate per second up to
+// Burst, and each request takes one.
+type RateLimiter struct {
+	Rate  float64
+	Burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a full bucket.
+func NewRateLimiter(rate, burst float64) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{Rate: rate, Burst: burst, tokens: burst, last: time.Now()}
+}
+
+// Wait blocks until a token is available or ctx is done, and returns how long
+// it waited.
+func (l *RateLimiter) Wait(ctx context.Context) (time.Duration, error) {
+	var waited time.Duration
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.last).Seconds() * l.Rate
+		if l.tokens > l.Burst {
+			l.tokens = l.Burst
+		}
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return waited, nil
+		}
+		delay := time.Duration((1 - l.tokens) / l.Rate * float64(time.Second))
+		l.mu.Unlock()
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return waited, ctx.Err()
+		case <-t.C:
+			waited += delay
+		}
+	}
+}
+
+// limiterIdleTTL is how long a per-key limiter may go unused before it is
+// dropped. It is far longer than any bucket takes to refill, so an evicted
+// limiter was full and a replacement starts in the same state.
+const limiterIdleTTL = 15 * time.Minute
+
+var (
+	limitersMu sync.Mutex
+	limiters   = map[string]*RateLimiter{} // by limiterKey
+	lastSweep  time.Time
+)
+
+// LimiterFor returns the process-wide limiter for an API key, so every client
+// using the key shares one budget. The rate is GRIDSTATUS_RATE_LIMIT requests
+// per second (default 2; 0 disables limiting) with a burst of
+// GRIDSTATUS_RATE_BURST (default 5).
+//
+// Limiters are keyed by a hash of the API key, so keys are not kept in
+// memory, and idle ones are evicted so per-request keys cannot grow the map
+// without bound.
+func LimiterFor(apiKey string) *RateLimiter {
+	rate := envFloat("GRIDSTATUS_RATE_LIMIT", 2)
+	if rate <= 0 {
+		return nil
+	}
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	now := time.Now()
+	if now.Sub(lastSweep) >= limiterIdleTTL {
+		sweepLimiters(now)
+		lastSweep = now
+	}
+	key := limiterKey(apiKey)
+	l := limiters[key]
+	if l == nil {
+		l = NewRateLimiter(rate, envFloat("GRIDSTATUS_RATE_BURST", 5))
+		limiters[key] = l
+	}
+	return l
+}
+
+// limiterKey hashes an API key for use as a map key.
+func limiterKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// sweepLimiters drops limiters unused for limiterIdleTTL. The caller holds
+// limitersMu.
+func sweepLimiters(now time.Time) {
+	for key, l := range limiters {
+		l.mu.Lock()
+		idle := now.Sub(l.last)
+		l.mu.Unlock()
+		if idle >= limiterIdleTTL {
+			delete(limiters, key)
+		}
+	}
+}
+
+func envFloat(name string, def float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil {
+		return v
+	}
+	return def
+}
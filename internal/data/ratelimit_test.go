@@ -0,0 +1,47 @@
+package data
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimiterForKeysByHashAndEvictsIdle(t *testing.T) {
+	t.Setenv("GRIDSTATUS_RATE_LIMIT", "2")
+	const key = "secret-api-key-0123456789"
+
+	a := LimiterFor(key)
+	if a == nil || LimiterFor(key) != a {
+		t.Fatal("same key should share one limiter")
+	}
+	if LimiterFor("other-key") == a {
+		t.Fatal("different keys should not share a limiter")
+	}
+
+	limitersMu.Lock()
+	for k := range limiters {
+		if strings.Contains(k, key) {
+			limitersMu.Unlock()
+			t.Fatalf("limiter map holds the raw API key")
+		}
+	}
+	tests := []struct {
+		name  string
+		after time.Duration
+		kept  bool
+	}{
+		{"recently used", limiterIdleTTL / 2, true},
+		{"idle", limiterIdleTTL, false},
+	}
+	for _, tc := range tests {
+		sweepLimiters(a.last.Add(tc.after))
+		if _, ok := limiters[limiterKey(key)]; ok != tc.kept {
+			t.Errorf("%s: kept = %v, want %v", tc.name, ok, tc.kept)
+		}
+	}
+	limitersMu.Unlock()
+
+	if LimiterFor(key) == a {
+		t.Error("an evicted key should get a new limiter")
+	}
+}
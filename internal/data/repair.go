@@ -0,0 +1,155 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"battery-backtest/internal/model"
+)
+
+// RepairPolicy selects how Repair treats gaps and, when enabled, outlier prices.
+type RepairPolicy string
+
+const (
+	RepairNone        RepairPolicy = "none"        // report only
+	RepairDrop        RepairPolicy = "drop"        // drop bad rows and outliers, leave gaps
+	RepairForwardFill RepairPolicy = "ffill"       // fill gaps and outliers with the previous prices
+	RepairInterpolate RepairPolicy = "interpolate" // fill gaps and outliers linearly in time
+	RepairUnavailable RepairPolicy = "unavailable" // fill gaps and mark them and outliers unavailable
+)
+
+// ParseRepairPolicy parses a policy name; "" means none.
+func ParseRepairPolicy(s string) (RepairPolicy, error) {
+	switch p := RepairPolicy(s); p {
+	case "":
+		return RepairNone, nil
+	case RepairNone, RepairDrop, RepairForwardFill, RepairInterpolate, RepairUnavailable:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown repair policy %q (want none, drop, ffill, interpolate or unavailable)", s)
+}
+
+// Repair validates intervals and returns a repaired copy with the report.
+//
+// Every policy but none sorts rows by start and drops duplicates, overlapping
+// rows and non-positive durations. Gaps are then filled with intervals of the
+// typical length (ffill, interpolate, unavailable). With opts.RepairOutliers,
+// outlier prices are also dropped, replaced or marked unavailable; otherwise
+// they are only reported. Filled intervals have Filled set; the engine treats
+// Unavailable intervals as outages. Mixed interval lengths and DST issues are
+// reported but not changed.
+func Repair(intervals []model.LMPInterval, policy RepairPolicy, opts ValidateOptions) ([]model.LMPInterval, *QualityReport) {
+	report := Validate(intervals, opts)
+	report.Policy = policy
+	if policy == RepairNone || policy == "" || len(intervals) == 0 {
+		return intervals, report
+	}
+
+	sorted := append([]model.LMPInterval(nil), intervals...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].IntervalStartUTC.Before(sorted[j].IntervalStartUTC) })
+	clean := sorted[:0]
+	for _, it := range sorted {
+		if it.Duration() <= 0 {
+			continue
+		}
+		if n := len(clean); n > 0 && it.IntervalStartUTC.Before(clean[n-1].IntervalEndUTC) {
+			continue
+		}
+		clean = append(clean, it)
+	}
+	report.Dropped = len(intervals) - len(clean)
+
+	// Outliers: indexes into clean. Only repaired when asked for.
+	bad := map[int]bool{}
+	if opts.RepairOutliers {
+		for _, i := range outliers(clean, opts.OutlierMADs) {
+			bad[i] = true
+		}
+	}
+	if policy == RepairDrop {
+		out := make([]model.LMPInterval, 0, len(clean))
+		for i, it := range clean {
+			if !bad[i] {
+				out = append(out, it)
+			}
+		}
+		report.Dropped += len(clean) - len(out)
+		return out, report
+	}
+
+	// goodFrom returns the first non-outlier row from j in direction step.
+	goodFrom := func(j, step int) (model.LMPInterval, bool) {
+		for ; j >= 0 && j < len(clean); j += step {
+			if !bad[j] {
+				return clean[j], true
+			}
+		}
+		return model.LMPInterval{}, false
+	}
+	// fill re-prices it (starting at at) from the good rows at or before
+	// before and at or after after, or marks it unavailable.
+	fill := func(it model.LMPInterval, at time.Time, before, after int) model.LMPInterval {
+		prev, okPrev := goodFrom(before, -1)
+		next, okNext := goodFrom(after, 1)
+		it.Filled = true
+		switch {
+		case policy == RepairUnavailable:
+			it.Unavailable = true
+			report.Unavailable++
+			return it
+		case policy == RepairInterpolate && okPrev && okNext:
+			f := float64(at.Sub(prev.IntervalStartUTC)) / float64(next.IntervalStartUTC.Sub(prev.IntervalStartUTC))
+			it = withPrices(it, lerp(prev, next, f))
+		case okPrev:
+			it = withPrices(it, prev)
+		case okNext:
+			it = withPrices(it, next)
+		}
+		report.Filled++
+		return it
+	}
+
+	step := report.IntervalLength
+	out := make([]model.LMPInterval, 0, len(clean)+report.MissingIntervals)
+	for i, it := range clean {
+		if i > 0 && step > 0 {
+			prev := clean[i-1]
+			loc := prev.IntervalStartLocal.Location()
+			for start := prev.IntervalEndUTC; start.Before(it.IntervalStartUTC); start = start.Add(step) {
+				end := start.Add(step)
+				if end.After(it.IntervalStartUTC) {
+					end = it.IntervalStartUTC
+				}
+				gap := prev
+				gap.IntervalStartUTC, gap.IntervalEndUTC = start, end
+				gap.IntervalStartLocal, gap.IntervalEndLocal = start.In(loc), end.In(loc)
+				gap.AmbientTempC, gap.EmissionsRate = nil, nil
+				out = append(out, fill(gap, start, i-1, i))
+			}
+		}
+		if bad[i] {
+			it = fill(it, it.IntervalStartUTC, i-1, i+1)
+		}
+		out = append(out, it)
+	}
+	return out, report
+}
+
+// withPrices returns it with the price fields of src.
+func withPrices(it, src model.LMPInterval) model.LMPInterval {
+	it.LMP, it.Energy, it.Congestion, it.Loss, it.GHG = src.LMP, src.Energy, src.Congestion, src.Loss, src.GHG
+	return it
+}
+
+// lerp interpolates the price fields of a and b at fraction f.
+func lerp(a, b model.LMPInterval, f float64) model.LMPInterval {
+	at := func(x, y float64) float64 { return x + f*(y-x) }
+	return model.LMPInterval{
+		LMP:        at(a.LMP, b.LMP),
+		Energy:     at(a.Energy, b.Energy),
+		Congestion: at(a.Congestion, b.Congestion),
+		Loss:       at(a.Loss, b.Loss),
+		GHG:        at(a.GHG, b.GHG),
+	}
+}
@@ -0,0 +1,168 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"battery-backtest/internal/model"
+)
+
+// ResampleMethod selects how Resample aggregates prices into a coarser interval.
+type ResampleMethod string
+
+const (
+	ResampleMean         ResampleMethod = "mean"          // simple average of the intervals
+	ResampleTimeWeighted ResampleMethod = "time_weighted" // average weighted by duration
+	ResampleMin          ResampleMethod = "min"           // interval with the lowest LMP
+	ResampleMax          ResampleMethod = "max"           // interval with the highest LMP
+)
+
+// ParseResampleMethod parses a method name; "" means time_weighted.
+func ParseResampleMethod(s string) (ResampleMethod, error) {
+	switch m := ResampleMethod(s); m {
+	case "":
+		return ResampleTimeWeighted, nil
+	case ResampleMean, ResampleTimeWeighted, ResampleMin, ResampleMax:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown resample method %q (want mean, time_weighted, min or max)", s)
+}
+
+// MinResampleStep is the smallest step Resample accepts. Finer steps would only
+// multiply the series (a day of 1s intervals is 86,400 rows) without adding
+// information.
+const MinResampleStep = time.Minute
+
+// Resample converts a sorted series to a fixed step of at least MinResampleStep.
+//
+// Intervals longer than step are split into step-long pieces carrying the same
+// prices (step must divide their duration). Intervals shorter than step are
+// grouped into buckets aligned to the local clock (local calendar days for
+// whole-day steps) and aggregated with method; min/max take all component
+// prices from the interval with the lowest/highest LMP so the components still
+// add up.
+// Ambient temperature and emissions rates are time-weighted; a bucket is
+// Filled/Unavailable if any of its intervals is.
+//
+// Timestamps are kept in both UTC and each interval's local offset; partial
+// buckets at the ends of the series span only the intervals they contain.
+func Resample(intervals []model.LMPInterval, step time.Duration, method ResampleMethod) ([]model.LMPInterval, error) {
+	if step < MinResampleStep {
+		return nil, fmt.Errorf("resample step must be at least %s (got %s)", MinResampleStep, step)
+	}
+	if method == "" {
+		method = ResampleTimeWeighted
+	}
+
+	// Disaggregate anything longer than step.
+	var fine []model.LMPInterval
+	for i, it := range intervals {
+		d := it.Duration()
+		if d <= step {
+			fine = append(fine, it)
+			continue
+		}
+		if d%step != 0 {
+			return nil, fmt.Errorf("interval %d: duration %s is not a multiple of %s", i, d, step)
+		}
+		loc := it.IntervalStartLocal.Location()
+		for start := it.IntervalStartUTC; start.Before(it.IntervalEndUTC); start = start.Add(step) {
+			piece := it
+			piece.IntervalStartUTC, piece.IntervalEndUTC = start, start.Add(step)
+			piece.IntervalStartLocal, piece.IntervalEndLocal = start.In(loc), start.Add(step).In(loc)
+			fine = append(fine, piece)
+		}
+	}
+
+	// Aggregate into local-clock buckets.
+	var out []model.LMPInterval
+	for i := 0; i < len(fine); {
+		key := bucketStart(fine[i], step)
+		j := i + 1
+		for j < len(fine) && bucketStart(fine[j], step).Equal(key) {
+			j++
+		}
+		out = append(out, aggregate(fine[i:j], method))
+		i = j
+	}
+	return out, nil
+}
+
+// bucketStart is the UTC start of the step-long bucket containing it, aligned
+// to multiples of step on its local clock. Steps of whole days are aligned to
+// local calendar days instead, so 23- and 25-hour DST days stay one bucket.
+func bucketStart(it model.LMPInterval, step time.Duration) time.Time {
+	const day = 24 * time.Hour
+	if step%day == 0 {
+		local := it.IntervalStartLocal
+		y, m, d := local.Date()
+		start := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Truncate(step)
+		return time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, local.Location()).UTC()
+	}
+	_, offset := it.IntervalStartLocal.Zone()
+	shift := time.Duration(offset) * time.Second
+	return it.IntervalStartUTC.Add(shift).Truncate(step).Add(-shift)
+}
+
+func aggregate(group []model.LMPInterval, method ResampleMethod) model.LMPInterval {
+	first, last := group[0], group[len(group)-1]
+	out := first
+	out.IntervalEndUTC, out.IntervalEndLocal = last.IntervalEndUTC, last.IntervalEndLocal
+	if len(group) == 1 {
+		return out
+	}
+
+	switch method {
+	case ResampleMin, ResampleMax:
+		pick := first
+		for _, it := range group[1:] {
+			if (method == ResampleMin && it.LMP < pick.LMP) || (method == ResampleMax && it.LMP > pick.LMP) {
+				pick = it
+			}
+		}
+		out = withPrices(out, pick)
+	default:
+		var sum model.LMPInterval
+		total := 0.0
+		for _, it := range group {
+			w := 1.0
+			if method == ResampleTimeWeighted {
+				w = it.DurationHours()
+			}
+			sum.LMP += w * it.LMP
+			sum.Energy += w * it.Energy
+			sum.Congestion += w * it.Congestion
+			sum.Loss += w * it.Loss
+			sum.GHG += w * it.GHG
+			total += w
+		}
+		sum.LMP, sum.Energy, sum.Congestion, sum.Loss, sum.GHG =
+			sum.LMP/total, sum.Energy/total, sum.Congestion/total, sum.Loss/total, sum.GHG/total
+		out = withPrices(out, sum)
+	}
+
+	out.AmbientTempC = weightedOptional(group, func(it model.LMPInterval) *float64 { return it.AmbientTempC })
+	out.EmissionsRate = weightedOptional(group, func(it model.LMPInterval) *float64 { return it.EmissionsRate })
+	for _, it := range group {
+		out.Filled = out.Filled || it.Filled
+		out.Unavailable = out.Unavailable || it.Unavailable
+	}
+	return out
+}
+
+// weightedOptional is the time-weighted mean of the non-nil values, or nil.
+func weightedOptional(group []model.LMPInterval, get func(model.LMPInterval) *float64) *float64 {
+	sum, total := 0.0, 0.0
+	for _, it := range group {
+		if v := get(it); v != nil {
+			w := it.DurationHours()
+			sum += w * *v
+			total += w
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	v := sum / total
+	return &v
+}
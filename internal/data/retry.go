@@ -0,0 +1,66 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy retries 429, 5xx and transport failures with exponential
+// backoff and jitter. A Retry-After from the server replaces the backoff; if
+// it exceeds MaxDelay the error is returned instead of waiting.
+type RetryPolicy struct {
+	MaxRetries int           // retries after the first attempt (0 = none)
+	BaseDelay  time.Duration // first backoff; doubles per retry
+	MaxDelay   time.Duration // cap on any single wait
+}
+
+// DefaultRetryPolicy is used by NewGridStatusClient.
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}
+
+func (p RetryPolicy) maxAttempts() int {
+	return p.MaxRetries + 1
+}
+
+// delay returns how long to wait before retrying after attempt (0-based)
+// failed with err, and whether to retry at all.
+func (p RetryPolicy) delay(attempt int, err error) (time.Duration, bool) {
+	if attempt >= p.MaxRetries || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0, false
+	}
+	var gsErr *GridStatusError
+	if errors.As(err, &gsErr) {
+		if gsErr.StatusCode != http.StatusTooManyRequests && gsErr.StatusCode < 500 {
+			return 0, false
+		}
+		if d, ok := parseRetryAfter(gsErr.RetryAfter); ok {
+			return d, d <= p.MaxDelay
+		}
+	}
+	// Jitter: uniform in [backoff/2, backoff].
+	backoff := float64(p.BaseDelay) * math.Pow(2, float64(attempt))
+	backoff = math.Min(backoff, float64(p.MaxDelay))
+	return time.Duration(backoff * (0.5 + 0.5*rand.Float64())), true
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or as an HTTP date.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
@@ -0,0 +1,126 @@
+package data
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"battery-backtest/internal/model"
+)
+
+// SeriesPoint is one observation of an exogenous time series
+// (e.g. ambient temperature).
+type SeriesPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// LoadSeriesCSV reads a two-column CSV (RFC3339 timestamp, value) with a header
+// row, e.g.
+//
+//	time,temp_c
+//	2024-07-01T00:00:00-07:00,31.5
+//
+// Points are returned sorted by time.
+func LoadSeriesCSV(path string) ([]SeriesPoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	var out []SeriesPoint
+	for line := 1; ; line++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 || len(rec) == 0 || strings.HasPrefix(rec[0], "#") {
+			continue
+		}
+		if len(rec) < 2 {
+			return nil, fmt.Errorf("%s:%d: expected time,value", path, line)
+		}
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(rec[0]))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(rec[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		out = append(out, SeriesPoint{Time: t, Value: v})
+	}
+	if len(out) == 0 {
+		return nil, errors.New("series is empty")
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out, nil
+}
+
+// SeriesAt linearly interpolates a sorted series at t, holding the first/last
+// value outside its range. ok is false for an empty series.
+func SeriesAt(points []SeriesPoint, t time.Time) (v float64, ok bool) {
+	if len(points) == 0 {
+		return 0, false
+	}
+	i := sort.Search(len(points), func(i int) bool { return !points[i].Time.Before(t) })
+	switch {
+	case i == 0:
+		return points[0].Value, true
+	case i == len(points):
+		return points[len(points)-1].Value, true
+	}
+	a, b := points[i-1], points[i]
+	f := float64(t.Sub(a.Time)) / float64(b.Time.Sub(a.Time))
+	return a.Value + f*(b.Value-a.Value), true
+}
+
+// JoinAmbientTemperature sets AmbientTempC on every interval from a temperature
+// series, interpolated at the interval midpoint. Intervals are modified in place.
+func JoinAmbientTemperature(intervals []model.LMPInterval, temps []SeriesPoint) {
+	for i := range intervals {
+		if v, ok := SeriesAt(temps, midpoint(intervals[i])); ok {
+			intervals[i].AmbientTempC = &v
+		}
+	}
+}
+
+// JoinEmissionsRate sets EmissionsRate (tCO2/MWh) on every interval from a
+// marginal emissions series, interpolated at the interval midpoint.
+func JoinEmissionsRate(intervals []model.LMPInterval, rates []SeriesPoint) {
+	for i := range intervals {
+		if v, ok := SeriesAt(rates, midpoint(intervals[i])); ok {
+			intervals[i].EmissionsRate = &v
+		}
+	}
+}
+
+// EmissionsFromGHG derives EmissionsRate from the GHG component of the LMP
+// ($/MWh) at an allowance price ($/tCO2), for intervals without a rate.
+func EmissionsFromGHG(intervals []model.LMPInterval, ghgPricePerTCO2 float64) {
+	if ghgPricePerTCO2 <= 0 {
+		return
+	}
+	for i := range intervals {
+		if intervals[i].EmissionsRate == nil {
+			v := intervals[i].GHG / ghgPricePerTCO2
+			intervals[i].EmissionsRate = &v
+		}
+	}
+}
+
+func midpoint(it model.LMPInterval) time.Time {
+	return it.IntervalStartUTC.Add(it.Duration() / 2)
+}
@@ -0,0 +1,430 @@
+package data
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"battery-backtest/internal/model"
+)
+
+// Store is a persistent on-disk price store for licensed data, laid out as
+//
+//	<dir>/<dataset>/<location>/<YYYY-MM>.lmpc   one columnar file per local month
+//	<dir>/<dataset>/<location>/coverage.json    synced market-local days
+//
+// Coverage records which days have been fetched in full, so a day with no
+// rows is distinguishable from one never synced. Unlike ResponseCache, the
+// store never fills itself from live queries: it is written by `cli sync`.
+type Store struct {
+	Dir string
+	mu  sync.Mutex
+}
+
+// NewStore returns a store rooted at dir (created on first write).
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// GetStore returns the store at PRICE_STORE_DIR, or nil if it is unset.
+func GetStore() *Store {
+	if dir := os.Getenv("PRICE_STORE_DIR"); dir != "" {
+		return NewStore(dir)
+	}
+	return nil
+}
+
+// DateRange is a half-open range of calendar days [Start, End), both at
+// midnight UTC.
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (r DateRange) String() string {
+	return r.Start.Format("2006-01-02") + ".." + r.End.Format("2006-01-02")
+}
+
+// Days returns the number of days in the range.
+func (r DateRange) Days() int {
+	return int(r.End.Sub(r.Start).Hours() / 24)
+}
+
+func (s *Store) locationDir(dataset, location string) string {
+	return filepath.Join(s.Dir, dataset, location)
+}
+
+// Missing returns the ranges of days in [from, to) not yet synced, in order.
+func (s *Store) Missing(dataset, location string, from, to time.Time) ([]DateRange, error) {
+	covered, err := s.coverage(dataset, location)
+	if err != nil {
+		return nil, err
+	}
+	var missing []DateRange
+	day := civilDate(from)
+	end := civilDate(to)
+	for _, r := range covered {
+		if !day.Before(end) {
+			break
+		}
+		if !r.End.After(day) {
+			continue
+		}
+		if r.Start.After(day) {
+			missing = append(missing, DateRange{Start: day, End: minTime(r.Start, end)})
+		}
+		day = maxTime(day, r.End)
+	}
+	if day.Before(end) {
+		missing = append(missing, DateRange{Start: day, End: end})
+	}
+	return missing, nil
+}
+
+// Covered reports whether every day in [from, to) has been synced.
+func (s *Store) Covered(dataset, location string, from, to time.Time) bool {
+	missing, err := s.Missing(dataset, location, from, to)
+	return err == nil && len(missing) == 0
+}
+
+// Read returns the stored intervals whose market-local start falls on a day in
+// [from, to), in time order.
+func (s *Store) Read(dataset, location string, from, to time.Time) ([]model.LMPInterval, error) {
+	from, to = civilDate(from), civilDate(to)
+	var out []model.LMPInterval
+	// Partitions are by local month; include the neighbours of the range.
+	for m := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC); m.Before(to); m = m.AddDate(0, 1, 0) {
+		rows, err := readPartition(s.partitionPath(dataset, location, m.Format("2006-01")))
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range rows {
+			day := civilDate(it.IntervalStartLocal)
+			if !day.Before(from) && day.Before(to) {
+				out = append(out, it)
+			}
+		}
+	}
+	return out, nil
+}
+
+// Write merges intervals into the store and marks the days in synced as
+// covered. Rows replace stored rows with the same UTC start.
+func (s *Store) Write(dataset, location string, intervals []model.LMPInterval, synced DateRange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byMonth := map[string][]model.LMPInterval{}
+	for _, it := range intervals {
+		key := it.IntervalStartLocal.Format("2006-01")
+		byMonth[key] = append(byMonth[key], it)
+	}
+	for month, rows := range byMonth {
+		path := s.partitionPath(dataset, location, month)
+		existing, err := readPartition(path)
+		if err != nil {
+			return err
+		}
+		merged := map[int64]model.LMPInterval{}
+		for _, it := range existing {
+			merged[it.IntervalStartUTC.Unix()] = it
+		}
+		for _, it := range rows {
+			merged[it.IntervalStartUTC.Unix()] = it
+		}
+		all := make([]model.LMPInterval, 0, len(merged))
+		for _, it := range merged {
+			all = append(all, it)
+		}
+		sort.Slice(all, func(i, j int) bool { return all[i].IntervalStartUTC.Before(all[j].IntervalStartUTC) })
+		if err := writeAtomic(path, func(w io.Writer) error { return writePartition(w, all) }); err != nil {
+			return err
+		}
+	}
+
+	if synced.Days() <= 0 {
+		return nil
+	}
+	covered, err := s.coverage(dataset, location)
+	if err != nil {
+		return err
+	}
+	covered = mergeRanges(append(covered, DateRange{Start: civilDate(synced.Start), End: civilDate(synced.End)}))
+	return s.writeCoverage(dataset, location, covered)
+}
+
+// Lookup serves a location query from the store when every day it needs has
+// been synced. Stored local times are market time; for a UTC query the
+// window is widened by a day each side and trimmed on UTC days.
+func (s *Store) Lookup(params QueryLocationParams) ([]model.LMPInterval, bool) {
+	from, to := civilDate(params.StartTime), civilDate(params.EndTime)
+	utc := params.Timezone == TimezoneUTC
+	readFrom, readTo := from, to
+	if utc {
+		readFrom, readTo = from.AddDate(0, 0, -1), to.AddDate(0, 0, 1)
+	}
+	if !s.Covered(params.DatasetID, params.LocationID, readFrom, readTo) {
+		return nil, false
+	}
+	rows, err := s.Read(params.DatasetID, params.LocationID, readFrom, readTo)
+	if err != nil {
+		return nil, false
+	}
+	if !utc {
+		return rows, true
+	}
+	out := rows[:0]
+	for _, it := range rows {
+		day := civilDate(it.IntervalStartUTC)
+		if !day.Before(from) && day.Before(to) {
+			it.IntervalStartLocal = it.IntervalStartUTC
+			it.IntervalEndLocal = it.IntervalEndUTC
+			out = append(out, it)
+		}
+	}
+	return out, true
+}
+
+func (s *Store) partitionPath(dataset, location, month string) string {
+	return filepath.Join(s.locationDir(dataset, location), month+".lmpc")
+}
+
+func (s *Store) coverage(dataset, location string) ([]DateRange, error) {
+	raw, err := os.ReadFile(filepath.Join(s.locationDir(dataset, location), "coverage.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Days [][2]string `json:"days"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("store coverage for %s/%s: %w", dataset, location, err)
+	}
+	ranges := make([]DateRange, 0, len(file.Days))
+	for _, d := range file.Days {
+		start, err1 := time.Parse("2006-01-02", d[0])
+		end, err2 := time.Parse("2006-01-02", d[1])
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("store coverage for %s/%s: bad range %v", dataset, location, d)
+		}
+		ranges = append(ranges, DateRange{Start: start, End: end})
+	}
+	return mergeRanges(ranges), nil
+}
+
+func (s *Store) writeCoverage(dataset, location string, ranges []DateRange) error {
+	var file struct {
+		Days [][2]string `json:"days"`
+	}
+	for _, r := range ranges {
+		file.Days = append(file.Days, [2]string{r.Start.Format("2006-01-02"), r.End.Format("2006-01-02")})
+	}
+	raw, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.locationDir(dataset, location), "coverage.json")
+	return writeAtomic(path, func(w io.Writer) error {
+		_, err := w.Write(append(raw, '\n'))
+		return err
+	})
+}
+
+// mergeRanges sorts ranges and joins overlapping or adjacent ones.
+func mergeRanges(ranges []DateRange) []DateRange {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start.Before(ranges[j].Start) })
+	var out []DateRange
+	for _, r := range ranges {
+		if !r.End.After(r.Start) {
+			continue
+		}
+		if n := len(out); n > 0 && !r.Start.After(out[n-1].End) {
+			out[n-1].End = maxTime(out[n-1].End, r.End)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// civilDate returns the calendar date of t (in its own location) at midnight UTC.
+func civilDate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// writeAtomic writes path via a temporary file and rename, so readers never
+// see a partial file.
+func writeAtomic(path string, write func(io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Partition files are gzip-compressed and columnar: a magic line, the row
+// count and the partition's market/location strings, then one array per
+// column. Timestamps are Unix seconds; local times are stored as UTC offsets.
+// A NaN ambient temperature means none.
+const partitionMagic = "LMPC1\n"
+
+func writePartition(w io.Writer, rows []model.LMPInterval) error {
+	zw := gzip.NewWriter(w)
+	bw := bufio.NewWriter(zw)
+	if _, err := bw.WriteString(partitionMagic); err != nil {
+		return err
+	}
+	var market, location, locationType string
+	if len(rows) > 0 {
+		market, location, locationType = rows[0].Market, rows[0].Location, rows[0].LocationType
+	}
+	header := strings.Join([]string{market, location, locationType}, "\t")
+	if err := binary.Write(bw, binary.LittleEndian, uint32(len(rows))); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, uint32(len(header))); err != nil {
+		return err
+	}
+	if _, err := bw.WriteString(header); err != nil {
+		return err
+	}
+
+	n := len(rows)
+	startUTC, endUTC := make([]int64, n), make([]int64, n)
+	startOff, endOff := make([]int32, n), make([]int32, n)
+	lmp, energy, congestion, loss, ghg, ambient := make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n)
+	for i, it := range rows {
+		startUTC[i], endUTC[i] = it.IntervalStartUTC.Unix(), it.IntervalEndUTC.Unix()
+		_, so := it.IntervalStartLocal.Zone()
+		_, eo := it.IntervalEndLocal.Zone()
+		startOff[i], endOff[i] = int32(so), int32(eo)
+		lmp[i], energy[i], congestion[i], loss[i], ghg[i] = it.LMP, it.Energy, it.Congestion, it.Loss, it.GHG
+		ambient[i] = math.NaN()
+		if it.AmbientTempC != nil {
+			ambient[i] = *it.AmbientTempC
+		}
+	}
+	for _, col := range []interface{}{startUTC, endUTC, startOff, endOff, lmp, energy, congestion, loss, ghg, ambient} {
+		if err := binary.Write(bw, binary.LittleEndian, col); err != nil {
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// readPartition reads a partition file; a missing file is an empty partition.
+func readPartition(path string) ([]model.LMPInterval, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	br := bufio.NewReader(zr)
+
+	magic := make([]byte, len(partitionMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != partitionMagic {
+		return nil, fmt.Errorf("%s: not a price store partition", path)
+	}
+	var n, headerLen uint32
+	if err := binary.Read(br, binary.LittleEndian, &n); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := binary.Read(br, binary.LittleEndian, &headerLen); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	names := strings.Split(string(header), "\t")
+	if len(names) != 3 {
+		return nil, fmt.Errorf("%s: bad header", path)
+	}
+
+	startUTC, endUTC := make([]int64, n), make([]int64, n)
+	startOff, endOff := make([]int32, n), make([]int32, n)
+	lmp, energy, congestion, loss, ghg, ambient := make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n)
+	for _, col := range []interface{}{startUTC, endUTC, startOff, endOff, lmp, energy, congestion, loss, ghg, ambient} {
+		if err := binary.Read(br, binary.LittleEndian, col); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	rows := make([]model.LMPInterval, n)
+	for i := range rows {
+		start := time.Unix(startUTC[i], 0).UTC()
+		end := time.Unix(endUTC[i], 0).UTC()
+		rows[i] = model.LMPInterval{
+			IntervalStartUTC:   start,
+			IntervalEndUTC:     end,
+			IntervalStartLocal: start.In(time.FixedZone("", int(startOff[i]))),
+			IntervalEndLocal:   end.In(time.FixedZone("", int(endOff[i]))),
+			Market:             names[0],
+			Location:           names[1],
+			LocationType:       names[2],
+			LMP:                lmp[i],
+			Energy:             energy[i],
+			Congestion:         congestion[i],
+			Loss:               loss[i],
+			GHG:                ghg[i],
+		}
+		if !math.IsNaN(ambient[i]) {
+			v := ambient[i]
+			rows[i].AmbientTempC = &v
+		}
+	}
+	return rows, nil
+}
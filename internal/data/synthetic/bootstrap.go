@@ -0,0 +1,193 @@
+package synthetic
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"time"
+
+	"battery-backtest/internal/model"
+)
+
+// BootstrapParams configures a block bootstrap of whole local days.
+type BootstrapParams struct {
+	Paths     int // number of price paths (default: 20)
+	BlockDays int // consecutive historical days drawn together (default: 1)
+	Seed      int64
+}
+
+// day is one local calendar day of a history.
+type day struct {
+	date      time.Time // local date at midnight UTC
+	intervals []model.LMPInterval
+	offsets   []time.Duration // wall-clock time of day of each interval start
+	byClock   []int           // interval indexes ordered by offset
+}
+
+func (d day) weekend() bool {
+	wd := d.date.Weekday()
+	return wd == time.Saturday || wd == time.Sunday
+}
+
+// Bootstrap builds alternative price paths from history. Each path keeps the
+// timestamps of history and replaces each block of BlockDays days with a
+// block drawn (with replacement) from consecutive historical days that start
+// in the same month and on the same day type (weekday or weekend). When no
+// such block exists the month condition is dropped, then the day type.
+//
+// Prices (LMP, components, ambient temperature and emissions rate) are copied
+// by wall-clock time of day, so DST days and days with gaps line up with the
+// nearest donor interval.
+func Bootstrap(history []model.LMPInterval, p BootstrapParams) ([][]model.LMPInterval, error) {
+	if p.Paths == 0 {
+		p.Paths = 20
+	}
+	if p.BlockDays == 0 {
+		p.BlockDays = 1
+	}
+	if p.Paths < 0 || p.BlockDays < 0 {
+		return nil, errors.New("paths and block days must be > 0")
+	}
+	days := splitDays(history)
+	if len(days) == 0 {
+		return nil, errors.New("bootstrap needs a non-empty history")
+	}
+
+	pool := newDonorPool(days)
+	rng := rand.New(rand.NewSource(p.Seed))
+	paths := make([][]model.LMPInterval, p.Paths)
+	for n := range paths {
+		path := make([]model.LMPInterval, 0, len(history))
+		for b := 0; b < len(days); b += p.BlockDays {
+			k := p.BlockDays
+			if b+k > len(days) {
+				k = len(days) - b
+			}
+			candidates := pool.candidates(days[b], k)
+			donor := candidates[rng.Intn(len(candidates))]
+			for o := 0; o < k; o++ {
+				path = append(path, resampleDay(days[b+o], days[donor+o])...)
+			}
+		}
+		paths[n] = path
+	}
+	return paths, nil
+}
+
+// splitDays groups a time-sorted copy of intervals by local date.
+func splitDays(intervals []model.LMPInterval) []day {
+	sorted := append([]model.LMPInterval(nil), intervals...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].IntervalStartUTC.Before(sorted[j].IntervalStartUTC) })
+
+	var days []day
+	for _, it := range sorted {
+		t := it.IntervalStartLocal
+		y, m, d := t.Date()
+		date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		if n := len(days); n == 0 || !days[n-1].date.Equal(date) {
+			days = append(days, day{date: date})
+		}
+		cur := &days[len(days)-1]
+		cur.intervals = append(cur.intervals, it)
+		// Wall-clock time, not time since midnight: after spring forward 03:00
+		// is 2h past midnight, and the fall-back hour repeats.
+		h, mi, sec := t.Clock()
+		cur.offsets = append(cur.offsets, time.Duration(h)*time.Hour+time.Duration(mi)*time.Minute+time.Duration(sec)*time.Second)
+	}
+	for i := range days {
+		d := &days[i]
+		d.byClock = make([]int, len(d.offsets))
+		for j := range d.byClock {
+			d.byClock[j] = j
+		}
+		sort.SliceStable(d.byClock, func(a, b int) bool { return d.offsets[d.byClock[a]] < d.offsets[d.byClock[b]] })
+	}
+	return days
+}
+
+// donorPool finds and memoizes block start days by condition.
+type donorPool struct {
+	days  []day
+	cache map[donorKey][]int
+}
+
+type donorKey struct {
+	month   time.Month // 0 = any
+	weekend int        // 0 = any, 1 = weekday, 2 = weekend
+	k       int
+}
+
+func newDonorPool(days []day) *donorPool {
+	return &donorPool{days: days, cache: map[donorKey][]int{}}
+}
+
+// candidates returns start indexes of k consecutive calendar days matching
+// target, relaxing the conditions until some exist.
+func (p *donorPool) candidates(target day, k int) []int {
+	dayType := 1
+	if target.weekend() {
+		dayType = 2
+	}
+	for _, key := range []donorKey{
+		{month: target.date.Month(), weekend: dayType, k: k},
+		{month: target.date.Month(), k: k},
+		{weekend: dayType, k: k},
+		{k: k},
+	} {
+		if c := p.find(key); len(c) > 0 {
+			return c
+		}
+	}
+	// No k consecutive calendar days anywhere: take any k rows of days.
+	out := make([]int, 0, len(p.days)-k+1)
+	for j := 0; j+k <= len(p.days); j++ {
+		out = append(out, j)
+	}
+	return out
+}
+
+func (p *donorPool) find(key donorKey) []int {
+	if c, ok := p.cache[key]; ok {
+		return c
+	}
+	var out []int
+	for j := 0; j+key.k <= len(p.days); j++ {
+		d := p.days[j]
+		if key.month != 0 && d.date.Month() != key.month {
+			continue
+		}
+		if key.weekend != 0 && d.weekend() != (key.weekend == 2) {
+			continue
+		}
+		consecutive := true
+		for o := 1; o < key.k; o++ {
+			if !p.days[j+o].date.Equal(d.date.AddDate(0, 0, o)) {
+				consecutive = false
+				break
+			}
+		}
+		if consecutive {
+			out = append(out, j)
+		}
+	}
+	p.cache[key] = out
+	return out
+}
+
+// resampleDay returns target's intervals carrying donor's prices at the same
+// wall-clock time of day (the nearest donor interval at or before it).
+func resampleDay(target, donor day) []model.LMPInterval {
+	out := make([]model.LMPInterval, len(target.intervals))
+	for i, it := range target.intervals {
+		k := sort.Search(len(donor.byClock), func(k int) bool { return donor.offsets[donor.byClock[k]] > target.offsets[i] }) - 1
+		if k < 0 {
+			k = 0
+		}
+		src := donor.intervals[donor.byClock[k]]
+		it.LMP, it.Energy, it.Congestion, it.Loss, it.GHG = src.LMP, src.Energy, src.Congestion, src.Loss, src.GHG
+		it.AmbientTempC, it.EmissionsRate = src.AmbientTempC, src.EmissionsRate
+		it.Filled, it.Unavailable = src.Filled, src.Unavailable
+		out[i] = it
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package synthetic
+
+import (
+	"testing"
+	"time"
+
+	"battery-backtest/internal/model"
+)
+
+// clockHistory returns five-minute intervals over days local days in
+// America/Los_Angeles from date, each priced at its wall-clock hour.
+func clockHistory(t *testing.T, date string, days int) []model.LMPInterval {
+	t.Helper()
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, err := time.ParseInLocation("2006-01-02", date, la)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []model.LMPInterval
+	for u := start.UTC(); u.Before(start.AddDate(0, 0, days)); u = u.Add(5 * time.Minute) {
+		local := u.In(la)
+		out = append(out, model.LMPInterval{
+			IntervalStartUTC:   u,
+			IntervalEndUTC:     u.Add(5 * time.Minute),
+			IntervalStartLocal: local,
+			IntervalEndLocal:   local.Add(5 * time.Minute),
+			LMP:                float64(local.Hour()),
+		})
+	}
+	return out
+}
+
+func TestBootstrapDST(t *testing.T) {
+	tests := []struct {
+		name  string
+		start string // two weeks around the change, in one month
+		days  int
+	}{
+		{"spring forward", "2024-03-03", 14},
+		{"fall back", "2024-11-01", 14},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			history := clockHistory(t, tc.start, tc.days)
+			paths, err := Bootstrap(history, BootstrapParams{Paths: 20, Seed: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for n, path := range paths {
+				if len(path) != len(history) {
+					t.Fatalf("path %d has %d intervals, want %d", n, len(path), len(history))
+				}
+				for i, it := range path {
+					if !it.IntervalStartUTC.Equal(history[i].IntervalStartUTC) {
+						t.Fatalf("path %d interval %d at %s, want %s", n, i, it.IntervalStartUTC, history[i].IntervalStartUTC)
+					}
+					// Prices follow wall-clock time. A spring-forward donor has
+					// no 02:00 hour, so its 01:55 price stands in.
+					h := it.IntervalStartLocal.Hour()
+					if it.LMP != float64(h) && !(h == 2 && it.LMP == 1) {
+						t.Fatalf("path %d: %s priced at hour %v", n, it.IntervalStartLocal.Format("2006-01-02 15:04 MST"), it.LMP)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+// Package synthetic generates reproducible LMP series for demos, tests and
+// stress scenarios without a Grid Status API key.
+//
+// A price is built from a daily shape scaled by seasonality, plus
+// mean-reverting (Ornstein-Uhlenbeck) noise, decaying price spikes and
+// negative-price episodes:
+//
+//	lmp = base·season(t) + amplitude·shape(hour)·season(t) + noise + spikes
+//
+// replaced by a near-constant negative price during an episode. All draws come
+// from one seeded source, so the same Params always give the same series.
+package synthetic
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"battery-backtest/internal/model"
+)
+
+// DefaultHourlyShape is a "duck curve" in [-1, 1] by local hour: a morning
+// shoulder, a midday solar trough and an evening peak.
+var DefaultHourlyShape = []float64{
+	-0.30, -0.40, -0.45, -0.45, -0.35, -0.10, 0.20, 0.30, // 00-07
+	0.05, -0.40, -0.75, -0.95, -1.00, -0.95, -0.80, -0.50, // 08-15
+	0.00, 0.55, 0.90, 1.00, 0.85, 0.55, 0.20, -0.10, // 16-23
+}
+
+// Params configures a synthetic series. For the rate and size fields a zero
+// value takes the default and a negative value turns the component off.
+type Params struct {
+	Start    time.Time     // first local day (only the date is used)
+	Days     int           // number of local days (default: 7)
+	Step     time.Duration // interval length (default: 5m)
+	Timezone string        // IANA zone or "UTC" (default: America/Los_Angeles)
+	Seed     int64
+
+	Market       string // default: SYNTHETIC
+	Location     string // default: SYNTHETIC_NODE
+	LocationType string // default: Node
+
+	BasePrice         float64   // mean price, $/MWh (default: 40)
+	DailyAmplitude    float64   // $/MWh at shape ±1 (default: 25)
+	HourlyShape       []float64 // 24 values in [-1, 1] (default: DefaultHourlyShape)
+	SeasonalAmplitude float64   // fractional swing over the year, peaking in late July (default: 0.25)
+
+	Volatility    float64 // stationary std dev of the noise, $/MWh (default: 6)
+	MeanReversion float64 // noise reversion rate per hour (default: 0.5)
+
+	SpikesPerDay    float64 // expected spikes per day, clustered at shape peaks (default: 0.3)
+	SpikeMean       float64 // mean spike height, $/MWh, exponentially distributed (default: 300)
+	SpikeDecayHours float64 // e-folding time of a spike (default: 0.5)
+
+	NegativePerDay float64 // expected negative-price episodes per day, starting 09:00-14:00 (default: 0.1)
+	NegativePrice  float64 // price level during an episode, $/MWh (default: -25)
+	NegativeHours  float64 // episode length (default: 2)
+
+	LossShare       float64 // loss component as a fraction of the LMP (default: 0.02)
+	CongestionShare float64 // congestion component as a fraction of the deviation from base (default: 0.15)
+}
+
+// DefaultParams returns the defaults for a week starting at start.
+func DefaultParams(start time.Time) Params {
+	p := Params{Start: start}
+	p.withDefaults()
+	return p
+}
+
+func (p *Params) withDefaults() {
+	if p.Days == 0 {
+		p.Days = 7
+	}
+	if p.Step == 0 {
+		p.Step = 5 * time.Minute
+	}
+	if p.Timezone == "" {
+		p.Timezone = "America/Los_Angeles"
+	}
+	if p.Market == "" {
+		p.Market = "SYNTHETIC"
+	}
+	if p.Location == "" {
+		p.Location = "SYNTHETIC_NODE"
+	}
+	if p.LocationType == "" {
+		p.LocationType = "Node"
+	}
+	if len(p.HourlyShape) == 0 {
+		p.HourlyShape = DefaultHourlyShape
+	}
+	def := func(v *float64, d float64) {
+		switch {
+		case *v == 0:
+			*v = d
+		case *v < 0:
+			*v = 0
+		}
+	}
+	def(&p.BasePrice, 40)
+	def(&p.DailyAmplitude, 25)
+	def(&p.SeasonalAmplitude, 0.25)
+	def(&p.Volatility, 6)
+	def(&p.MeanReversion, 0.5)
+	def(&p.SpikesPerDay, 0.3)
+	def(&p.SpikeMean, 300)
+	def(&p.SpikeDecayHours, 0.5)
+	def(&p.NegativePerDay, 0.1)
+	def(&p.NegativeHours, 2)
+	def(&p.LossShare, 0.02)
+	def(&p.CongestionShare, 0.15)
+	// The episode price is a level, not a size: only zero takes the default.
+	if p.NegativePrice == 0 {
+		p.NegativePrice = -25
+	}
+}
+
+func (p Params) validate() error {
+	if p.Days < 0 {
+		return fmt.Errorf("days must be >= 0")
+	}
+	if p.Step < time.Minute || (24*time.Hour)%p.Step != 0 {
+		return fmt.Errorf("step must be at least 1m and divide a day, got %s", p.Step)
+	}
+	if len(p.HourlyShape) != 24 {
+		return fmt.Errorf("hourly_shape must have 24 values, got %d", len(p.HourlyShape))
+	}
+	if p.Start.IsZero() {
+		return fmt.Errorf("start is required")
+	}
+	return nil
+}
+
+// Generate builds the series described by p.
+func Generate(p Params) ([]model.LMPInterval, error) {
+	p.withDefaults()
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", p.Timezone, err)
+	}
+
+	y, m, d := p.Start.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	end := time.Date(y, m, d+p.Days, 0, 0, 0, 0, loc)
+	rng := rand.New(rand.NewSource(p.Seed))
+
+	dtH := p.Step.Hours()
+	decay := math.Exp(-p.MeanReversion * dtH)
+	noiseStep := p.Volatility * math.Sqrt(1-decay*decay)
+	spikeDecay := 0.0
+	if p.SpikeDecayHours > 0 {
+		spikeDecay = math.Exp(-dtH / p.SpikeDecayHours)
+	}
+
+	var out []model.LMPInterval
+	noise := p.Volatility * rng.NormFloat64()
+	spike := 0.0
+	var negativeUntil time.Time
+	for t := start.UTC(); t.Before(end); t = t.Add(p.Step) {
+		local := t.In(loc)
+		hour := local.Hour()
+		season := 1 + p.SeasonalAmplitude*math.Cos(2*math.Pi*float64(local.YearDay()-205)/365)
+		shape := p.HourlyShape[hour]
+		expected := (p.BasePrice + p.DailyAmplitude*shape) * season
+
+		noise = noise*decay + noiseStep*rng.NormFloat64()
+		spike *= spikeDecay
+		if rng.Float64() < p.SpikesPerDay*dtH/24*math.Max(0, 1+shape) {
+			spike += rng.ExpFloat64() * p.SpikeMean
+		}
+		// Episodes start in the solar window; the daily rate is spread over its 5 hours.
+		if hour >= 9 && hour < 14 && !t.Before(negativeUntil) && rng.Float64() < p.NegativePerDay*dtH/5 {
+			negativeUntil = t.Add(time.Duration(p.NegativeHours * float64(time.Hour)))
+		}
+
+		lmp := expected + noise + spike
+		if t.Before(negativeUntil) {
+			lmp = p.NegativePrice + 0.1*noise
+		}
+		lmp = math.Round(lmp*1000) / 1000
+		loss := math.Round(p.LossShare*lmp*1000) / 1000
+		congestion := math.Round(p.CongestionShare*(lmp-p.BasePrice*season)*1000) / 1000
+		next := t.Add(p.Step)
+		out = append(out, model.LMPInterval{
+			IntervalStartLocal: local,
+			IntervalStartUTC:   t,
+			IntervalEndLocal:   next.In(loc),
+			IntervalEndUTC:     next,
+			Market:             p.Market,
+			Location:           p.Location,
+			LocationType:       p.LocationType,
+			LMP:                lmp,
+			Energy:             math.Round((lmp-loss-congestion)*1000) / 1000,
+			Congestion:         congestion,
+			Loss:               loss,
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,75 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"battery-backtest/internal/model"
+)
+
+// Timezone policies for the local timestamps of an interval series. Any other
+// value is an IANA zone name such as "America/Los_Angeles".
+const (
+	TimezoneMarket = "market" // local timestamps as provided by the source
+	TimezoneUTC    = "UTC"    // local timestamps in UTC
+)
+
+// LoadTimezone resolves a timezone policy. It returns nil for "market" (or
+// ""), meaning local timestamps are kept as provided.
+func LoadTimezone(tz string) (*time.Location, error) {
+	switch tz {
+	case "", TimezoneMarket:
+		return nil, nil
+	case TimezoneUTC:
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", tz, err)
+	}
+	return loc, nil
+}
+
+// ApplyTimezone rewrites the local timestamps of intervals in loc, from their
+// UTC timestamps, in place. A nil loc leaves them unchanged.
+//
+// Source data carries a fixed UTC offset per row; converting to a named zone
+// keeps day, month and year boundaries on the local calendar across DST
+// changes (23- and 25-hour days).
+func ApplyTimezone(intervals []model.LMPInterval, loc *time.Location) {
+	if loc == nil {
+		return
+	}
+	for i := range intervals {
+		it := &intervals[i]
+		it.IntervalStartLocal = it.IntervalStartUTC.In(loc)
+		it.IntervalEndLocal = it.IntervalEndUTC.In(loc)
+	}
+}
+
+// marketZones maps a dataset prefix to the zone of its market-local days.
+// MISO publishes in Eastern Standard Time all year.
+var marketZones = map[string]string{
+	"caiso": "America/Los_Angeles",
+	"ercot": "America/Chicago",
+	"spp":   "America/Chicago",
+	"miso":  "Etc/GMT+5",
+	"pjm":   "America/New_York",
+	"nyiso": "America/New_York",
+	"isone": "America/New_York",
+}
+
+// MarketZone returns the zone of the market-local days of a dataset such as
+// "caiso_lmp_real_time_5_min", or "" if the market is not known.
+func MarketZone(dataset string) string {
+	prefix, _, _ := strings.Cut(dataset, "_")
+	return marketZones[strings.ToLower(prefix)]
+}
+
+// MarketToday returns the current market-local date in loc, at midnight UTC
+// like the other day boundaries of the store.
+func MarketToday(now time.Time, loc *time.Location) time.Time {
+	y, m, d := now.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
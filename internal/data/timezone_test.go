@@ -0,0 +1,155 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"battery-backtest/internal/model"
+)
+
+// dstDays are the 2024 Pacific DST transitions: spring forward (23-hour day)
+// and fall back (25-hour day).
+var dstDays = []struct {
+	name      string
+	date      string
+	intervals int // five-minute intervals in the local day
+}{
+	{"spring forward", "2024-03-10", 276},
+	{"fall back", "2024-11-03", 300},
+}
+
+// marketSeries returns five-minute intervals from UTC start to end whose local
+// timestamps carry a fixed offset, as in source data.
+func marketSeries(start, end time.Time, offset time.Duration) []model.LMPInterval {
+	zone := time.FixedZone("market", int(offset.Seconds()))
+	var out []model.LMPInterval
+	for t := start; t.Before(end); t = t.Add(5 * time.Minute) {
+		out = append(out, model.LMPInterval{
+			IntervalStartUTC:   t,
+			IntervalEndUTC:     t.Add(5 * time.Minute),
+			IntervalStartLocal: t.In(zone),
+			IntervalEndLocal:   t.Add(5 * time.Minute).In(zone),
+		})
+	}
+	return out
+}
+
+func TestApplyTimezoneDSTDays(t *testing.T) {
+	la, err := LoadTimezone("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range dstDays {
+		t.Run(tc.name, func(t *testing.T) {
+			day, _ := time.ParseInLocation("2006-01-02", tc.date, la)
+			// A day on each side, with a standard-time offset throughout.
+			series := marketSeries(day.AddDate(0, 0, -1).UTC(), day.AddDate(0, 0, 2).UTC(), -8*time.Hour)
+			ApplyTimezone(series, la)
+
+			n := 0
+			for _, it := range series {
+				if it.IntervalStartLocal.Location() != la {
+					t.Fatalf("local start %s not in %s", it.IntervalStartLocal, la)
+				}
+				if !it.IntervalStartLocal.Equal(it.IntervalStartUTC) {
+					t.Fatalf("local start %s is not the UTC instant %s", it.IntervalStartLocal, it.IntervalStartUTC)
+				}
+				if it.IntervalStartLocal.Format("2006-01-02") == tc.date {
+					n++
+				}
+			}
+			if n != tc.intervals {
+				t.Errorf("%s has %d intervals, want %d", tc.date, n, tc.intervals)
+			}
+		})
+	}
+}
+
+func TestResampleDailyDSTDays(t *testing.T) {
+	la, err := LoadTimezone("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range dstDays {
+		t.Run(tc.name, func(t *testing.T) {
+			day, _ := time.ParseInLocation("2006-01-02", tc.date, la)
+			series := marketSeries(day.AddDate(0, 0, -1).UTC(), day.AddDate(0, 0, 2).UTC(), -8*time.Hour)
+			ApplyTimezone(series, la)
+
+			out, err := Resample(series, 24*time.Hour, ResampleTimeWeighted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(out) != 3 {
+				t.Fatalf("got %d daily buckets, want 3", len(out))
+			}
+			got := out[1]
+			if s := got.IntervalStartLocal.Format("2006-01-02 15:04"); s != tc.date+" 00:00" {
+				t.Errorf("DST day starts at %s, want local midnight", s)
+			}
+			if s := got.IntervalEndLocal.Format("2006-01-02 15:04"); s != day.AddDate(0, 0, 1).Format("2006-01-02")+" 00:00" {
+				t.Errorf("DST day ends at %s, want the next local midnight", s)
+			}
+			if want := time.Duration(tc.intervals) * 5 * time.Minute; got.Duration() != want {
+				t.Errorf("DST day lasts %s, want %s", got.Duration(), want)
+			}
+		})
+	}
+}
+
+func TestLoadTimezone(t *testing.T) {
+	tests := []struct {
+		tz      string
+		want    string // "" = nil location
+		wantErr bool
+	}{
+		{"", "", false},
+		{TimezoneMarket, "", false},
+		{TimezoneUTC, "UTC", false},
+		{"America/Los_Angeles", "America/Los_Angeles", false},
+		{"Not/A_Zone", "", true},
+	}
+	for _, tc := range tests {
+		loc, err := LoadTimezone(tc.tz)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("LoadTimezone(%q) error = %v, want error %v", tc.tz, err, tc.wantErr)
+			continue
+		}
+		got := ""
+		if loc != nil {
+			got = loc.String()
+		}
+		if got != tc.want {
+			t.Errorf("LoadTimezone(%q) = %q, want %q", tc.tz, got, tc.want)
+		}
+	}
+}
+
+func TestMarketToday(t *testing.T) {
+	tests := []struct {
+		dataset string
+		now     string // UTC
+		want    string
+	}{
+		// Still the evening before in California.
+		{"caiso_lmp_real_time_5_min", "2024-03-10T05:00:00Z", "2024-03-09"},
+		{"caiso_lmp_real_time_5_min", "2024-03-10T08:00:00Z", "2024-03-10"},
+		{"pjm_lmp_real_time_5_min", "2024-11-03T04:30:00Z", "2024-11-03"},
+		{"miso_lmp_real_time_5_min", "2024-07-01T04:30:00Z", "2024-06-30"},
+	}
+	for _, tc := range tests {
+		zone := MarketZone(tc.dataset)
+		loc, err := time.LoadLocation(zone)
+		if zone == "" || err != nil {
+			t.Fatalf("MarketZone(%q) = %q, %v", tc.dataset, zone, err)
+		}
+		now, _ := time.Parse(time.RFC3339, tc.now)
+		got := MarketToday(now, loc)
+		if got.Format("2006-01-02") != tc.want || got.Location() != time.UTC || got.Hour() != 0 {
+			t.Errorf("%s at %s: market today = %s, want %s", tc.dataset, tc.now, got, tc.want)
+		}
+	}
+	if z := MarketZone("unknown_dataset"); z != "" {
+		t.Errorf("MarketZone(unknown) = %q, want \"\"", z)
+	}
+}
@@ -0,0 +1,191 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"battery-backtest/internal/model"
+)
+
+// IssueKind classifies a data-quality issue.
+type IssueKind string
+
+const (
+	IssueUnsorted    IssueKind = "unsorted"     // row starts before the previous row in input order
+	IssueDuplicate   IssueKind = "duplicate"    // same start as another row
+	IssueOverlap     IssueKind = "overlap"      // starts before the previous row ends
+	IssueGap         IssueKind = "gap"          // starts after the previous row (in time) ends
+	IssueMixedLength IssueKind = "mixed_length" // duration differs from the typical interval
+	IssueNonPositive IssueKind = "non_positive" // end is not after start
+	IssueDST         IssueKind = "dst"          // local and UTC timestamps disagree
+	IssueOutlier     IssueKind = "outlier"      // LMP far outside the robust price range
+)
+
+// Issue is one data-quality finding. Index refers to the input order.
+type Issue struct {
+	Kind   IssueKind
+	Index  int
+	Time   time.Time // interval start (UTC)
+	Detail string
+}
+
+// QualityReport summarizes a Validate pass and, after Repair, what was changed.
+type QualityReport struct {
+	Intervals      int
+	IntervalLength time.Duration // most common interval length
+	Counts         map[IssueKind]int
+	Issues         []Issue
+
+	// MissingIntervals is the number of typical-length intervals in the gaps.
+	MissingIntervals int
+
+	// Repair results (zero before Repair).
+	Policy      RepairPolicy
+	Dropped     int // rows removed
+	Filled      int // intervals synthesized or re-priced
+	Unavailable int // intervals marked unavailable (not also counted in Filled)
+}
+
+// OK reports whether no issues were found.
+func (r *QualityReport) OK() bool { return len(r.Issues) == 0 }
+
+// ValidateOptions tunes Validate.
+type ValidateOptions struct {
+	// OutlierMADs flags LMPs more than this many scaled median absolute
+	// deviations from the median (default 15; negative disables).
+	OutlierMADs float64
+
+	// RepairOutliers lets Repair act on outliers like on bad rows. Off by
+	// default: extreme prices are often real scarcity events.
+	RepairOutliers bool
+}
+
+// Validate checks an interval series for unsorted rows, duplicates, overlaps,
+// gaps, mixed interval lengths, non-positive durations, local/UTC (DST)
+// inconsistencies and outlier prices. It does not modify intervals.
+func Validate(intervals []model.LMPInterval, opts ValidateOptions) *QualityReport {
+	r := &QualityReport{Intervals: len(intervals), Counts: map[IssueKind]int{}}
+	if len(intervals) == 0 {
+		return r
+	}
+	add := func(kind IssueKind, i int, format string, args ...any) {
+		r.Counts[kind]++
+		r.Issues = append(r.Issues, Issue{Kind: kind, Index: i, Time: intervals[i].IntervalStartUTC, Detail: fmt.Sprintf(format, args...)})
+	}
+	r.IntervalLength = typicalLength(intervals)
+
+	for i, it := range intervals {
+		d := it.Duration()
+		if d <= 0 {
+			add(IssueNonPositive, i, "duration %s", d)
+		} else if d != r.IntervalLength {
+			add(IssueMixedLength, i, "duration %s, typical %s", d, r.IntervalLength)
+		}
+		if localUTCMismatch(it) {
+			add(IssueDST, i, "local %s / %s does not match UTC %s / %s",
+				it.IntervalStartLocal.Format(time.RFC3339), it.IntervalEndLocal.Format(time.RFC3339),
+				it.IntervalStartUTC.Format(time.RFC3339), it.IntervalEndUTC.Format(time.RFC3339))
+		}
+		if i > 0 && it.IntervalStartUTC.Before(intervals[i-1].IntervalStartUTC) {
+			add(IssueUnsorted, i, "starts before row %d", i-1)
+		}
+	}
+
+	// Duplicates, overlaps and gaps are checked in time order.
+	order := make([]int, len(intervals))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return intervals[order[a]].IntervalStartUTC.Before(intervals[order[b]].IntervalStartUTC)
+	})
+	for k := 1; k < len(order); k++ {
+		i, prev := order[k], intervals[order[k-1]]
+		it := intervals[i]
+		switch {
+		case it.IntervalStartUTC.Equal(prev.IntervalStartUTC):
+			add(IssueDuplicate, i, "same start as row %d", order[k-1])
+		case it.IntervalStartUTC.Before(prev.IntervalEndUTC):
+			add(IssueOverlap, i, "overlaps row %d by %s", order[k-1], prev.IntervalEndUTC.Sub(it.IntervalStartUTC))
+		case it.IntervalStartUTC.After(prev.IntervalEndUTC):
+			gap := it.IntervalStartUTC.Sub(prev.IntervalEndUTC)
+			add(IssueGap, i, "%s missing after row %d", gap, order[k-1])
+			if r.IntervalLength > 0 {
+				r.MissingIntervals += int(gap / r.IntervalLength)
+			}
+		}
+	}
+
+	for _, i := range outliers(intervals, opts.OutlierMADs) {
+		add(IssueOutlier, i, "LMP %.2f", intervals[i].LMP)
+	}
+	return r
+}
+
+// typicalLength is the most common positive interval duration.
+func typicalLength(intervals []model.LMPInterval) time.Duration {
+	counts := map[time.Duration]int{}
+	var best time.Duration
+	for _, it := range intervals {
+		d := it.Duration()
+		if d <= 0 {
+			continue
+		}
+		counts[d]++
+		if counts[d] > counts[best] || (counts[d] == counts[best] && d < best) {
+			best = d
+		}
+	}
+	return best
+}
+
+// localUTCMismatch reports local timestamps that do not denote the same
+// instants as the UTC ones, e.g. a naive local clock across a DST change.
+func localUTCMismatch(it model.LMPInterval) bool {
+	if it.IntervalStartLocal.IsZero() || it.IntervalStartUTC.IsZero() {
+		return false
+	}
+	return !it.IntervalStartLocal.Equal(it.IntervalStartUTC) || !it.IntervalEndLocal.Equal(it.IntervalEndUTC)
+}
+
+// outliers returns the indexes of LMPs more than k scaled MADs from the median.
+func outliers(intervals []model.LMPInterval, k float64) []int {
+	if k == 0 {
+		k = 15
+	}
+	if k < 0 || len(intervals) < 3 {
+		return nil
+	}
+	prices := make([]float64, len(intervals))
+	for i, it := range intervals {
+		prices[i] = it.LMP
+	}
+	median := medianOf(prices)
+	dev := make([]float64, len(prices))
+	for i, p := range prices {
+		dev[i] = math.Abs(p - median)
+	}
+	mad := 1.4826 * medianOf(dev)
+	if mad == 0 {
+		return nil
+	}
+	var out []int
+	for i, p := range prices {
+		if math.Abs(p-median) > k*mad {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func medianOf(xs []float64) float64 {
+	s := append([]float64(nil), xs...)
+	sort.Float64s(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
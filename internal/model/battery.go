@@ -2,6 +2,7 @@ package model
 
 import (
 	"errors"
+	"fmt"
 	"math"
 )
 
@@ -13,31 +14,248 @@ import (
 // - SOC: fraction 0..1
 // - DegradationCostPerMWh: $/MWh throughput (charge + discharge)
 type BatteryParams struct {
-	EnergyCapacityMWh      float64
-	PowerCapacityMW        float64
-	ChargeEfficiency       float64
-	DischargeEfficiency    float64
-	MinSOC                 float64
-	MaxSOC                 float64
-	DegradationCostPerMWh  float64
+	EnergyCapacityMWh     float64
+	PowerCapacityMW       float64
+	ChargeEfficiency      float64
+	DischargeEfficiency   float64
+	MinSOC                float64
+	MaxSOC                float64
+	DegradationCostPerMWh float64
+
+	// MaxChargeMW/MaxDischargeMW are the rated limits per direction.
+	// Zero means PowerCapacityMW.
+	MaxChargeMW    float64
+	MaxDischargeMW float64
+
+	// ChargeCurve/DischargeCurve optionally taper the rated limit by SOC
+	// (e.g. reduced charge power above 90% SOC). Empty means no taper.
+	ChargeCurve    PowerCurve
+	DischargeCurve PowerCurve
+
+	// ChargeEfficiencyCurve/DischargeEfficiencyCurve optionally make efficiency
+	// depend on the power fraction of the direction's rating. Empty means the
+	// constant ChargeEfficiency/DischargeEfficiency.
+	ChargeEfficiencyCurve    EfficiencyCurve
+	DischargeEfficiencyCurve EfficiencyCurve
+
+	// AuxLoadMW is a constant auxiliary load (HVAC, controls) drawn from the grid,
+	// or from stored energy when Islanded.
+	AuxLoadMW float64
+	Islanded  bool
+
+	// SelfDischargePctPerDay is the percentage of stored energy lost per day.
+	SelfDischargePctPerDay float64
+
+	// RampRateMWPerMin limits the change in power between consecutive intervals
+	// (0 = unlimited). MinModeMinutes is the minimum time a mode (charging, idle,
+	// discharging) must be held before switching. ModeSwitchCostUSD is charged
+	// each time the battery starts charging or discharging from another mode.
+	RampRateMWPerMin  float64
+	MinModeMinutes    float64
+	ModeSwitchCostUSD float64
+
+	// MaxCyclesPerDay/MaxCyclesPerYear are warranty caps on equivalent full
+	// cycles per local calendar day/year (0 = unlimited). One cycle is
+	// EnergyCapacityMWh delivered to the grid, so the caps are also discharge
+	// throughput caps. They are enforced by the backtest engine.
+	MaxCyclesPerDay  float64
+	MaxCyclesPerYear float64
+
+	// Thermal enables the cell temperature model (nil = isothermal).
+	Thermal *ThermalParams
+
+	// Settlement adds fees and monthly charges to the PnL (nil = LMP only).
+	Settlement *SettlementParams
+}
+
+// CycleCapMWh converts a cycle count into grid-side discharge MWh.
+func (p BatteryParams) CycleCapMWh(cycles float64) float64 {
+	return cycles * p.EnergyCapacityMWh
+}
+
+// EfficiencyPoint is one point of an efficiency-vs-power curve.
+type EfficiencyPoint struct {
+	PowerFraction float64 // |power| / rating, 0..1
+	Efficiency    float64 // 0..1
+}
+
+// EfficiencyCurve is a piecewise-linear efficiency curve sorted by power
+// fraction, flat beyond its first and last points.
+type EfficiencyCurve []EfficiencyPoint
+
+// At returns the efficiency at power fraction f, or fallback for an empty curve.
+func (c EfficiencyCurve) At(f, fallback float64) float64 {
+	if len(c) == 0 {
+		return fallback
+	}
+	if f <= c[0].PowerFraction {
+		return c[0].Efficiency
+	}
+	for i := 1; i < len(c); i++ {
+		if f <= c[i].PowerFraction {
+			a, b := c[i-1], c[i]
+			if b.PowerFraction == a.PowerFraction {
+				return b.Efficiency
+			}
+			return a.Efficiency + (f-a.PowerFraction)/(b.PowerFraction-a.PowerFraction)*(b.Efficiency-a.Efficiency)
+		}
+	}
+	return c[len(c)-1].Efficiency
+}
+
+func (c EfficiencyCurve) validate() error {
+	for i, pt := range c {
+		if pt.PowerFraction < 0 || pt.PowerFraction > 1 {
+			return errors.New("efficiency curve power fraction must be in [0, 1]")
+		}
+		if pt.Efficiency <= 0 || pt.Efficiency > 1 {
+			return errors.New("efficiency curve efficiency must be in (0, 1]")
+		}
+		if i > 0 && pt.PowerFraction < c[i-1].PowerFraction {
+			return errors.New("efficiency curve points must be sorted by power fraction")
+		}
+	}
+	return nil
+}
+
+// ChargeEfficiencyAt is the charge efficiency at chargeMW (grid side, >= 0).
+func (p BatteryParams) ChargeEfficiencyAt(chargeMW float64) float64 {
+	return p.ChargeEfficiencyCurve.At(chargeMW/p.ChargeRatingMW(), p.ChargeEfficiency)
+}
+
+// DischargeEfficiencyAt is the discharge efficiency at dischargeMW (grid side).
+func (p BatteryParams) DischargeEfficiencyAt(dischargeMW float64) float64 {
+	return p.DischargeEfficiencyCurve.At(dischargeMW/p.DischargeRatingMW(), p.DischargeEfficiency)
+}
+
+// PowerCurvePoint is one point of a SOC-to-max-power curve.
+type PowerCurvePoint struct {
+	SOC   float64 // fraction 0..1
+	MaxMW float64
+}
+
+// PowerCurve is a piecewise-linear SOC-to-max-power curve, sorted by SOC.
+// It is flat beyond its first and last points.
+type PowerCurve []PowerCurvePoint
+
+// At returns the max power at soc, or +Inf for an empty curve.
+func (c PowerCurve) At(soc float64) float64 {
+	if len(c) == 0 {
+		return math.Inf(1)
+	}
+	if soc <= c[0].SOC {
+		return c[0].MaxMW
+	}
+	for i := 1; i < len(c); i++ {
+		if soc <= c[i].SOC {
+			a, b := c[i-1], c[i]
+			if b.SOC == a.SOC {
+				return b.MaxMW
+			}
+			return a.MaxMW + (soc-a.SOC)/(b.SOC-a.SOC)*(b.MaxMW-a.MaxMW)
+		}
+	}
+	return c[len(c)-1].MaxMW
+}
+
+func (c PowerCurve) validate() error {
+	for i, pt := range c {
+		if pt.SOC < 0 || pt.SOC > 1 {
+			return errors.New("power curve SOC must be in [0, 1]")
+		}
+		if pt.MaxMW < 0 {
+			return errors.New("power curve MaxMW must be >= 0")
+		}
+		if i > 0 && pt.SOC < c[i-1].SOC {
+			return errors.New("power curve points must be sorted by SOC")
+		}
+	}
+	return nil
+}
+
+// ChargeRatingMW is the rated charge limit, ignoring any SOC taper.
+func (p BatteryParams) ChargeRatingMW() float64 {
+	if p.MaxChargeMW > 0 {
+		return p.MaxChargeMW
+	}
+	return p.PowerCapacityMW
+}
+
+// DischargeRatingMW is the rated discharge limit, ignoring any SOC taper.
+func (p BatteryParams) DischargeRatingMW() float64 {
+	if p.MaxDischargeMW > 0 {
+		return p.MaxDischargeMW
+	}
+	return p.PowerCapacityMW
+}
+
+// ChargeLimitMW is the max charge power (grid side, positive MW) at soc.
+func (p BatteryParams) ChargeLimitMW(soc float64) float64 {
+	return math.Max(0, math.Min(p.ChargeRatingMW(), p.ChargeCurve.At(soc)))
+}
+
+// DischargeLimitMW is the max discharge power (grid side) at soc.
+func (p BatteryParams) DischargeLimitMW(soc float64) float64 {
+	return math.Max(0, math.Min(p.DischargeRatingMW(), p.DischargeCurve.At(soc)))
+}
+
+// ClipPowerMW clips a signed power request to the limits at soc.
+// The taper is evaluated at the SOC at the start of the interval.
+func (p BatteryParams) ClipPowerMW(powerMW, soc float64) float64 {
+	if max := p.DischargeLimitMW(soc); powerMW > max {
+		return max
+	}
+	if max := p.ChargeLimitMW(soc); powerMW < -max {
+		return -max
+	}
+	return powerMW
 }
 
 // BatteryState captures mutable state.
 type BatteryState struct {
 	// SOC is the state of charge as a fraction [0,1].
 	SOC float64
+
+	// PowerMW is the realized power of the previous interval.
+	PowerMW float64
+
+	// ModeMinutes is how long the current mode has been held.
+	// 0 means unknown (e.g. before the first interval) and is unconstrained.
+	ModeMinutes float64
+
+	// CellTempC is the cell temperature (thermal model only).
+	// AmbientTempC is an input for the coming interval, set via SetAmbient.
+	CellTempC    float64
+	AmbientTempC float64
 }
 
 // Battery is a convenience wrapper bundling params + state.
 type Battery struct {
 	Params BatteryParams
 	State  BatteryState
+
+	thermalStarted bool
+}
+
+// SetAmbient sets the ambient temperature for the next interval (nil = the
+// thermal model's default). Cells start at the steady temperature for the
+// first ambient seen. It is a no-op without a thermal model.
+func (b *Battery) SetAmbient(ambientC *float64) {
+	t := b.Params.Thermal
+	if t == nil {
+		return
+	}
+	b.State.AmbientTempC = t.Ambient(ambientC)
+	if !b.thermalStarted {
+		b.State.CellTempC = t.SteadyTempC(b.State.AmbientTempC)
+		b.thermalStarted = true
+	}
 }
 
 func NewBattery(params BatteryParams, initialSOC float64) (*Battery, error) {
 	b := &Battery{
 		Params: params,
-		State: BatteryState{SOC: initialSOC},
+		State:  BatteryState{SOC: initialSOC},
 	}
 	if err := b.Validate(); err != nil {
 		return nil, err
@@ -68,6 +286,43 @@ func (b *Battery) Validate() error {
 	if p.DegradationCostPerMWh < 0 {
 		return errors.New("DegradationCostPerMWh must be >= 0")
 	}
+	if p.MaxChargeMW < 0 || p.MaxDischargeMW < 0 {
+		return errors.New("MaxChargeMW/MaxDischargeMW must be >= 0")
+	}
+	if err := p.ChargeCurve.validate(); err != nil {
+		return fmt.Errorf("charge curve: %w", err)
+	}
+	if err := p.DischargeCurve.validate(); err != nil {
+		return fmt.Errorf("discharge curve: %w", err)
+	}
+	if err := p.ChargeEfficiencyCurve.validate(); err != nil {
+		return fmt.Errorf("charge efficiency curve: %w", err)
+	}
+	if err := p.DischargeEfficiencyCurve.validate(); err != nil {
+		return fmt.Errorf("discharge efficiency curve: %w", err)
+	}
+	if p.AuxLoadMW < 0 {
+		return errors.New("AuxLoadMW must be >= 0")
+	}
+	if p.SelfDischargePctPerDay < 0 || p.SelfDischargePctPerDay > 100 {
+		return errors.New("SelfDischargePctPerDay must be in [0, 100]")
+	}
+	if p.Thermal != nil {
+		if err := p.Thermal.validate(); err != nil {
+			return err
+		}
+	}
+	if p.Settlement != nil {
+		if err := p.Settlement.validate(); err != nil {
+			return err
+		}
+	}
+	if p.MaxCyclesPerDay < 0 || p.MaxCyclesPerYear < 0 {
+		return errors.New("MaxCyclesPerDay/MaxCyclesPerYear must be >= 0")
+	}
+	if p.RampRateMWPerMin < 0 || p.MinModeMinutes < 0 || p.ModeSwitchCostUSD < 0 {
+		return errors.New("RampRateMWPerMin, MinModeMinutes and ModeSwitchCostUSD must be >= 0")
+	}
 	return nil
 }
 
@@ -75,34 +330,54 @@ func (b *Battery) Validate() error {
 // Convention: positive MW = discharge to grid, negative MW = charge from grid.
 type Dispatch struct {
 	PowerMW float64
+
+	// DischargeCapMW optionally bounds the realized discharge after ramp and
+	// mode constraints (used for warranty cycle allowances). Nil means no cap.
+	DischargeCapMW *float64
+
+	// Outage forces zero power regardless of ramp and mode constraints.
+	Outage bool
 }
 
 // IntervalResult captures what happened in one interval.
 type IntervalResult struct {
-	PowerMW        float64 // realized power (may be clipped)
-	EnergyToGridMWh float64 // discharge energy delivered to grid
+	PowerMW           float64 // realized power (may be clipped)
+	EnergyToGridMWh   float64 // discharge energy delivered to grid
 	EnergyFromGridMWh float64 // charge energy pulled from grid
-	ThroughputMWh  float64 // EnergyFromGridMWh + EnergyToGridMWh
-	SOCStart       float64
-	SOCEnd         float64
-	PNL            float64 // $ for this interval (incl degradation)
+	ThroughputMWh     float64 // EnergyFromGridMWh + EnergyToGridMWh
+	AuxMWh            float64 // auxiliary load energy incl. HVAC (from grid unless islanded)
+	HVACMWh           float64 // thermal model HVAC electric energy (part of AuxMWh)
+	CellTempC         float64 // cell temperature at the end of the interval (thermal model)
+	Derate            float64 // thermal power derate factor applied (1 = none)
+	SelfDischargeMWh  float64 // stored energy lost to self-discharge
+	RampClipped       bool    // request was limited by RampRateMWPerMin
+	ModeHeld          bool    // request was replaced by the previous power (MinModeMinutes)
+	SwitchCostUSD     float64 // ModeSwitchCostUSD if a charge/discharge mode started
+	SOCStart          float64
+	SOCEnd            float64
+	PNL               float64 // $ for this interval (incl degradation, grid aux, fees and switch costs)
+
+	// PnL breakdown: PNL = EnergyRevenueUSD - EnergyCostUSD - DegradationUSD -
+	// ChargingFeeUSD - ThroughputFeeUSD - SwitchCostUSD.
+	SettlementPrice  float64 // LMP after any settlement floor/cap
+	EnergyRevenueUSD float64 // SettlementPrice * EnergyToGridMWh
+	EnergyCostUSD    float64 // SettlementPrice * (EnergyFromGridMWh + aux drawn from the grid)
+	DegradationUSD   float64
+	ChargingFeeUSD   float64
+	ThroughputFeeUSD float64
+	GridDrawMW       float64 // average draw on the charging meter (charging + grid aux)
 }
 
-// ClipDispatch enforces the power limit, without applying SOC constraints.
+// ClipDispatch enforces the charge/discharge power limits at the current SOC,
+// without applying SOC bounds.
 func (b *Battery) ClipDispatch(d Dispatch) Dispatch {
-	p := d.PowerMW
-	if p > b.Params.PowerCapacityMW {
-		p = b.Params.PowerCapacityMW
-	}
-	if p < -b.Params.PowerCapacityMW {
-		p = -b.Params.PowerCapacityMW
-	}
-	return Dispatch{PowerMW: p}
+	return Dispatch{PowerMW: b.Params.ClipPowerMW(d.PowerMW, b.State.SOC)}
 }
 
 // ApplyDispatch applies a dispatch for a single interval, enforcing:
-// - power capacity
+// - charge/discharge power limits (including any SOC taper)
 // - SOC bounds (by clipping the requested power)
+// and accounting for efficiency curves, auxiliary load and self-discharge.
 //
 // lmp is $/MWh for the interval.
 // durationHours is the interval length in hours.
@@ -110,90 +385,181 @@ func (b *Battery) ApplyDispatch(lmp float64, d Dispatch, durationHours float64)
 	if durationHours <= 0 {
 		return IntervalResult{}, errors.New("durationHours must be > 0")
 	}
+	res, next := b.Params.Simulate(b.State, lmp, d, durationHours)
+	b.State = next
+	return res, nil
+}
 
-	d = b.ClipDispatch(d)
-	p := d.PowerMW
+// Constrain applies the minimum mode time and ramp limit to a request from
+// st: a request to change mode before MinModeMinutes is replaced by the
+// previous power, then the result is ramp-limited against it. It reports which
+// constraint changed the request. Power limits and SOC bounds are not applied.
+func (p BatteryParams) Constrain(st BatteryState, d Dispatch, durationHours float64) (powerMW float64, modeHeld, rampClipped bool) {
+	if d.Outage {
+		return 0, false, false
+	}
+	power := d.PowerMW
+	if p.MinModeMinutes > 0 && st.ModeMinutes > 0 && st.ModeMinutes < p.MinModeMinutes &&
+		ActionFromPowerMW(power) != ActionFromPowerMW(st.PowerMW) {
+		power = st.PowerMW
+		modeHeld = true
+	}
+	if p.RampRateMWPerMin > 0 {
+		maxDelta := p.RampRateMWPerMin * durationHours * 60
+		ramped := math.Max(st.PowerMW-maxDelta, math.Min(st.PowerMW+maxDelta, power))
+		if math.Abs(ramped-power) > 1e-9 {
+			rampClipped = true
+		}
+		power = ramped
+	}
+	return power, modeHeld, rampClipped
+}
 
-	res := IntervalResult{
-		SOCStart: b.State.SOC,
+// NextModeMinutes is how long the mode of a realized power will have been
+// held at the end of an interval started from st.
+func (st BatteryState) NextModeMinutes(realizedMW, durationHours float64) float64 {
+	if ActionFromPowerMW(realizedMW) != ActionFromPowerMW(st.PowerMW) {
+		return durationHours * 60
 	}
+	// A mode of unknown age (e.g. the initial idle) stays unconstrained until it changes.
+	if st.ModeMinutes > 0 {
+		return st.ModeMinutes + durationHours*60
+	}
+	return 0
+}
 
-	// SOC constraints determine the max feasible charge/discharge for the interval.
-	maxChargeMWhGrid := b.maxChargeEnergyFromGridMWh(durationHours)
-	maxDischargeMWhGrid := b.maxDischargeEnergyToGridMWh(durationHours)
+// Simulate is the pure interval physics + PnL behind ApplyDispatch, starting
+// from st. It returns the interval result and the next state. Optimizers use it
+// so their model matches the engine exactly.
+//
+// The request is first held in the current mode if MinModeMinutes has not
+// elapsed, then ramp-limited against the previous power, then clipped to the
+// power limits and SOC bounds. SOC bounds win over ramp limits: a battery that
+// hits MinSOC/MaxSOC stops even if that is a faster ramp.
+//
+// Order within an interval: the dispatch is applied first, then self-discharge,
+// then (when islanded) the auxiliary load. Losses never take SOC below MinSOC;
+// an islanded aux shortfall is served from the grid instead.
+func (p BatteryParams) Simulate(st BatteryState, lmp float64, d Dispatch, durationHours float64) (IntervalResult, BatteryState) {
+	soc := st.SOC
+	res := IntervalResult{SOCStart: soc}
+	dtH := durationHours
+	capMWh := p.EnergyCapacityMWh
+
+	prevMode := ActionFromPowerMW(st.PowerMW)
+	power, held, clipped := p.Constrain(st, d, dtH)
+	res.ModeHeld, res.RampClipped = held, clipped
+	power = p.ClipPowerMW(power, soc)
+	// Thermal derate scales the power limits at the cell temperature.
+	res.Derate = p.Thermal.Derate(st.CellTempC)
+	if res.Derate < 1 {
+		power = math.Max(-res.Derate*p.ChargeLimitMW(soc), math.Min(res.Derate*p.DischargeLimitMW(soc), power))
+	}
+	if d.DischargeCapMW != nil && power > *d.DischargeCapMW {
+		power = math.Max(0, *d.DischargeCapMW)
+	}
+	chargeEff := func(mw float64) float64 {
+		return p.Thermal.efficiencyFactor(p.ChargeEfficiencyAt(mw), st.CellTempC)
+	}
+	dischargeEff := func(mw float64) float64 {
+		return p.Thermal.efficiencyFactor(p.DischargeEfficiencyAt(mw), st.CellTempC)
+	}
+	lossesMWh := 0.0
 
-	// Convert requested power to requested grid-energy (before/after efficiency).
-	if p < 0 {
-		// Charging: power magnitude is MW from grid.
-		reqFromGridMWh := math.Abs(p) * durationHours
-		if reqFromGridMWh > maxChargeMWhGrid {
-			reqFromGridMWh = maxChargeMWhGrid
-			p = -reqFromGridMWh / durationHours
+	if power < 0 && dtH > 0 {
+		// Charging: power magnitude is MW from grid; stored = fromGrid * eff.
+		storableMWh := math.Max(0, (p.MaxSOC-soc)*capMWh)
+		fromGrid := -power * dtH
+		eff := chargeEff(-power)
+		// The efficiency depends on the realized power, so re-evaluate it a few
+		// times after clipping to the SOC headroom.
+		for i := 0; i < 4 && fromGrid*eff > storableMWh; i++ {
+			fromGrid = storableMWh / eff
+			eff = chargeEff(fromGrid / dtH)
 		}
-		// SOC increases by stored energy = fromGrid * chargeEff
-		storedMWh := reqFromGridMWh * b.Params.ChargeEfficiency
-		b.State.SOC = clamp01((b.State.SOC*b.Params.EnergyCapacityMWh + storedMWh) / b.Params.EnergyCapacityMWh)
-
-		res.PowerMW = p
-		res.EnergyFromGridMWh = reqFromGridMWh
-		res.EnergyToGridMWh = 0
-		res.ThroughputMWh = reqFromGridMWh
-	} else if p > 0 {
-		// Discharging: power is MW delivered to grid.
-		reqToGridMWh := p * durationHours
-		if reqToGridMWh > maxDischargeMWhGrid {
-			reqToGridMWh = maxDischargeMWhGrid
-			p = reqToGridMWh / durationHours
+		stored := math.Min(fromGrid*eff, storableMWh)
+		soc += stored / capMWh
+		lossesMWh = fromGrid - stored
+		res.PowerMW = -fromGrid / dtH
+		res.EnergyFromGridMWh = fromGrid
+	} else if power > 0 && dtH > 0 {
+		// Discharging: power is MW delivered to grid; withdrawn = toGrid / eff.
+		withdrawableMWh := math.Max(0, (soc-p.MinSOC)*capMWh)
+		toGrid := power * dtH
+		eff := dischargeEff(power)
+		for i := 0; i < 4 && toGrid/eff > withdrawableMWh; i++ {
+			toGrid = withdrawableMWh * eff
+			eff = dischargeEff(toGrid / dtH)
 		}
-		// SOC decreases by withdrawn energy = toGrid / dischargeEff
-		withdrawnMWh := reqToGridMWh / b.Params.DischargeEfficiency
-		b.State.SOC = clamp01((b.State.SOC*b.Params.EnergyCapacityMWh - withdrawnMWh) / b.Params.EnergyCapacityMWh)
+		withdrawn := math.Min(toGrid/eff, withdrawableMWh)
+		soc -= withdrawn / capMWh
+		lossesMWh = withdrawn - toGrid
+		res.PowerMW = toGrid / dtH
+		res.EnergyToGridMWh = toGrid
+	}
+	res.ThroughputMWh = res.EnergyFromGridMWh + res.EnergyToGridMWh
 
-		res.PowerMW = p
-		res.EnergyFromGridMWh = 0
-		res.EnergyToGridMWh = reqToGridMWh
-		res.ThroughputMWh = reqToGridMWh
-	} else {
-		res.PowerMW = 0
+	// Cell temperature: conversion losses heat the cells; HVAC draws aux power.
+	cellTemp := st.CellTempC
+	if p.Thermal != nil && dtH > 0 {
+		cellTemp, res.HVACMWh = p.Thermal.step(st.CellTempC, st.AmbientTempC, math.Max(0, lossesMWh), dtH)
 	}
+	res.CellTempC = cellTemp
 
-	res.SOCEnd = b.State.SOC
-	res.PNL = b.CalculateIntervalPnL(lmp, res.EnergyFromGridMWh, res.EnergyToGridMWh)
-	return res, nil
+	// Self-discharge: a fraction of the stored energy per day.
+	if p.SelfDischargePctPerDay > 0 && dtH > 0 {
+		loss := soc * capMWh * p.SelfDischargePctPerDay / 100 * dtH / 24
+		loss = math.Min(loss, math.Max(0, (soc-p.MinSOC)*capMWh))
+		soc -= loss / capMWh
+		res.SelfDischargeMWh = loss
+	}
+
+	// Auxiliary load (HVAC, controls): from the grid, or from storage when islanded.
+	auxFromGrid := 0.0
+	if (p.AuxLoadMW > 0 || res.HVACMWh > 0) && dtH > 0 {
+		res.AuxMWh = p.AuxLoadMW*dtH + res.HVACMWh
+		auxFromGrid = res.AuxMWh
+		if p.Islanded {
+			fromSOC := math.Min(res.AuxMWh, math.Max(0, (soc-p.MinSOC)*capMWh))
+			soc -= fromSOC / capMWh
+			auxFromGrid = res.AuxMWh - fromSOC
+		}
+	}
+
+	mode := ActionFromPowerMW(res.PowerMW)
+	if mode != ActionIdle && mode != prevMode {
+		res.SwitchCostUSD = p.ModeSwitchCostUSD
+	}
+
+	// Clamp numeric drift.
+	res.SOCEnd = math.Max(p.MinSOC, math.Min(p.MaxSOC, clamp01(soc)))
+	res.SettlementPrice = p.Settlement.Price(lmp)
+	res.EnergyRevenueUSD = res.SettlementPrice * res.EnergyToGridMWh
+	res.EnergyCostUSD = res.SettlementPrice * (res.EnergyFromGridMWh + auxFromGrid)
+	res.DegradationUSD = p.DegradationCostPerMWh * res.ThroughputMWh
+	res.ChargingFeeUSD, res.ThroughputFeeUSD = p.Settlement.fees(res.EnergyFromGridMWh, res.EnergyToGridMWh)
+	if dtH > 0 {
+		res.GridDrawMW = (res.EnergyFromGridMWh + auxFromGrid) / dtH
+	}
+	res.PNL = res.EnergyRevenueUSD - res.EnergyCostUSD - res.DegradationUSD -
+		res.ChargingFeeUSD - res.ThroughputFeeUSD - res.SwitchCostUSD
+
+	next := BatteryState{SOC: res.SOCEnd, PowerMW: res.PowerMW, ModeMinutes: st.NextModeMinutes(res.PowerMW, dtH), CellTempC: cellTemp, AmbientTempC: st.AmbientTempC}
+	return res, next
 }
 
 // CalculateIntervalPnL computes interval PnL given the *grid-side* energies.
 // - energyFromGridMWh: MWh purchased to charge (cost)
 // - energyToGridMWh: MWh sold when discharging (revenue)
+// Settlement floor/cap and per-MWh fees are included; monthly charges and bid
+// cost recovery are not.
 func (b *Battery) CalculateIntervalPnL(lmp float64, energyFromGridMWh float64, energyToGridMWh float64) float64 {
-	revenue := lmp * energyToGridMWh
-	cost := lmp * energyFromGridMWh
+	price := b.Params.Settlement.Price(lmp)
+	revenue := price * energyToGridMWh
+	cost := price * energyFromGridMWh
 	degradation := b.Params.DegradationCostPerMWh * (energyFromGridMWh + energyToGridMWh)
-	return revenue - cost - degradation
-}
-
-func (b *Battery) maxChargeEnergyFromGridMWh(durationHours float64) float64 {
-	// Max additional stored energy before hitting MaxSOC.
-	storableMWh := (b.Params.MaxSOC - b.State.SOC) * b.Params.EnergyCapacityMWh
-	if storableMWh <= 0 {
-		return 0
-	}
-	// Grid energy required = stored / eff.
-	limitBySOC := storableMWh / b.Params.ChargeEfficiency
-	limitByPower := b.Params.PowerCapacityMW * durationHours
-	return math.Max(0, math.Min(limitBySOC, limitByPower))
-}
-
-func (b *Battery) maxDischargeEnergyToGridMWh(durationHours float64) float64 {
-	// Max withdrawable stored energy before hitting MinSOC.
-	withdrawableMWh := (b.State.SOC - b.Params.MinSOC) * b.Params.EnergyCapacityMWh
-	if withdrawableMWh <= 0 {
-		return 0
-	}
-	// Grid energy delivered = withdrawn * eff.
-	limitBySOC := withdrawableMWh * b.Params.DischargeEfficiency
-	limitByPower := b.Params.PowerCapacityMW * durationHours
-	return math.Max(0, math.Min(limitBySOC, limitByPower))
+	charging, throughput := b.Params.Settlement.fees(energyFromGridMWh, energyToGridMWh)
+	return revenue - cost - degradation - charging - throughput
 }
 
 func clamp01(x float64) float64 {
@@ -205,4 +571,3 @@ func clamp01(x float64) float64 {
 	}
 	return x
 }
-
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BidSegment is one step of a price-quantity curve.
+// Convention matches Dispatch: positive MW = discharge offer (sell when
+// LMP >= Price), negative MW = charge bid (buy when LMP <= Price).
+type BidSegment struct {
+	PowerMW        float64
+	PriceUSDPerMWh float64
+}
+
+// BidCurve is the set of segments submitted for one interval.
+type BidCurve struct {
+	Segments []BidSegment
+}
+
+// Clear returns the net MW awarded when the market clears at lmp.
+// Offers clear at or above their price, bids at or below. If the curve
+// crosses itself (a bid above an offer), both sides clear and net out.
+func (c BidCurve) Clear(lmp float64) float64 {
+	p := 0.0
+	for _, s := range c.Segments {
+		switch {
+		case s.PowerMW > 0 && lmp >= s.PriceUSDPerMWh:
+			p += s.PowerMW
+		case s.PowerMW < 0 && lmp <= s.PriceUSDPerMWh:
+			p += s.PowerMW
+		}
+	}
+	return p
+}
+
+// String renders the curve compactly for CSV output, e.g. "-50@20;50@80".
+func (c BidCurve) String() string {
+	parts := make([]string, len(c.Segments))
+	for i, s := range c.Segments {
+		parts[i] = fmt.Sprintf("%g@%g", s.PowerMW, s.PriceUSDPerMWh)
+	}
+	return strings.Join(parts, ";")
+}
@@ -5,10 +5,11 @@ import "time"
 // GridStatusLMPResponse matches the JSON shape of sample_data.json.
 //
 // Example:
-// {
-//   "status_code": 200,
-//   "data": [ ... ]
-// }
+//
+//	{
+//	  "status_code": 200,
+//	  "data": [ ... ]
+//	}
 type GridStatusLMPResponse struct {
 	StatusCode int           `json:"status_code"`
 	Data       []LMPInterval `json:"data"`
@@ -32,6 +33,25 @@ type LMPInterval struct {
 	Congestion float64 `json:"congestion"`
 	Loss       float64 `json:"loss"`
 	GHG        float64 `json:"ghg"`
+
+	// AmbientTempC is the optional ambient temperature (°C) for the thermal model,
+	// either present in the data or joined from a separate series.
+	AmbientTempC *float64 `json:"ambient_temp_c,omitempty"`
+
+	// EmissionsRate is the optional marginal emissions rate (tCO2/MWh), joined
+	// from a separate series or derived from GHG.
+	EmissionsRate *float64 `json:"emissions_t_per_mwh,omitempty"`
+
+	// Filled marks an interval synthesized or re-priced by data repair;
+	// Unavailable marks one the battery cannot trade (treated as an outage).
+	Filled      bool `json:"filled,omitempty"`
+	Unavailable bool `json:"unavailable,omitempty"`
+}
+
+// Components splits the LMP into energy, congestion and loss, with any
+// remainder (e.g. GHG adders or rounding) in other.
+func (i LMPInterval) Components() (energy, congestion, loss, other float64) {
+	return i.Energy, i.Congestion, i.Loss, i.LMP - i.Energy - i.Congestion - i.Loss
 }
 
 func (i LMPInterval) Duration() time.Duration {
@@ -45,4 +65,3 @@ func (i LMPInterval) Duration() time.Duration {
 func (i LMPInterval) DurationHours() float64 {
 	return i.Duration().Hours()
 }
-
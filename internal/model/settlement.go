@@ -0,0 +1,85 @@
+package model
+
+import "errors"
+
+// SettlementParams is the cost stack between the LMP and the invoice.
+//
+// Per-MWh fees are charged in every interval (and therefore seen by the
+// optimizers). Monthly per-MW charges depend on the whole month and are
+// applied by the backtest engine on the last interval of each local month.
+type SettlementParams struct {
+	// ChargingFeePerMWh is charged on energy drawn to charge (e.g. transmission
+	// access or wheeling charges).
+	ChargingFeePerMWh float64
+	// ThroughputFeePerMWh is charged on charge + discharge energy (e.g. ISO
+	// grid management charges).
+	ThroughputFeePerMWh float64
+
+	// DemandChargePerMWMonth is charged on the peak grid draw of the charging
+	// meter (charging plus auxiliary load) in each month.
+	DemandChargePerMWMonth float64
+	// FixedChargePerMWMonth is charged on PowerCapacityMW, prorated by the
+	// share of each month covered by the backtest.
+	FixedChargePerMWMonth float64
+
+	// PriceFloor/PriceCap bound the price energy settles at (nil = LMP as is),
+	// e.g. an ISO bid floor or offer cap.
+	PriceFloor *float64
+	PriceCap   *float64
+
+	// ScarcityPrice marks scarcity/cap events for reporting: intervals with an
+	// LMP at or above it (0 = PriceCap, if set).
+	ScarcityPrice float64
+
+	// BidCostRecovery pays a daily make-whole uplift when discharge revenue
+	// falls short of its bid cost, BidCostPerMWh per MWh discharged
+	// (0 = DegradationCostPerMWh). It is applied by the backtest engine.
+	BidCostRecovery bool
+	BidCostPerMWh   float64
+}
+
+func (s *SettlementParams) validate() error {
+	if s.ChargingFeePerMWh < 0 || s.ThroughputFeePerMWh < 0 || s.DemandChargePerMWMonth < 0 || s.FixedChargePerMWMonth < 0 {
+		return errors.New("settlement fees and charges must be >= 0")
+	}
+	if s.PriceFloor != nil && s.PriceCap != nil && *s.PriceFloor > *s.PriceCap {
+		return errors.New("settlement PriceFloor must be <= PriceCap")
+	}
+	if s.BidCostPerMWh < 0 {
+		return errors.New("settlement BidCostPerMWh must be >= 0")
+	}
+	return nil
+}
+
+// Price is the price energy settles at for an interval clearing at lmp.
+func (s *SettlementParams) Price(lmp float64) float64 {
+	if s == nil {
+		return lmp
+	}
+	if s.PriceFloor != nil && lmp < *s.PriceFloor {
+		return *s.PriceFloor
+	}
+	if s.PriceCap != nil && lmp > *s.PriceCap {
+		return *s.PriceCap
+	}
+	return lmp
+}
+
+// Scarcity reports whether lmp is a scarcity/cap event.
+func (s *SettlementParams) Scarcity(lmp float64) bool {
+	if s == nil {
+		return false
+	}
+	if s.ScarcityPrice != 0 {
+		return lmp >= s.ScarcityPrice
+	}
+	return s.PriceCap != nil && lmp >= *s.PriceCap
+}
+
+// fees returns the per-MWh charging and throughput fees of an interval.
+func (s *SettlementParams) fees(energyFromGridMWh, energyToGridMWh float64) (charging, throughput float64) {
+	if s == nil {
+		return 0, 0
+	}
+	return s.ChargingFeePerMWh * energyFromGridMWh, s.ThroughputFeePerMWh * (energyFromGridMWh + energyToGridMWh)
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"errors"
+	"math"
+)
+
+// ThermalParams is a lumped (single-node) thermal model of the cells.
+//
+// Cell temperature T evolves as
+//
+//	C dT/dt = losses - G (T - ambient) - cooling
+//
+// where losses are the conversion losses of the interval, G the conductance to
+// ambient and cooling the HVAC heat removal. HVAC holds T at HVACSetpointC as
+// long as its capacity allows; its electric draw (cooling / HVACCOP) is added
+// to the auxiliary load. Power limits and efficiencies are derated with T.
+type ThermalParams struct {
+	HeatCapacityMWhPerC float64 // C: MWh of heat per °C
+	ConductanceMWPerC   float64 // G: MW of heat exchanged per °C above ambient
+
+	HVACSetpointC  float64 // cooling engages above this cell temperature
+	HVACCoolingMW  float64 // max heat removal
+	HVACCOP        float64 // heat removed per MW of electricity (default 3)
+	DefaultAmbient float64 // ambient °C when the interval has no temperature (default 25)
+
+	// Power derate: 1 below DerateStartC, falling linearly to MinDerate at
+	// DerateEndC and beyond.
+	DerateStartC float64
+	DerateEndC   float64
+	MinDerate    float64
+
+	// Efficiency falls by EfficiencyLossPerC (absolute, e.g. 0.001) per °C away
+	// from ReferenceTempC.
+	ReferenceTempC     float64
+	EfficiencyLossPerC float64
+}
+
+func (t *ThermalParams) validate() error {
+	if t.HeatCapacityMWhPerC <= 0 {
+		return errors.New("thermal HeatCapacityMWhPerC must be > 0")
+	}
+	if t.ConductanceMWPerC < 0 || t.HVACCoolingMW < 0 || t.HVACCOP < 0 || t.EfficiencyLossPerC < 0 {
+		return errors.New("thermal conductance, HVAC and efficiency loss must be >= 0")
+	}
+	if t.DerateEndC < t.DerateStartC {
+		return errors.New("thermal DerateEndC must be >= DerateStartC")
+	}
+	if t.MinDerate < 0 || t.MinDerate > 1 {
+		return errors.New("thermal MinDerate must be in [0, 1]")
+	}
+	return nil
+}
+
+func (t *ThermalParams) cop() float64 {
+	if t.HVACCOP > 0 {
+		return t.HVACCOP
+	}
+	return 3
+}
+
+// Ambient returns the interval's ambient temperature or the default.
+func (t *ThermalParams) Ambient(ambientC *float64) float64 {
+	if ambientC != nil {
+		return *ambientC
+	}
+	if t.DefaultAmbient != 0 {
+		return t.DefaultAmbient
+	}
+	return 25
+}
+
+// Derate is the power derate factor (0..1) at cell temperature tempC.
+func (t *ThermalParams) Derate(tempC float64) float64 {
+	if t == nil || t.DerateEndC == 0 || tempC <= t.DerateStartC {
+		return 1
+	}
+	if tempC >= t.DerateEndC || t.DerateEndC == t.DerateStartC {
+		return t.MinDerate
+	}
+	f := (tempC - t.DerateStartC) / (t.DerateEndC - t.DerateStartC)
+	return 1 - f*(1-t.MinDerate)
+}
+
+// efficiencyFactor scales an efficiency at cell temperature tempC.
+func (t *ThermalParams) efficiencyFactor(eff, tempC float64) float64 {
+	if t == nil || t.EfficiencyLossPerC == 0 {
+		return eff
+	}
+	return math.Max(0.01, eff-t.EfficiencyLossPerC*math.Abs(tempC-t.ReferenceTempC))
+}
+
+// SteadyTempC is the idle equilibrium cell temperature at ambient: ambient,
+// pulled down towards the HVAC setpoint as far as cooling capacity allows.
+func (t *ThermalParams) SteadyTempC(ambientC float64) float64 {
+	if ambientC <= t.HVACSetpointC || t.HVACCoolingMW == 0 {
+		return ambientC
+	}
+	if t.ConductanceMWPerC == 0 {
+		return t.HVACSetpointC
+	}
+	return math.Max(t.HVACSetpointC, ambientC-t.HVACCoolingMW/t.ConductanceMWPerC)
+}
+
+// step advances the cell temperature over dtH hours given conversion losses
+// (MWh of heat). It returns the new temperature and HVAC electric energy (MWh).
+func (t *ThermalParams) step(tempC, ambientC, lossesMWh, dtH float64) (float64, float64) {
+	c := t.HeatCapacityMWhPerC
+	// Exact decay towards ambient, then losses.
+	decay := math.Exp(-t.ConductanceMWPerC * dtH / c)
+	next := ambientC + (tempC-ambientC)*decay + lossesMWh/c
+
+	cooledMWh := 0.0
+	if t.HVACCoolingMW > 0 && next > t.HVACSetpointC {
+		cooledMWh = math.Min(t.HVACCoolingMW*dtH, (next-t.HVACSetpointC)*c)
+		next -= cooledMWh / c
+	}
+	return next, cooledMWh / t.cop()
+}
@@ -0,0 +1,75 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+
+	"battery-backtest/internal/model"
+)
+
+// BidCurveStrategy submits the same bid/offer curve every interval, e.g.
+// "buy 50 MW at or below $20, sell 25 MW at $80 and another 25 MW at $150".
+// Physical limits (power, SOC) are enforced by the battery after clearing.
+type BidCurveStrategy struct {
+	Curve model.BidCurve
+}
+
+// NewBidCurveStrategy validates the curve.
+func NewBidCurveStrategy(segments []model.BidSegment) (*BidCurveStrategy, error) {
+	if len(segments) == 0 {
+		return nil, errors.New("bidcurve requires at least one segment")
+	}
+	for i, s := range segments {
+		if s.PowerMW == 0 {
+			return nil, fmt.Errorf("segment %d has zero MW", i)
+		}
+	}
+	return &BidCurveStrategy{Curve: model.BidCurve{Segments: segments}}, nil
+}
+
+func (s *BidCurveStrategy) Name() string { return "bidcurve" }
+
+func (s *BidCurveStrategy) Bid(ctx Context) model.BidCurve {
+	return s.Curve
+}
+
+func (s *BidCurveStrategy) Decide(ctx Context) model.Dispatch {
+	return model.Dispatch{PowerMW: s.Bid(ctx).Clear(ctx.Interval.LMP)}
+}
+
+// BidSegmentsFromParams parses a params list of {power_mw, price} maps as
+// decoded from YAML or JSON.
+func BidSegmentsFromParams(raw any) ([]model.BidSegment, error) {
+	if raw == nil {
+		return nil, errors.New("segments is required")
+	}
+	list, ok := raw.([]any)
+	if !ok {
+		return nil, errors.New("segments must be a list of {power_mw, price}")
+	}
+	out := make([]model.BidSegment, 0, len(list))
+	for i, item := range list {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("segment %d must be a map with power_mw and price", i)
+		}
+		mw, okMW := toFloat(m["power_mw"])
+		price, okPrice := toFloat(m["price"])
+		if !okMW || !okPrice {
+			return nil, fmt.Errorf("segment %d must have numeric power_mw and price", i)
+		}
+		out = append(out, model.BidSegment{PowerMW: mw, PriceUSDPerMWh: price})
+	}
+	return out, nil
+}
+
+func toFloat(v any) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case int:
+		return float64(x), true
+	default:
+		return 0, false
+	}
+}
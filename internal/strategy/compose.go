@@ -0,0 +1,310 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"battery-backtest/internal/model"
+)
+
+// Condition is a predicate over an interval and the battery state.
+// Unset fields match everything; all set fields must match.
+//
+// Calendar fields (Months, Weekdays, Hours) are evaluated on interval_start_local.
+type Condition struct {
+	LMPAbove *float64
+	LMPBelow *float64
+	SOCAbove *float64
+	SOCBelow *float64
+
+	Months   []time.Month
+	Weekdays []time.Weekday
+	Hours    []int
+}
+
+// Matches reports whether ctx satisfies every set field.
+func (c Condition) Matches(ctx Context) bool {
+	lmp := ctx.Interval.LMP
+	if c.LMPAbove != nil && !(lmp > *c.LMPAbove) {
+		return false
+	}
+	if c.LMPBelow != nil && !(lmp < *c.LMPBelow) {
+		return false
+	}
+	if c.SOCAbove != nil || c.SOCBelow != nil {
+		if ctx.Battery == nil {
+			return false
+		}
+		soc := ctx.Battery.State.SOC
+		if c.SOCAbove != nil && !(soc > *c.SOCAbove) {
+			return false
+		}
+		if c.SOCBelow != nil && !(soc < *c.SOCBelow) {
+			return false
+		}
+	}
+	t := ctx.Interval.IntervalStartLocal
+	if len(c.Months) > 0 && !containsMonth(c.Months, t.Month()) {
+		return false
+	}
+	if len(c.Weekdays) > 0 && !containsWeekday(c.Weekdays, t.Weekday()) {
+		return false
+	}
+	if len(c.Hours) > 0 && !containsInt(c.Hours, t.Hour()) {
+		return false
+	}
+	return true
+}
+
+// IsCalendarOnly reports whether the condition uses only calendar fields.
+func (c Condition) IsCalendarOnly() bool {
+	return c.LMPAbove == nil && c.LMPBelow == nil && c.SOCAbove == nil && c.SOCBelow == nil
+}
+
+// Layer is one entry of an overlay: a strategy gated by an optional condition.
+// A nil When always matches, which makes the layer a fallback.
+type Layer struct {
+	When     *Condition
+	Strategy Strategy
+}
+
+// OverlayStrategy evaluates layers in priority order and uses the first layer
+// whose condition matches. Put overrides first and the base strategy last.
+//
+// Every child is asked to Decide on every interval, even when it is not selected,
+// so stateful children (rolling features, learned policies) see the full series.
+type OverlayStrategy struct {
+	name   string
+	Layers []Layer
+}
+
+// NewOverlayStrategy builds a priority overlay ("overlay").
+func NewOverlayStrategy(layers []Layer) (*OverlayStrategy, error) {
+	if len(layers) == 0 {
+		return nil, errors.New("overlay requires at least one child")
+	}
+	for i, l := range layers {
+		if l.Strategy == nil {
+			return nil, fmt.Errorf("overlay child %d is nil", i)
+		}
+	}
+	return &OverlayStrategy{name: "overlay", Layers: layers}, nil
+}
+
+// NewCalendarStrategy builds a switch-by-calendar ("calendar"): like an overlay,
+// but conditions may only use months, weekdays and hours.
+func NewCalendarStrategy(layers []Layer) (*OverlayStrategy, error) {
+	s, err := NewOverlayStrategy(layers)
+	if err != nil {
+		return nil, err
+	}
+	for i, l := range layers {
+		if l.When != nil && !l.When.IsCalendarOnly() {
+			return nil, fmt.Errorf("calendar child %d: only months/weekdays/hours conditions are allowed", i)
+		}
+	}
+	s.name = "calendar"
+	return s, nil
+}
+
+func (s *OverlayStrategy) Name() string { return s.name }
+
+func (s *OverlayStrategy) Decide(ctx Context) model.Dispatch {
+	out := model.Dispatch{PowerMW: 0}
+	selected := false
+	for _, l := range s.Layers {
+		d := l.Strategy.Decide(ctx)
+		if !selected && (l.When == nil || l.When.Matches(ctx)) {
+			out = d
+			selected = true
+		}
+	}
+	return out
+}
+
+func (s *OverlayStrategy) Close() error {
+	children := make([]Strategy, len(s.Layers))
+	for i, l := range s.Layers {
+		children[i] = l.Strategy
+	}
+	return CloseAll(children)
+}
+
+func (s *OverlayStrategy) Err() error {
+	children := make([]Strategy, len(s.Layers))
+	for i, l := range s.Layers {
+		children[i] = l.Strategy
+	}
+	return firstErr(children)
+}
+
+// Direction names which side of the dispatch a guard forbids.
+type Direction string
+
+const (
+	DirectionCharge    Direction = "charge"
+	DirectionDischarge Direction = "discharge"
+	DirectionBoth      Direction = "both"
+)
+
+// GuardStrategy vetoes its inner strategy: when the condition matches, requests
+// in the forbidden direction are replaced by idle.
+// Example: never charge when LMP > $100.
+type GuardStrategy struct {
+	Inner  Strategy
+	When   Condition
+	Forbid Direction
+}
+
+// NewGuardStrategy builds a guard ("guard").
+func NewGuardStrategy(inner Strategy, when Condition, forbid Direction) (*GuardStrategy, error) {
+	if inner == nil {
+		return nil, errors.New("guard requires exactly one child")
+	}
+	switch forbid {
+	case DirectionCharge, DirectionDischarge, DirectionBoth:
+	default:
+		return nil, fmt.Errorf("guard forbid must be charge, discharge or both (got %q)", forbid)
+	}
+	return &GuardStrategy{Inner: inner, When: when, Forbid: forbid}, nil
+}
+
+func (s *GuardStrategy) Name() string { return "guard" }
+
+func (s *GuardStrategy) Decide(ctx Context) model.Dispatch {
+	d := s.Inner.Decide(ctx)
+	if !s.When.Matches(ctx) {
+		return d
+	}
+	switch {
+	case d.PowerMW < 0 && (s.Forbid == DirectionCharge || s.Forbid == DirectionBoth):
+		return model.Dispatch{PowerMW: 0}
+	case d.PowerMW > 0 && (s.Forbid == DirectionDischarge || s.Forbid == DirectionBoth):
+		return model.Dispatch{PowerMW: 0}
+	}
+	return d
+}
+
+func (s *GuardStrategy) Close() error { return CloseAll([]Strategy{s.Inner}) }
+
+func (s *GuardStrategy) Err() error { return firstErr([]Strategy{s.Inner}) }
+
+// BlendStrategy requests the weighted sum of its children's requests.
+// Weights are normalized to sum to 1, so equal weights give the average.
+type BlendStrategy struct {
+	Children []Strategy
+	Weights  []float64
+}
+
+// NewBlendStrategy builds a weighted blend ("blend"). A nil weights slice means
+// equal weights.
+func NewBlendStrategy(children []Strategy, weights []float64) (*BlendStrategy, error) {
+	if len(children) == 0 {
+		return nil, errors.New("blend requires at least one child")
+	}
+	if weights == nil {
+		weights = make([]float64, len(children))
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	if len(weights) != len(children) {
+		return nil, fmt.Errorf("blend has %d children but %d weights", len(children), len(weights))
+	}
+	sum := 0.0
+	for _, w := range weights {
+		if w < 0 || math.IsNaN(w) {
+			return nil, errors.New("blend weights must be >= 0")
+		}
+		sum += w
+	}
+	if sum == 0 {
+		return nil, errors.New("blend weights must not all be 0")
+	}
+	norm := make([]float64, len(weights))
+	for i, w := range weights {
+		norm[i] = w / sum
+	}
+	return &BlendStrategy{Children: children, Weights: norm}, nil
+}
+
+func (s *BlendStrategy) Name() string { return "blend" }
+
+func (s *BlendStrategy) Decide(ctx Context) model.Dispatch {
+	p := 0.0
+	for i, child := range s.Children {
+		p += s.Weights[i] * child.Decide(ctx).PowerMW
+	}
+	return model.Dispatch{PowerMW: p}
+}
+
+func (s *BlendStrategy) Close() error { return CloseAll(s.Children) }
+
+func (s *BlendStrategy) Err() error { return firstErr(s.Children) }
+
+// ConstantStrategy always requests the same power. It is mainly a building block
+// for overlays, e.g. "discharge at full power when LMP > $500".
+type ConstantStrategy struct {
+	PowerMW float64
+}
+
+func (s *ConstantStrategy) Name() string { return "constant" }
+
+func (s *ConstantStrategy) Decide(ctx Context) model.Dispatch {
+	return model.Dispatch{PowerMW: s.PowerMW}
+}
+
+// CloseAll closes every strategy that holds resources (e.g. WASM runtimes).
+func CloseAll(children []Strategy) error {
+	var errs []error
+	for _, c := range children {
+		if closer, ok := c.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// firstErr returns the first error reported by a failing child (see Failer).
+func firstErr(children []Strategy) error {
+	for _, c := range children {
+		if f, ok := c.(Failer); ok {
+			if err := f.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func containsMonth(xs []time.Month, m time.Month) bool {
+	for _, x := range xs {
+		if x == m {
+			return true
+		}
+	}
+	return false
+}
+
+func containsWeekday(xs []time.Weekday, d time.Weekday) bool {
+	for _, x := range xs {
+		if x == d {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(xs []int, v int) bool {
+	for _, x := range xs {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
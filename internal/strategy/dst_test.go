@@ -0,0 +1,151 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"battery-backtest/internal/model"
+)
+
+// dstDays are the 2024 Pacific DST transitions: spring forward (02:00-03:00
+// does not exist) and fall back (01:00-02:00 happens twice).
+var dstDays = []struct {
+	name      string
+	date      string
+	intervals int // five-minute intervals in the local day
+}{
+	{"spring forward", "2024-03-10", 276},
+	{"fall back", "2024-11-03", 300},
+}
+
+// laDays returns five-minute intervals covering days local days in
+// America/Los_Angeles from date, with local timestamps in that zone.
+func laDays(t *testing.T, date string, days int) []model.LMPInterval {
+	t.Helper()
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, err := time.ParseInLocation("2006-01-02", date, la)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []model.LMPInterval
+	for u := start.UTC(); u.Before(start.AddDate(0, 0, days)); u = u.Add(5 * time.Minute) {
+		end := u.Add(5 * time.Minute)
+		out = append(out, model.LMPInterval{
+			IntervalStartUTC:   u,
+			IntervalEndUTC:     end,
+			IntervalStartLocal: u.In(la),
+			IntervalEndLocal:   end.In(la),
+			LMP:                30,
+		})
+	}
+	return out
+}
+
+func TestSplitByDayDST(t *testing.T) {
+	for _, tc := range dstDays {
+		t.Run(tc.name, func(t *testing.T) {
+			// The day before, the DST day and the day after.
+			prev, _ := time.Parse("2006-01-02", tc.date)
+			intervals := laDays(t, prev.AddDate(0, 0, -1).Format("2006-01-02"), 3)
+
+			days := splitByDay(intervals)
+			if len(days) != 3 {
+				t.Fatalf("got %d days, want 3", len(days))
+			}
+			for i, want := range []int{288, tc.intervals, 288} {
+				if len(days[i]) != want {
+					t.Errorf("day %d (%s) has %d intervals, want %d", i, days[i][0].IntervalStartLocal.Format("2006-01-02"), len(days[i]), want)
+				}
+			}
+			if got := days[1][0].IntervalStartLocal.Format("2006-01-02 15:04"); got != tc.date+" 00:00" {
+				t.Errorf("DST day starts at %s, want local midnight", got)
+			}
+		})
+	}
+}
+
+func TestSameDateDST(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		a, b time.Time
+		want bool
+	}{
+		{
+			"spring forward, before and after the gap",
+			time.Date(2024, 3, 10, 1, 55, 0, 0, la),
+			time.Date(2024, 3, 10, 23, 55, 0, 0, la),
+			true,
+		},
+		{
+			"fall back, last interval vs next midnight",
+			time.Date(2024, 11, 3, 23, 55, 0, 0, la),
+			time.Date(2024, 11, 4, 0, 0, 0, 0, la),
+			false,
+		},
+		{
+			// 2024-11-03 08:30 UTC is 01:30 PDT, 09:30 UTC is 01:30 PST.
+			"fall back, repeated hour",
+			time.Date(2024, 11, 3, 8, 30, 0, 0, time.UTC).In(la),
+			time.Date(2024, 11, 3, 9, 30, 0, 0, time.UTC).In(la),
+			true,
+		},
+		{
+			// Same instant, different local dates: dates are compared in
+			// each time's own location.
+			"local vs UTC",
+			time.Date(2024, 3, 10, 20, 0, 0, 0, la),
+			time.Date(2024, 3, 10, 20, 0, 0, 0, la).UTC(),
+			false,
+		},
+	}
+	for _, tc := range tests {
+		if got := sameDate(tc.a, tc.b); got != tc.want {
+			t.Errorf("%s: sameDate(%s, %s) = %v, want %v", tc.name, tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestScheduleDST(t *testing.T) {
+	// Charge 01:00-04:00 spans the DST change; discharge 17:00-21:00 does not.
+	params := ScheduleParams{
+		ChargeStart:      "01:00",
+		ChargeEnd:        "04:00",
+		DischargeStart:   "17:00",
+		DischargeEnd:     "21:00",
+		ChargePowerMW:    10,
+		DischargePowerMW: 10,
+	}
+	tests := []struct {
+		name                string
+		date                string
+		charging, discharge int
+	}{
+		{"spring forward", "2024-03-10", 2 * 12, 4 * 12}, // 02:00-03:00 is skipped
+		{"fall back", "2024-11-03", 4 * 12, 4 * 12},      // 01:00-02:00 runs twice
+		{"ordinary day", "2024-03-11", 3 * 12, 4 * 12},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &ScheduleStrategy{Params: params}
+			var charging, discharging int
+			for i, it := range laDays(t, tc.date, 1) {
+				switch p := s.Decide(Context{Index: i, Interval: it}).PowerMW; {
+				case p < 0:
+					charging++
+				case p > 0:
+					discharging++
+				}
+			}
+			if charging != tc.charging || discharging != tc.discharge {
+				t.Errorf("charging %d, discharging %d intervals; want %d, %d", charging, discharging, tc.charging, tc.discharge)
+			}
+		})
+	}
+}
@@ -29,6 +29,11 @@ type OracleParams struct {
 	// PowerSteps controls action discretization between [-Pmax, +Pmax].
 	// Higher = more accurate, slower.
 	PowerSteps int
+
+	// CarbonPricePerTCO2 weights emissions against profit: the plan maximizes
+	// profit - CarbonPricePerTCO2 * net tCO2, using each interval's
+	// EmissionsRate (0 = profit only).
+	CarbonPricePerTCO2 float64
 }
 
 func NewOracleStrategy(intervals []model.LMPInterval, params model.BatteryParams, initialSOC float64, cfg OracleParams) (*OracleStrategy, error) {
@@ -44,7 +49,7 @@ func NewOracleStrategy(intervals []model.LMPInterval, params model.BatteryParams
 
 	// Group intervals by day and optimize each day independently
 	// This maximizes profit per day rather than across the entire period
-	plan, err := optimizeDPByDay(intervals, params, initialSOC, cfg.SocSteps, cfg.PowerSteps)
+	plan, err := optimizeDPByDay(intervals, params, initialSOC, cfg.SocSteps, cfg.PowerSteps, cfg.CarbonPricePerTCO2)
 	if err != nil {
 		return nil, err
 	}
@@ -62,52 +67,58 @@ func (s *OracleStrategy) Decide(ctx Context) model.Dispatch {
 
 // optimizeDPByDay groups intervals by day and optimizes each day independently.
 // This maximizes profit per day, starting from initialSOC at the start of each day.
-// Since intervals are already sorted chronologically, we can group them in a single pass.
-func optimizeDPByDay(intervals []model.LMPInterval, p model.BatteryParams, initialSOC float64, socSteps int, powerSteps int) ([]model.Dispatch, error) {
+//
+// Warranty cycle caps are handled as a resource constraint by Lagrangian
+// relaxation: discharged MWh are charged a shadow price, found by bisection, so
+// that each day stays within MaxCyclesPerDay and each calendar year within
+// MaxCyclesPerYear. The annual price couples the days of a year, so days are
+// re-solved together when the annual cap binds.
+func optimizeDPByDay(intervals []model.LMPInterval, p model.BatteryParams, initialSOC float64, socSteps int, powerSteps int, carbonPrice float64) ([]model.Dispatch, error) {
 	if len(intervals) == 0 {
 		return nil, fmt.Errorf("no intervals")
 	}
 
-	// Group intervals by day (intervals are already sorted chronologically)
-	var dayIntervals []model.LMPInterval
-	var fullPlan []model.Dispatch
-	var currentDay time.Time
-
-	for i, interval := range intervals {
-		intervalDay := time.Date(
-			interval.IntervalStartLocal.Year(),
-			interval.IntervalStartLocal.Month(),
-			interval.IntervalStartLocal.Day(),
-			0, 0, 0, 0,
-			interval.IntervalStartLocal.Location(),
-		)
-
-		// If this is a new day (or the first interval), optimize the previous day
-		if i > 0 && !intervalDay.Equal(currentDay) {
-			// Optimize the previous day
-			dayPlan, err := optimizeDP(dayIntervals, p, initialSOC, socSteps, powerSteps)
+	// solveDay solves one day at an annual shadow price, adding a daily one if needed.
+	solveDay := func(day []model.LMPInterval, yearPrice float64) ([]model.Dispatch, float64, error) {
+		solve := func(price float64) ([]model.Dispatch, float64, error) {
+			plan, err := optimizeDP(day, p, initialSOC, socSteps, powerSteps, yearPrice+price, carbonPrice)
 			if err != nil {
-				return nil, fmt.Errorf("error optimizing day %s: %w", currentDay.Format("2006-01-02"), err)
+				return nil, 0, fmt.Errorf("error optimizing day %s: %w", day[0].IntervalStartLocal.Format("2006-01-02"), err)
 			}
-			fullPlan = append(fullPlan, dayPlan...)
-			dayIntervals = dayIntervals[:0] // Reset for new day
+			return plan, dischargeMWh(day, plan), nil
 		}
-
-		// Start tracking a new day
-		if len(dayIntervals) == 0 {
-			currentDay = intervalDay
+		if p.MaxCyclesPerDay <= 0 {
+			return solve(0)
 		}
-
-		dayIntervals = append(dayIntervals, interval)
+		return withinCap(p.CycleCapMWh(p.MaxCyclesPerDay), solve)
 	}
 
-	// Optimize the last day
-	if len(dayIntervals) > 0 {
-		dayPlan, err := optimizeDP(dayIntervals, p, initialSOC, socSteps, powerSteps)
+	var fullPlan []model.Dispatch
+	for _, year := range splitByYear(splitByDay(intervals)) {
+		solveYear := func(price float64) ([]model.Dispatch, float64, error) {
+			var plan []model.Dispatch
+			total := 0.0
+			for _, day := range year {
+				dayPlan, mwh, err := solveDay(day, price)
+				if err != nil {
+					return nil, 0, err
+				}
+				plan = append(plan, dayPlan...)
+				total += mwh
+			}
+			return plan, total, nil
+		}
+		var plan []model.Dispatch
+		var err error
+		if p.MaxCyclesPerYear > 0 {
+			plan, _, err = withinCap(p.CycleCapMWh(p.MaxCyclesPerYear), solveYear)
+		} else {
+			plan, _, err = solveYear(0)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("error optimizing day %s: %w", currentDay.Format("2006-01-02"), err)
+			return nil, err
 		}
-		fullPlan = append(fullPlan, dayPlan...)
+		fullPlan = append(fullPlan, plan...)
 	}
 
 	// Validate that plan length matches intervals length
@@ -118,12 +129,106 @@ func optimizeDPByDay(intervals []model.LMPInterval, p model.BatteryParams, initi
 	return fullPlan, nil
 }
 
-func optimizeDP(intervals []model.LMPInterval, p model.BatteryParams, initialSOC float64, socSteps int, powerSteps int) ([]model.Dispatch, error) {
+// withinCap returns solve's plan at the smallest shadow price ($/MWh
+// discharged) whose discharge fits within capMWh.
+func withinCap(capMWh float64, solve func(price float64) ([]model.Dispatch, float64, error)) ([]model.Dispatch, float64, error) {
+	plan, mwh, err := solve(0)
+	if err != nil || mwh <= capMWh+1e-9 {
+		return plan, mwh, err
+	}
+	lo, hi := 0.0, 1.0
+	for {
+		plan, mwh, err = solve(hi)
+		if err != nil {
+			return nil, 0, err
+		}
+		if mwh <= capMWh+1e-9 || hi > 1e7 {
+			break
+		}
+		lo, hi = hi, hi*4
+	}
+	for i := 0; i < 10; i++ {
+		mid := (lo + hi) / 2
+		midPlan, midMWh, err := solve(mid)
+		if err != nil {
+			return nil, 0, err
+		}
+		if midMWh <= capMWh+1e-9 {
+			plan, mwh, hi = midPlan, midMWh, mid
+		} else {
+			lo = mid
+		}
+	}
+	return plan, mwh, nil
+}
+
+// dischargeMWh is the grid-side discharge of a plan of realized powers.
+func dischargeMWh(day []model.LMPInterval, plan []model.Dispatch) float64 {
+	total := 0.0
+	for t, it := range day {
+		if plan[t].PowerMW > 0 {
+			total += plan[t].PowerMW * it.DurationHours()
+		}
+	}
+	return total
+}
+
+// splitByYear groups consecutive days by local calendar year.
+func splitByYear(days [][]model.LMPInterval) [][][]model.LMPInterval {
+	var years [][][]model.LMPInterval
+	for i, day := range days {
+		if i == 0 || day[0].IntervalStartLocal.Year() != days[i-1][0].IntervalStartLocal.Year() {
+			years = append(years, nil)
+		}
+		years[len(years)-1] = append(years[len(years)-1], day)
+	}
+	return years
+}
+
+// splitByDay groups chronologically sorted intervals into consecutive local days.
+// Days are compared by calendar date, not instant, so a DST change (which
+// shifts the UTC offset mid-day) does not split a day.
+// The returned slices alias the input.
+func splitByDay(intervals []model.LMPInterval) [][]model.LMPInterval {
+	var days [][]model.LMPInterval
+	start := 0
+	for i := 1; i < len(intervals); i++ {
+		if !sameDate(intervals[i].IntervalStartLocal, intervals[start].IntervalStartLocal) {
+			days = append(days, intervals[start:i])
+			start = i
+		}
+	}
+	if start < len(intervals) {
+		days = append(days, intervals[start:])
+	}
+	return days
+}
+
+// sameDate reports whether a and b fall on the same calendar date in their own
+// locations.
+func sameDate(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// maxRampPowerSteps bounds the action grid refined for a ramp limit; finer
+// grids make the (SOC, previous power) state too large to solve.
+const maxRampPowerSteps = 50
+
+// optimizeDP solves one day by backward induction over a discretized state:
+// (SOC, previous power, mode age). The last two dimensions collapse to a single
+// value unless ramp limits, minimum mode time or switch costs are set.
+// cyclePrice is a shadow price charged per MWh discharged (0 = none).
+// carbonPrice ($/tCO2) is charged on the interval's net emissions, rate *
+// (grid draw - delivered energy), apart from the settlement price so price
+// floors and caps do not clamp it.
+func optimizeDP(intervals []model.LMPInterval, p model.BatteryParams, initialSOC float64, socSteps int, powerSteps int, cyclePrice, carbonPrice float64) ([]model.Dispatch, error) {
 	// SOC grid is [MinSOC, MaxSOC] in socSteps increments.
 	if socSteps < 2 {
 		socSteps = 2
 	}
-	nStates := socSteps + 1
+	nSOC := socSteps + 1
 
 	socToIdx := func(soc float64) int {
 		if soc <= p.MinSOC {
@@ -146,187 +251,224 @@ func optimizeDP(intervals []model.LMPInterval, p model.BatteryParams, initialSOC
 		return p.MinSOC + f*(p.MaxSOC-p.MinSOC)
 	}
 
-	negInf := -1e100
-	dp := make([]float64, nStates)
-	next := make([]float64, nStates)
-	for i := range dp {
-		dp[i] = negInf
+	// Action set: [-Pcharge .. +Pdischarge] in steps of each direction's rating.
+	// Under a ramp limit the grid is refined so one interval's ramp spans at
+	// least one step.
+	dtH0 := intervals[0].DurationHours()
+	chargeSteps, dischargeSteps := powerSteps, powerSteps
+	if ramp := p.RampRateMWPerMin * dtH0 * 60; ramp > 0 {
+		chargeSteps = max(chargeSteps, int(math.Ceil(p.ChargeRatingMW()/ramp-1e-9)))
+		dischargeSteps = max(dischargeSteps, int(math.Ceil(p.DischargeRatingMW()/ramp-1e-9)))
+		if max(chargeSteps, dischargeSteps) > maxRampPowerSteps {
+			return nil, fmt.Errorf("ramp rate %g MW/min needs more than %d power steps per direction", p.RampRateMWPerMin, maxRampPowerSteps)
+		}
+	}
+	actions := make([]float64, 0, chargeSteps+dischargeSteps+1)
+	for k := -chargeSteps; k < 0; k++ {
+		actions = append(actions, float64(k)*p.ChargeRatingMW()/float64(chargeSteps))
+	}
+	for k := 0; k <= dischargeSteps; k++ {
+		actions = append(actions, float64(k)*p.DischargeRatingMW()/float64(dischargeSteps))
+	}
+	nActions := len(actions)
+
+	// Previous-power dimension. Ramp limits depend on the previous power itself
+	// (a level of the action grid); minimum mode time and switch costs only on
+	// its sign.
+	prevLevels := []float64{0}
+	signOnly := false
+	switch {
+	case p.RampRateMWPerMin > 0:
+		prevLevels = actions
+	case p.MinModeMinutes > 0 || p.ModeSwitchCostUSD > 0:
+		prevLevels = []float64{-p.ChargeRatingMW(), 0, p.DischargeRatingMW()}
+		signOnly = true
+	}
+	nPrev := len(prevLevels)
+	prevToIdx := func(power float64) int {
+		switch {
+		case nPrev == 1:
+			return 0
+		case signOnly && power < 0:
+			return 0
+		case signOnly && power > 0:
+			return 2
+		case signOnly:
+			return 1
+		}
+		// Nearest point of the action grid.
+		if power < 0 {
+			return chargeSteps + clampInt(int(math.Round(power/p.ChargeRatingMW()*float64(chargeSteps))), -chargeSteps, 0)
+		}
+		return chargeSteps + clampInt(int(math.Round(power/p.DischargeRatingMW()*float64(dischargeSteps))), 0, dischargeSteps)
 	}
-	initIdx := socToIdx(initialSOC)
-	dp[initIdx] = 0
 
-	// Backpointers:
-	choice := make([][]int, len(intervals)) // chosen next-state index
-	powerChosen := make([][]float64, len(intervals))
-	for t := range intervals {
-		choice[t] = make([]int, nStates)
-		powerChosen[t] = make([]float64, nStates)
-		for s := 0; s < nStates; s++ {
-			choice[t][s] = -1
-			powerChosen[t][s] = 0
+	// Mode-age dimension: whole intervals the current mode has been held, while
+	// that is still below MinModeMinutes. Age 0 means unconstrained.
+	nAge := 1
+	dtMin := dtH0 * 60
+	if p.MinModeMinutes > 0 && dtMin > 0 {
+		nAge = int(math.Ceil(p.MinModeMinutes/dtMin)) + 1
+	}
+	ageToIdx := func(minutes float64) int {
+		if nAge == 1 || minutes <= 0 || minutes >= p.MinModeMinutes {
+			return 0
 		}
+		return clampInt(int(math.Round(minutes/dtMin)), 1, nAge-1)
 	}
 
-	// Action set: [-Pmax .. +Pmax] in steps.
-	pmax := p.PowerCapacityMW
-	step := pmax / float64(powerSteps)
-	actions := make([]float64, 0, 2*powerSteps+1)
-	for k := -powerSteps; k <= powerSteps; k++ {
-		actions = append(actions, float64(k)*step)
+	nStates := nSOC * nPrev * nAge
+	stateIdx := func(st model.BatteryState) int {
+		return (socToIdx(st.SOC)*nPrev+prevToIdx(st.PowerMW))*nAge + ageToIdx(st.ModeMinutes)
+	}
+	idxToState := func(idx int) model.BatteryState {
+		age := idx % nAge
+		prev := (idx / nAge) % nPrev
+		return model.BatteryState{
+			SOC:         idxToSoc(idx / nAge / nPrev),
+			PowerMW:     prevLevels[prev],
+			ModeMinutes: float64(age) * dtMin,
+		}
 	}
 
-	for t, it := range intervals {
-		for i := range next {
-			next[i] = negInf
+	// candidates[prev][held] lists the indexes of the actions that Constrain
+	// leaves unchanged from a previous power level: within its ramp, and in
+	// its mode while MinModeMinutes holds it. Only grid points qualify, so the
+	// next state's power is exactly a level of the grid.
+	var candidates [][2][]int
+	candidatesDtH := 0.0
+	buildCandidates := func(dtH float64) {
+		candidates = make([][2][]int, nPrev)
+		maxDelta := math.Inf(1)
+		if p.RampRateMWPerMin > 0 {
+			maxDelta = p.RampRateMWPerMin*dtH*60 + 1e-9
 		}
+		for prev, level := range prevLevels {
+			for held := 0; held < 2; held++ {
+				for k, v := range actions {
+					if math.Abs(v-level) <= maxDelta && (held == 0 || modeIdx(v) == modeIdx(level)) {
+						candidates[prev][held] = append(candidates[prev][held], k)
+					}
+				}
+			}
+		}
+		candidatesDtH = dtH
+	}
+
+	// Since candidates pass Constrain unchanged, an interval's outcome depends
+	// only on the SOC, the action and the previous mode (for switch costs).
+	// step memoizes it per interval, simulated without ramp or mode limits.
+	type step struct {
+		done     bool
+		gain     float64 // PnL net of the cycle shadow price and carbon cost
+		nextSOC  int
+		nextPrev int
+		mode     int
+	}
+	free := p
+	free.RampRateMWPerMin, free.MinModeMinutes = 0, 0
+	memo := make([]step, nSOC*nActions*3)
+	// nextAge[age][same] is the next age index, same = the realized power
+	// keeps the previous mode.
+	nextAge := make([][2]int, nAge)
+
+	// Backward induction: value[s] is the best profit from t to the end of the day.
+	value := make([]float64, nStates)
+	nextValue := make([]float64, nStates)
+	choice := make([][]uint16, len(intervals)) // desired action index per state
+	for t := len(intervals) - 1; t >= 0; t-- {
+		it := intervals[t]
 		dtH := it.DurationHours()
 		if dtH <= 0 {
 			return nil, fmt.Errorf("non-positive dt at t=%d", t)
 		}
-
-		for sIdx := 0; sIdx < nStates; sIdx++ {
-			if dp[sIdx] <= negInf/2 {
-				continue
+		if dtH != candidatesDtH {
+			buildCandidates(dtH)
+		}
+		for age := range nextAge {
+			st := model.BatteryState{PowerMW: 1, ModeMinutes: float64(age) * dtMin}
+			nextAge[age] = [2]int{ageToIdx(st.NextModeMinutes(-1, dtH)), ageToIdx(st.NextModeMinutes(1, dtH))}
+		}
+		carbonRate := 0.0 // $/MWh of net grid energy
+		if it.EmissionsRate != nil {
+			carbonRate = carbonPrice * *it.EmissionsRate
+		}
+		value, nextValue = nextValue, value
+		choice[t] = make([]uint16, nStates)
+		clear(memo)
+		for s := 0; s < nStates; s++ {
+			age := s % nAge
+			prev := (s / nAge) % nPrev
+			socIdx := s / nAge / nPrev
+			held := 0
+			if age > 0 {
+				held = 1
 			}
-			soc := idxToSoc(sIdx)
-
-			// Always consider all actions, including idle (power = 0)
-			// Track the best action from this state to ensure we always have a choice
-			bestNextState := sIdx // Default to staying in same state (idle)
-			bestValue := dp[sIdx] // At minimum, idle maintains current value
-			bestPower := 0.0      // Idle power
-
-			for _, desiredPower := range actions {
-				nsoc, realizedPower, pnl := simulateInterval(soc, desiredPower, it.LMP, dtH, p)
-				ns := socToIdx(nsoc)
-				v := dp[sIdx] + pnl
-				// Update next state value if this is better
-				if v > next[ns] {
-					next[ns] = v
+			mode := modeIdx(prevLevels[prev])
+			best := math.Inf(-1)
+			for _, k := range candidates[prev][held] {
+				m := &memo[(socIdx*nActions+k)*3+mode]
+				if !m.done {
+					st := withSteadyTemp(idxToState(s), it, p)
+					res, next := free.Simulate(st, it.LMP, model.Dispatch{PowerMW: actions[k], Outage: it.Unavailable}, dtH)
+					gain := res.PNL - cyclePrice*res.EnergyToGridMWh - carbonRate*(res.GridDrawMW*dtH-res.EnergyToGridMWh)
+					*m = step{done: true, gain: gain, nextSOC: socToIdx(next.SOC), nextPrev: prevToIdx(res.PowerMW), mode: modeIdx(res.PowerMW)}
 				}
-				// Track best action from this state
-				if v > bestValue {
-					bestValue = v
-					bestNextState = ns
-					bestPower = realizedPower
+				same := 0
+				if m.mode == mode {
+					same = 1
+				}
+				v := m.gain + nextValue[(m.nextSOC*nPrev+m.nextPrev)*nAge+nextAge[age][same]]
+				// Prefer the smaller request (idle) on ties.
+				if v > best+1e-9 || (v >= best-1e-9 && math.Abs(actions[k]) < math.Abs(actions[choice[t][s]])) {
+					best = v
+					choice[t][s] = uint16(k)
 				}
 			}
-
-			// Always record the best action from this reachable state
-			// Since we default to idle, bestNextState should always be valid
-			choice[t][sIdx] = bestNextState
-			powerChosen[t][sIdx] = bestPower
-		}
-
-		// Swap dp and next for next iteration
-		dp, next = next, dp
-	}
-
-	// Pick best final state.
-	bestVal := negInf
-	bestState := 0
-	for i, v := range dp {
-		if v > bestVal {
-			bestVal = v
-			bestState = i
+			value[s] = best
 		}
 	}
 
-	// Reconstruct plan.
+	// Forward rollout from the initial state, following the choices of the
+	// nearest grid state but simulating the exact state, so that the plan is
+	// what the engine will realize. The plan holds the realized power when
+	// the engine would accept it as a request unchanged, and otherwise the
+	// constrained request (e.g. a SOC bound cut a ramp short).
 	plan := make([]model.Dispatch, len(intervals))
-	state := bestState
-	for t := len(intervals) - 1; t >= 0; t-- {
-		// We stored choice/power from previous state to next; need to find a prev state that leads to 'state' with optimal value.
-		// For simplicity, we replay forward by tracking from initial state using recorded choices.
-		// We'll reconstruct forward instead.
-		_ = t
-		_ = state
-		break
-	}
-
-	// Forward reconstruction:
-	// Follow the recorded choices from the initial state
-	cur := initIdx
-	for t := 0; t < len(intervals); t++ {
-		// choice[t][cur] should always be valid since we record at least idle for reachable states
-		ns := choice[t][cur]
-		if ns < 0 {
-			// This shouldn't happen, but fallback to idle if it does
-			plan[t] = model.Dispatch{PowerMW: 0}
-			// Stay in current state
-			continue
+	st := model.BatteryState{SOC: initialSOC}
+	for t, it := range intervals {
+		st = withSteadyTemp(st, it, p)
+		d := model.Dispatch{PowerMW: actions[choice[t][stateIdx(st)]], Outage: it.Unavailable}
+		requested, _, _ := p.Constrain(st, d, it.DurationHours())
+		res, next := p.Simulate(st, it.LMP, d, it.DurationHours())
+		plan[t] = model.Dispatch{PowerMW: res.PowerMW}
+		if _, held, clipped := p.Constrain(st, plan[t], it.DurationHours()); held || clipped {
+			plan[t].PowerMW = requested
 		}
-		plan[t] = model.Dispatch{PowerMW: powerChosen[t][cur]}
-		cur = ns
+		st = next
 	}
-
 	return plan, nil
 }
 
-// simulateInterval is a pure version of the battery interval physics+PnL.
-// It mirrors model.Battery.ApplyDispatch semantics: desired power is clipped by power limit and SOC bounds.
-func simulateInterval(soc float64, desiredPower float64, lmp float64, dtH float64, p model.BatteryParams) (nextSOC float64, realizedPower float64, pnl float64) {
-	// Clip by power.
-	power := desiredPower
-	if power > p.PowerCapacityMW {
-		power = p.PowerCapacityMW
-	}
-	if power < -p.PowerCapacityMW {
-		power = -p.PowerCapacityMW
-	}
-
-	energyFromGrid := 0.0
-	energyToGrid := 0.0
-
-	if power < 0 {
-		reqFromGrid := math.Abs(power) * dtH
-		// SOC constraint (max charge).
-		storableMWh := (p.MaxSOC - soc) * p.EnergyCapacityMWh
-		if storableMWh < 0 {
-			storableMWh = 0
-		}
-		limitBySOC := storableMWh / p.ChargeEfficiency
-		limitByPower := p.PowerCapacityMW * dtH
-		maxFromGrid := math.Min(limitBySOC, limitByPower)
-		if reqFromGrid > maxFromGrid && dtH > 0 {
-			reqFromGrid = maxFromGrid
-			power = -reqFromGrid / dtH
-		}
-		storedMWh := reqFromGrid * p.ChargeEfficiency
-		nextSOC = soc + storedMWh/p.EnergyCapacityMWh
-		energyFromGrid = reqFromGrid
-	} else if power > 0 {
-		reqToGrid := power * dtH
-		withdrawableMWh := (soc - p.MinSOC) * p.EnergyCapacityMWh
-		if withdrawableMWh < 0 {
-			withdrawableMWh = 0
-		}
-		limitBySOC := withdrawableMWh * p.DischargeEfficiency
-		limitByPower := p.PowerCapacityMW * dtH
-		maxToGrid := math.Min(limitBySOC, limitByPower)
-		if reqToGrid > maxToGrid && dtH > 0 {
-			reqToGrid = maxToGrid
-			power = reqToGrid / dtH
-		}
-		withdrawnMWh := reqToGrid / p.DischargeEfficiency
-		nextSOC = soc - withdrawnMWh/p.EnergyCapacityMWh
-		energyToGrid = reqToGrid
-	} else {
-		nextSOC = soc
+// modeIdx numbers the mode of a power: 0 charging, 1 idle, 2 discharging.
+func modeIdx(powerMW float64) int {
+	switch {
+	case powerMW < 0:
+		return 0
+	case powerMW > 0:
+		return 2
 	}
+	return 1
+}
 
-	// Clamp numeric drift.
-	if nextSOC < p.MinSOC {
-		nextSOC = p.MinSOC
+// withSteadyTemp sets the interval's ambient and the idle steady-state cell
+// temperature. The optimizers treat temperature as exogenous (no self-heating
+// from their own dispatch); the engine simulates the full thermal model.
+func withSteadyTemp(st model.BatteryState, it model.LMPInterval, p model.BatteryParams) model.BatteryState {
+	if p.Thermal == nil {
+		return st
 	}
-	if nextSOC > p.MaxSOC {
-		nextSOC = p.MaxSOC
-	}
-
-	// PnL uses grid-side energies + degradation.
-	revenue := lmp * energyToGrid
-	cost := lmp * energyFromGrid
-	deg := p.DegradationCostPerMWh * (energyFromGrid + energyToGrid)
-	pnl = revenue - cost - deg
-	return nextSOC, power, pnl
+	st.AmbientTempC = p.Thermal.Ambient(it.AmbientTempC)
+	st.CellTempC = p.Thermal.SteadyTempC(st.AmbientTempC)
+	return st
 }
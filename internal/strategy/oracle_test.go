@@ -0,0 +1,59 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"battery-backtest/internal/model"
+)
+
+// TestOracleCarbonPriceUnderPriceCap checks that the carbon price is not
+// clamped by a settlement price cap: discharging into dirty hours avoids
+// emissions worth more than the cap.
+func TestOracleCarbonPriceUnderPriceCap(t *testing.T) {
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	clean, dirty := 0.0, 1.0
+	var intervals []model.LMPInterval
+	for i := 0; i < 24; i++ {
+		s := start.Add(time.Duration(i) * time.Hour)
+		it := model.LMPInterval{IntervalStartUTC: s, IntervalEndUTC: s.Add(time.Hour), IntervalStartLocal: s, IntervalEndLocal: s.Add(time.Hour)}
+		if i < 12 {
+			it.LMP, it.EmissionsRate = 50, &clean
+		} else {
+			it.LMP, it.EmissionsRate = 40, &dirty
+		}
+		intervals = append(intervals, it)
+	}
+	priceCap := 45.0
+	params := model.BatteryParams{EnergyCapacityMWh: 4, PowerCapacityMW: 1, ChargeEfficiency: 0.95, DischargeEfficiency: 0.95, MinSOC: 0.1, MaxSOC: 0.9}
+
+	tests := []struct {
+		name        string
+		carbonPrice float64
+		capped      bool
+		cycles      bool
+	}{
+		{"no carbon price", 0, false, false},
+		{"carbon price", 100, false, true},
+		{"carbon price above the cap", 100, true, true},
+	}
+	for _, tc := range tests {
+		p := params
+		if tc.capped {
+			p.Settlement = &model.SettlementParams{PriceCap: &priceCap}
+		}
+		s, err := NewOracleStrategy(intervals, p, p.MinSOC, OracleParams{CarbonPricePerTCO2: tc.carbonPrice})
+		if err != nil {
+			t.Fatal(err)
+		}
+		discharged := 0.0
+		for i := range intervals {
+			if mw := s.Decide(Context{Index: i}).PowerMW; mw > 0 {
+				discharged += mw
+			}
+		}
+		if cycles := discharged > 1; cycles != tc.cycles {
+			t.Errorf("%s: discharged %.2f MWh, want cycling = %v", tc.name, discharged, tc.cycles)
+		}
+	}
+}
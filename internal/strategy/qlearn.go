@@ -0,0 +1,293 @@
+package strategy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"battery-backtest/internal/model"
+)
+
+// QLearnConfig controls state discretization and training of a tabular Q-policy.
+//
+// State = (local hour, SOC bin, price bin). The price feature is the z-score of
+// the current LMP against a trailing window of *past* prices only, so the policy
+// never sees the future. Actions are fractions of the rated charge (negative)
+// or discharge (positive) power.
+type QLearnConfig struct {
+	SOCBins       int       `json:"soc_bins"`
+	PriceBins     int       `json:"price_bins"`
+	LookbackHours float64   `json:"lookback_hours"`
+	PowerLevels   []float64 `json:"power_levels"` // fractions of rated power, e.g. [-1, -0.5, 0, 0.5, 1]
+
+	Episodes int     `json:"episodes"`
+	Alpha    float64 `json:"alpha"`   // learning rate
+	Gamma    float64 `json:"gamma"`   // per-interval discount in [0, 1]; negative = default
+	Epsilon  float64 `json:"epsilon"` // initial exploration rate in [0, 1], decays linearly to 0; negative = default
+	Seed     int64   `json:"seed"`
+}
+
+// withDefaults fills unset fields. Gamma and Epsilon are unset only when
+// negative, since 0 is meaningful for both (myopic, purely greedy).
+func (c QLearnConfig) withDefaults() QLearnConfig {
+	if c.SOCBins <= 0 {
+		c.SOCBins = 10
+	}
+	if c.PriceBins <= 0 {
+		c.PriceBins = 5
+	}
+	if c.LookbackHours <= 0 {
+		c.LookbackHours = 24
+	}
+	if len(c.PowerLevels) == 0 {
+		c.PowerLevels = []float64{-1, -0.5, 0, 0.5, 1}
+	}
+	if c.Episodes <= 0 {
+		c.Episodes = 50
+	}
+	if c.Alpha <= 0 {
+		c.Alpha = 0.1
+	}
+	if c.Gamma < 0 {
+		c.Gamma = 0.99
+	}
+	if c.Epsilon < 0 {
+		c.Epsilon = 0.2
+	}
+	return c
+}
+
+// QPolicy is a trained policy as saved to disk.
+type QPolicy struct {
+	Config QLearnConfig `json:"config"`
+
+	// TrainStartUTC/TrainEndUTC bound the intervals used for training.
+	// Backtests must start at or after TrainEndUTC.
+	TrainStartUTC time.Time `json:"train_start_utc"`
+	TrainEndUTC   time.Time `json:"train_end_utc"`
+	Location      string    `json:"location"`
+
+	// Q is indexed [state][action].
+	Q [][]float64 `json:"q"`
+}
+
+func (p *QPolicy) numStates() int {
+	return 24 * p.Config.SOCBins * p.Config.PriceBins
+}
+
+func (p *QPolicy) bestAction(state int) int {
+	best := 0
+	for a, v := range p.Q[state] {
+		if v > p.Q[state][best] {
+			best = a
+		}
+	}
+	return best
+}
+
+// SaveQPolicy writes a policy as JSON.
+func SaveQPolicy(path string, p *QPolicy) error {
+	raw, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, raw, 0o644)
+}
+
+// LoadQPolicy reads a policy written by SaveQPolicy.
+func LoadQPolicy(path string) (*QPolicy, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p QPolicy
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, fmt.Errorf("parse policy: %w", err)
+	}
+	p.Config = p.Config.withDefaults()
+	if len(p.Q) != p.numStates() {
+		return nil, fmt.Errorf("policy has %d states, expected %d", len(p.Q), p.numStates())
+	}
+	for _, row := range p.Q {
+		if len(row) != len(p.Config.PowerLevels) {
+			return nil, errors.New("policy action count does not match power_levels")
+		}
+	}
+	return &p, nil
+}
+
+// TrainQPolicy learns a policy on intervals using model.Battery as the environment.
+// Each episode replays the whole training series from initialSOC with
+// epsilon-greedy exploration; rewards are interval PnL per MW of capacity.
+func TrainQPolicy(intervals []model.LMPInterval, params model.BatteryParams, initialSOC float64, cfg QLearnConfig) (*QPolicy, error) {
+	if len(intervals) < 2 {
+		return nil, errors.New("need at least 2 intervals to train")
+	}
+	cfg = cfg.withDefaults()
+	if cfg.Gamma > 1 || cfg.Epsilon > 1 {
+		return nil, errors.New("gamma and epsilon must be in [0, 1]")
+	}
+	policy := &QPolicy{
+		Config:        cfg,
+		TrainStartUTC: intervals[0].IntervalStartUTC,
+		TrainEndUTC:   intervals[len(intervals)-1].IntervalEndUTC,
+		Location:      intervals[0].Location,
+	}
+	policy.Q = make([][]float64, policy.numStates())
+	for i := range policy.Q {
+		policy.Q[i] = make([]float64, len(cfg.PowerLevels))
+	}
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	scale := math.Max(params.ChargeRatingMW(), params.DischargeRatingMW())
+	for ep := 0; ep < cfg.Episodes; ep++ {
+		eps := cfg.Epsilon * (1 - float64(ep)/float64(cfg.Episodes))
+		batt, err := model.NewBattery(params, initialSOC)
+		if err != nil {
+			return nil, err
+		}
+		feat := newPriceFeature(cfg)
+		state := feat.state(intervals[0], batt.State.SOC, params)
+		for t, it := range intervals {
+			a := policy.bestAction(state)
+			if rng.Float64() < eps {
+				a = rng.Intn(len(cfg.PowerLevels))
+			}
+			res, err := batt.ApplyDispatch(it.LMP, model.Dispatch{PowerMW: levelMW(cfg.PowerLevels[a], params)}, it.DurationHours())
+			if err != nil {
+				return nil, fmt.Errorf("episode %d interval %d: %w", ep, t, err)
+			}
+			feat.observe(it)
+			reward := res.PNL / scale
+
+			target := reward
+			next := state
+			if t+1 < len(intervals) {
+				next = feat.state(intervals[t+1], batt.State.SOC, params)
+				target += cfg.Gamma * policy.Q[next][policy.bestAction(next)]
+			}
+			policy.Q[state][a] += cfg.Alpha * (target - policy.Q[state][a])
+			state = next
+		}
+	}
+	return policy, nil
+}
+
+// QLearnStrategy dispatches greedily according to a trained QPolicy.
+type QLearnStrategy struct {
+	policy *QPolicy
+	feat   *priceFeature
+}
+
+// NewQLearnStrategy checks the train/test split and returns the strategy.
+// Every backtest interval must start at or after the end of the training window.
+func NewQLearnStrategy(policy *QPolicy, intervals []model.LMPInterval) (*QLearnStrategy, error) {
+	if policy == nil {
+		return nil, errors.New("policy is nil")
+	}
+	if len(intervals) > 0 && !policy.TrainEndUTC.IsZero() {
+		first := intervals[0].IntervalStartUTC
+		if first.Before(policy.TrainEndUTC) {
+			return nil, fmt.Errorf("backtest starts %s, before the policy's training window ends (%s): train/test overlap",
+				first.Format(time.RFC3339), policy.TrainEndUTC.Format(time.RFC3339))
+		}
+	}
+	return &QLearnStrategy{policy: policy, feat: newPriceFeature(policy.Config)}, nil
+}
+
+func (s *QLearnStrategy) Name() string { return "qlearn" }
+
+func (s *QLearnStrategy) Decide(ctx Context) model.Dispatch {
+	if ctx.Battery == nil {
+		return model.Dispatch{PowerMW: 0}
+	}
+	p := ctx.Battery.Params
+	state := s.feat.state(ctx.Interval, ctx.Battery.State.SOC, p)
+	// Observe after deciding so the feature only ever includes past prices.
+	s.feat.observe(ctx.Interval)
+	a := s.policy.bestAction(state)
+	return model.Dispatch{PowerMW: levelMW(s.policy.Config.PowerLevels[a], p)}
+}
+
+// levelMW converts a power level fraction to MW using the rating of its direction.
+func levelMW(level float64, p model.BatteryParams) float64 {
+	if level < 0 {
+		return level * p.ChargeRatingMW()
+	}
+	return level * p.DischargeRatingMW()
+}
+
+// priceFeature keeps a trailing window of observed prices.
+type priceFeature struct {
+	cfg    QLearnConfig
+	times  []time.Time
+	prices []float64
+}
+
+func newPriceFeature(cfg QLearnConfig) *priceFeature {
+	return &priceFeature{cfg: cfg}
+}
+
+func (f *priceFeature) observe(it model.LMPInterval) {
+	f.times = append(f.times, it.IntervalStartUTC)
+	f.prices = append(f.prices, it.LMP)
+	cutoff := it.IntervalStartUTC.Add(-time.Duration(f.cfg.LookbackHours * float64(time.Hour)))
+	drop := 0
+	for drop < len(f.times) && f.times[drop].Before(cutoff) {
+		drop++
+	}
+	if drop > 0 {
+		f.times = append(f.times[:0], f.times[drop:]...)
+		f.prices = append(f.prices[:0], f.prices[drop:]...)
+	}
+}
+
+// state encodes (hour, soc bin, price bin) for the interval about to be decided.
+func (f *priceFeature) state(it model.LMPInterval, soc float64, p model.BatteryParams) int {
+	hour := it.IntervalStartLocal.Hour()
+
+	socFrac := 0.0
+	if p.MaxSOC > p.MinSOC {
+		socFrac = (soc - p.MinSOC) / (p.MaxSOC - p.MinSOC)
+	}
+	socBin := int(socFrac * float64(f.cfg.SOCBins))
+	socBin = clampInt(socBin, 0, f.cfg.SOCBins-1)
+
+	priceBin := f.cfg.PriceBins / 2
+	if len(f.prices) >= 2 {
+		mean, std := meanStd(f.prices)
+		if std > 0 {
+			// z-score in [-2, 2] mapped onto PriceBins equal-width bins.
+			z := math.Max(-2, math.Min(2, (it.LMP-mean)/std))
+			priceBin = clampInt(int((z+2)/4*float64(f.cfg.PriceBins)), 0, f.cfg.PriceBins-1)
+		}
+	}
+	return (hour*f.cfg.SOCBins+socBin)*f.cfg.PriceBins + priceBin
+}
+
+func meanStd(xs []float64) (float64, float64) {
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	mean := sum / float64(len(xs))
+	ss := 0.0
+	for _, x := range xs {
+		ss += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(ss / float64(len(xs)))
+}
+
+func clampInt(x, lo, hi int) int {
+	if x < lo {
+		return lo
+	}
+	if x > hi {
+		return hi
+	}
+	return x
+}
@@ -0,0 +1,21 @@
+package strategy
+
+import "testing"
+
+func TestQLearnConfigDefaults(t *testing.T) {
+	tests := []struct {
+		name           string
+		in             QLearnConfig
+		gamma, epsilon float64
+	}{
+		{"unset", QLearnConfig{Gamma: -1, Epsilon: -1}, 0.99, 0.2},
+		{"myopic and greedy", QLearnConfig{Gamma: 0, Epsilon: 0}, 0, 0},
+		{"explicit", QLearnConfig{Gamma: 0.5, Epsilon: 0.1}, 0.5, 0.1},
+	}
+	for _, tc := range tests {
+		got := tc.in.withDefaults()
+		if got.Gamma != tc.gamma || got.Epsilon != tc.epsilon {
+			t.Errorf("%s: gamma %v, epsilon %v; want %v, %v", tc.name, got.Gamma, got.Epsilon, tc.gamma, tc.epsilon)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"battery-backtest/internal/analysis"
+	"battery-backtest/internal/model"
+)
+
+// ScenarioSource supplies price scenarios for one day. Each scenario is a price
+// path with one price per interval of day. Implementations must only use
+// information available before the day starts (history), never the day itself.
+type ScenarioSource interface {
+	Scenarios(day []model.LMPInterval, history [][]model.LMPInterval) [][]float64
+}
+
+// AnalogDays uses the most recent K past days of the same day type
+// (weekday/weekend) and the same interval count as scenarios.
+type AnalogDays struct {
+	K           int
+	SameDayType bool
+}
+
+func (a AnalogDays) Scenarios(day []model.LMPInterval, history [][]model.LMPInterval) [][]float64 {
+	k := a.K
+	if k <= 0 {
+		k = 10
+	}
+	weekend := isWeekend(day[0].IntervalStartLocal)
+	var out [][]float64
+	for i := len(history) - 1; i >= 0 && len(out) < k; i-- {
+		h := history[i]
+		if len(h) != len(day) {
+			continue
+		}
+		if a.SameDayType && isWeekend(h[0].IntervalStartLocal) != weekend {
+			continue
+		}
+		prices := make([]float64, len(h))
+		for j, it := range h {
+			prices[j] = it.LMP
+		}
+		out = append(out, prices)
+	}
+	return out
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// ScenarioParams configures the scenario-based optimizer.
+type ScenarioParams struct {
+	SocSteps   int
+	PowerSteps int
+
+	// Objective is "expected" (maximize mean profit) or "cvar" (maximize the
+	// mean of the worst CVaRAlpha fraction of scenarios).
+	Objective string
+	CVaRAlpha float64
+
+	// CVaRIterations bounds the scenario-reweighting loop used for "cvar".
+	CVaRIterations int
+
+	// Source defaults to AnalogDays{K: 10, SameDayType: true}.
+	Source ScenarioSource
+}
+
+// ScenarioDayReport describes one day's non-anticipative schedule.
+type ScenarioDayReport struct {
+	Day       string
+	Scenarios int
+
+	// Outcomes is the distribution of the schedule's profit across scenarios.
+	Outcomes analysis.Distribution
+
+	// Realized is the schedule's profit on the actual prices of the day.
+	Realized float64
+}
+
+// ScenarioStrategy produces one dispatch schedule per day that is optimal for
+// a set of price scenarios rather than the realized prices. Scenarios for a day
+// come only from earlier days, so the schedule is non-anticipative.
+//
+// For a fixed schedule, interval profit is linear in the settlement price but
+// not in the LMP once a settlement floor or cap applies. Scenarios are therefore
+// mapped to settlement prices before they are averaged, and the "expected"
+// objective is solved by the oracle DP on the probability-weighted mean
+// settlement price path. That is exact up to discretization for the per-interval
+// profit the DP models; monthly charges and bid cost recovery are not part of
+// it. For "cvar", the DP is run repeatedly on reweighted mean paths (fictitious
+// play against the worst-case tail) and the schedule with the best CVaR is kept.
+//
+// Intervals marked Unavailable are idle in every scenario, as in the engine.
+//
+// Days without any usable scenario are left idle.
+type ScenarioStrategy struct {
+	plan []model.Dispatch
+	days []ScenarioDayReport
+}
+
+func NewScenarioStrategy(intervals []model.LMPInterval, params model.BatteryParams, initialSOC float64, cfg ScenarioParams) (*ScenarioStrategy, error) {
+	if len(intervals) == 0 {
+		return nil, fmt.Errorf("no intervals")
+	}
+	if cfg.SocSteps <= 0 {
+		cfg.SocSteps = 200
+	}
+	if cfg.PowerSteps <= 0 {
+		cfg.PowerSteps = 10
+	}
+	if cfg.Objective == "" {
+		cfg.Objective = "expected"
+	}
+	if cfg.Objective != "expected" && cfg.Objective != "cvar" {
+		return nil, fmt.Errorf("objective must be \"expected\" or \"cvar\" (got %q)", cfg.Objective)
+	}
+	if cfg.CVaRAlpha <= 0 || cfg.CVaRAlpha > 1 {
+		cfg.CVaRAlpha = 0.1
+	}
+	if cfg.CVaRIterations <= 0 {
+		cfg.CVaRIterations = 20
+	}
+	if cfg.Source == nil {
+		cfg.Source = AnalogDays{K: 10, SameDayType: true}
+	}
+
+	s := &ScenarioStrategy{}
+	days := splitByDay(intervals)
+	for d, day := range days {
+		scenarios := cfg.Source.Scenarios(day, days[:d])
+		if len(scenarios) == 0 {
+			for range day {
+				s.plan = append(s.plan, model.Dispatch{PowerMW: 0})
+			}
+			continue
+		}
+
+		plan, outcomes, err := optimizeScenarios(day, scenarios, params, initialSOC, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("error optimizing day %s: %w", day[0].IntervalStartLocal.Format("2006-01-02"), err)
+		}
+		s.plan = append(s.plan, plan...)
+
+		actual := make([]float64, len(day))
+		for i, it := range day {
+			actual[i] = it.LMP
+		}
+		s.days = append(s.days, ScenarioDayReport{
+			Day:       day[0].IntervalStartLocal.Format("2006-01-02"),
+			Scenarios: len(scenarios),
+			Outcomes:  analysis.Summarize(outcomes),
+			Realized:  evaluatePlan(day, plan, actual, params, initialSOC),
+		})
+	}
+	if len(s.plan) != len(intervals) {
+		return nil, fmt.Errorf("plan length (%d) does not match intervals length (%d)", len(s.plan), len(intervals))
+	}
+	return s, nil
+}
+
+func (s *ScenarioStrategy) Name() string { return "stochastic" }
+
+func (s *ScenarioStrategy) Decide(ctx Context) model.Dispatch {
+	if ctx.Index < 0 || ctx.Index >= len(s.plan) {
+		return model.Dispatch{PowerMW: 0}
+	}
+	return s.plan[ctx.Index]
+}
+
+// Report returns one entry per planned day.
+func (s *ScenarioStrategy) Report() []ScenarioDayReport {
+	return s.days
+}
+
+// optimizeScenarios returns the day plan and its profit under each scenario.
+func optimizeScenarios(day []model.LMPInterval, scenarios [][]float64, p model.BatteryParams, initialSOC float64, cfg ScenarioParams) ([]model.Dispatch, []float64, error) {
+	k := len(scenarios)
+	weights := make([]float64, k)
+	for i := range weights {
+		weights[i] = 1 / float64(k)
+	}
+
+	// The mean of settled prices lies within the floor/cap, so the DP's own
+	// settlement leaves it unchanged.
+	settled := make([][]float64, k)
+	for i, prices := range scenarios {
+		settled[i] = make([]float64, len(prices))
+		for t, v := range prices {
+			settled[i][t] = p.Settlement.Price(v)
+		}
+	}
+
+	solve := func(w []float64) ([]model.Dispatch, []float64, error) {
+		plan, err := optimizeDP(withPrices(day, weightedMean(settled, w)), p, initialSOC, cfg.SocSteps, cfg.PowerSteps, 0, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		outcomes := make([]float64, k)
+		for i, prices := range scenarios {
+			outcomes[i] = evaluatePlan(day, plan, prices, p, initialSOC)
+		}
+		return plan, outcomes, nil
+	}
+
+	plan, outcomes, err := solve(weights)
+	if err != nil || cfg.Objective == "expected" {
+		return plan, outcomes, err
+	}
+
+	bestPlan, bestOutcomes := plan, outcomes
+	bestCVaR := cvarOf(outcomes, cfg.CVaRAlpha)
+	avg := append([]float64(nil), weights...)
+	for iter := 1; iter <= cfg.CVaRIterations; iter++ {
+		// Worst-case tail weights for the current plan, averaged into the running mix.
+		tail := tailWeights(outcomes, cfg.CVaRAlpha)
+		for i := range avg {
+			avg[i] = (avg[i]*float64(iter) + tail[i]) / float64(iter+1)
+		}
+		plan, outcomes, err = solve(avg)
+		if err != nil {
+			return nil, nil, err
+		}
+		if c := cvarOf(outcomes, cfg.CVaRAlpha); c > bestCVaR {
+			bestPlan, bestOutcomes, bestCVaR = plan, outcomes, c
+		}
+	}
+	return bestPlan, bestOutcomes, nil
+}
+
+// evaluatePlan replays a plan against a price path using the same physics as
+// the DP, including forced idling in Unavailable intervals.
+func evaluatePlan(day []model.LMPInterval, plan []model.Dispatch, prices []float64, p model.BatteryParams, initialSOC float64) float64 {
+	st := model.BatteryState{SOC: initialSOC}
+	total := 0.0
+	for t, it := range day {
+		var res model.IntervalResult
+		res, st = p.Simulate(withSteadyTemp(st, it, p), prices[t], model.Dispatch{PowerMW: plan[t].PowerMW, Outage: it.Unavailable}, it.DurationHours())
+		total += res.PNL
+	}
+	return total
+}
+
+// withPrices copies day with LMP replaced by prices.
+func withPrices(day []model.LMPInterval, prices []float64) []model.LMPInterval {
+	out := make([]model.LMPInterval, len(day))
+	copy(out, day)
+	for i := range out {
+		out[i].LMP = prices[i]
+	}
+	return out
+}
+
+func weightedMean(scenarios [][]float64, w []float64) []float64 {
+	out := make([]float64, len(scenarios[0]))
+	for k, prices := range scenarios {
+		for t, v := range prices {
+			out[t] += w[k] * v
+		}
+	}
+	return out
+}
+
+func cvarOf(outcomes []float64, alpha float64) float64 {
+	sorted := append([]float64(nil), outcomes...)
+	sort.Float64s(sorted)
+	return analysis.LowerTailMean(sorted, alpha)
+}
+
+// tailWeights puts probability mass uniformly on the worst alpha fraction of
+// outcomes (the CVaR risk envelope's extreme point for these outcomes).
+func tailWeights(outcomes []float64, alpha float64) []float64 {
+	idx := make([]int, len(outcomes))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool { return outcomes[idx[a]] < outcomes[idx[b]] })
+
+	w := make([]float64, len(outcomes))
+	mass := alpha * float64(len(outcomes))
+	used := 0.0
+	for _, i := range idx {
+		take := mass - used
+		if take <= 0 {
+			break
+		}
+		if take > 1 {
+			take = 1
+		}
+		w[i] = take / mass
+		used += take
+	}
+	return w
+}
@@ -3,7 +3,7 @@ package strategy
 import "battery-backtest/internal/model"
 
 type Context struct {
-	Index int
+	Index    int
 	Interval model.LMPInterval
 	Battery  *model.Battery
 }
@@ -13,3 +13,20 @@ type Strategy interface {
 	Decide(ctx Context) model.Dispatch
 }
 
+// Bidder is a strategy that participates by submitting a bid/offer curve
+// instead of a MW setpoint. The engine clears the curve against the realized
+// LMP to determine the requested dispatch.
+//
+// Decide must still be implemented; it is used when a bidder is nested inside
+// a combinator and should normally return the curve cleared at ctx.Interval.LMP.
+type Bidder interface {
+	Strategy
+	Bid(ctx Context) model.BidCurve
+}
+
+// Failer is a strategy that can fail mid-run, e.g. a WebAssembly module that
+// traps or exceeds its time budget. Decide cannot return an error, so the
+// engine checks Err after every decision and stops the backtest with it.
+type Failer interface {
+	Err() error
+}
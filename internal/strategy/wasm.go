@@ -0,0 +1,270 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"battery-backtest/internal/model"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// WasmStrategy runs a strategy compiled to WebAssembly.
+//
+// The module is executed by wazero (pure Go, no cgo) and must export:
+//
+//	decide(index i32, start_unix i64, utc_offset_s i32, duration_h f64,
+//	       lmp f64, energy f64, congestion f64, loss f64, soc f64) -> f64
+//
+// returning the requested power in MW (positive = discharge, negative = charge).
+// Optional exports:
+//
+//	init(energy_capacity_mwh f64, power_capacity_mw f64, min_soc f64, max_soc f64)
+//	name() -> i64   // (ptr << 32) | len of a UTF-8 string in exported "memory"
+//
+// WASI reactors (e.g. Go's -buildmode=c-shared or Rust cdylib) are supported:
+// WASI is provided without filesystem, env or clock access beyond the defaults,
+// and "_initialize" is called if present. "_start" is never run.
+type WasmStrategy struct {
+	name    string
+	limits  WasmLimits
+	runtime wazero.Runtime
+	module  api.Module
+	decide  api.Function
+	init    api.Function
+
+	initialized bool
+	err         error // first init/decide failure; see Err
+}
+
+// WasmLimits bounds what a module may consume.
+type WasmLimits struct {
+	// MaxMemoryPages caps linear memory (64 KiB pages). Default 512 (32 MiB).
+	MaxMemoryPages uint32
+
+	// CallTimeout is the execution budget for each exported call. wazero has no
+	// instruction metering, so the budget is wall-clock: a call that exceeds it is
+	// aborted and the module is closed. Default 100ms.
+	CallTimeout time.Duration
+}
+
+func (l WasmLimits) withDefaults() WasmLimits {
+	if l.MaxMemoryPages == 0 {
+		l.MaxMemoryPages = 512
+	}
+	if l.CallTimeout <= 0 {
+		l.CallTimeout = 100 * time.Millisecond
+	}
+	return l
+}
+
+// NewWasmStrategyFromFile loads a module from disk. See NewWasmStrategy.
+func NewWasmStrategyFromFile(path string, limits WasmLimits) (*WasmStrategy, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read wasm module: %w", err)
+	}
+	return NewWasmStrategy(raw, limits)
+}
+
+// NewWasmStrategy compiles and instantiates a module and checks its exports.
+// The caller should Close the strategy when the backtest is done.
+func NewWasmStrategy(wasm []byte, limits WasmLimits) (*WasmStrategy, error) {
+	limits = limits.withDefaults()
+	ctx := context.Background()
+
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(limits.MaxMemoryPages).
+		WithCloseOnContextDone(true))
+
+	s, err := instantiateWasm(ctx, r, wasm, limits)
+	if err != nil {
+		_ = r.Close(ctx)
+		return nil, err
+	}
+	return s, nil
+}
+
+func instantiateWasm(ctx context.Context, r wazero.Runtime, wasm []byte, limits WasmLimits) (*WasmStrategy, error) {
+	compiled, err := r.CompileModule(ctx, wasm)
+	if err != nil {
+		return nil, fmt.Errorf("compile wasm module: %w", err)
+	}
+	if err := validateWasmExports(compiled); err != nil {
+		return nil, err
+	}
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r); err != nil {
+		return nil, fmt.Errorf("instantiate wasi: %w", err)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, limits.CallTimeout)
+	defer cancel()
+	mod, err := r.InstantiateModule(callCtx, compiled, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, fmt.Errorf("instantiate wasm module: %w", err)
+	}
+
+	s := &WasmStrategy{
+		name:    "wasm",
+		limits:  limits,
+		runtime: r,
+		module:  mod,
+		decide:  mod.ExportedFunction("decide"),
+		init:    mod.ExportedFunction("init"),
+	}
+	if fn := mod.ExportedFunction("name"); fn != nil {
+		if name, err := s.readName(fn); err == nil && name != "" {
+			s.name = name
+		}
+	}
+	return s, nil
+}
+
+// ValidateWasmModule compiles a module without running it and checks its exports
+// against the strategy ABI. Used to reject bad uploads early.
+func ValidateWasmModule(wasm []byte) error {
+	ctx := context.Background()
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+
+	compiled, err := r.CompileModule(ctx, wasm)
+	if err != nil {
+		return fmt.Errorf("compile wasm module: %w", err)
+	}
+	return validateWasmExports(compiled)
+}
+
+func validateWasmExports(compiled wazero.CompiledModule) error {
+	exports := compiled.ExportedFunctions()
+	decide, ok := exports["decide"]
+	if !ok {
+		return errors.New("wasm module must export \"decide\"")
+	}
+	wantParams := []api.ValueType{
+		api.ValueTypeI32, api.ValueTypeI64, api.ValueTypeI32, api.ValueTypeF64,
+		api.ValueTypeF64, api.ValueTypeF64, api.ValueTypeF64, api.ValueTypeF64, api.ValueTypeF64,
+	}
+	if !sameTypes(decide.ParamTypes(), wantParams) || !sameTypes(decide.ResultTypes(), []api.ValueType{api.ValueTypeF64}) {
+		return errors.New("wasm export \"decide\" must have signature (i32, i64, i32, f64, f64, f64, f64, f64, f64) -> f64")
+	}
+	if fn, ok := exports["init"]; ok {
+		want := []api.ValueType{api.ValueTypeF64, api.ValueTypeF64, api.ValueTypeF64, api.ValueTypeF64}
+		if !sameTypes(fn.ParamTypes(), want) || len(fn.ResultTypes()) != 0 {
+			return errors.New("wasm export \"init\" must have signature (f64, f64, f64, f64)")
+		}
+	}
+	if fn, ok := exports["name"]; ok {
+		if len(fn.ParamTypes()) != 0 || !sameTypes(fn.ResultTypes(), []api.ValueType{api.ValueTypeI64}) {
+			return errors.New("wasm export \"name\" must have signature () -> i64")
+		}
+	}
+	return nil
+}
+
+func sameTypes(a, b []api.ValueType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *WasmStrategy) Name() string { return s.name }
+
+// Decide calls the module's decide export. If a call fails (trap, timeout or a
+// closed module) Decide records the error, returns idle from then on and Err
+// reports it, so the engine can stop the backtest.
+func (s *WasmStrategy) Decide(ctx Context) model.Dispatch {
+	if s.err != nil {
+		return model.Dispatch{PowerMW: 0}
+	}
+	if !s.initialized {
+		if s.init != nil && ctx.Battery != nil {
+			p := ctx.Battery.Params
+			if _, err := s.call(s.init,
+				api.EncodeF64(p.EnergyCapacityMWh),
+				api.EncodeF64(p.PowerCapacityMW),
+				api.EncodeF64(p.MinSOC),
+				api.EncodeF64(p.MaxSOC),
+			); err != nil {
+				s.err = fmt.Errorf("wasm init: %w", err)
+				return model.Dispatch{PowerMW: 0}
+			}
+		}
+		s.initialized = true
+	}
+
+	it := ctx.Interval
+	start := it.IntervalStartUTC
+	if start.IsZero() {
+		start = it.IntervalStartLocal
+	}
+	_, offset := it.IntervalStartLocal.Zone()
+	soc := 0.0
+	if ctx.Battery != nil {
+		soc = ctx.Battery.State.SOC
+	}
+
+	out, err := s.call(s.decide,
+		api.EncodeI32(int32(ctx.Index)),
+		api.EncodeI64(start.Unix()),
+		api.EncodeI32(int32(offset)),
+		api.EncodeF64(it.DurationHours()),
+		api.EncodeF64(it.LMP),
+		api.EncodeF64(it.Energy),
+		api.EncodeF64(it.Congestion),
+		api.EncodeF64(it.Loss),
+		api.EncodeF64(soc),
+	)
+	if err != nil {
+		s.err = fmt.Errorf("wasm decide (index %d): %w", ctx.Index, err)
+		return model.Dispatch{PowerMW: 0}
+	}
+	return model.Dispatch{PowerMW: api.DecodeF64(out[0])}
+}
+
+// Err returns the first failed module call, if any.
+func (s *WasmStrategy) Err() error { return s.err }
+
+// Close releases the runtime and all compiled code.
+func (s *WasmStrategy) Close() error {
+	if s == nil || s.runtime == nil {
+		return nil
+	}
+	return s.runtime.Close(context.Background())
+}
+
+func (s *WasmStrategy) call(fn api.Function, params ...uint64) ([]uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.limits.CallTimeout)
+	defer cancel()
+	return fn.Call(ctx, params...)
+}
+
+func (s *WasmStrategy) readName(fn api.Function) (string, error) {
+	out, err := s.call(fn)
+	if err != nil {
+		return "", err
+	}
+	ptr := uint32(out[0] >> 32)
+	n := uint32(out[0])
+	mem := s.module.Memory()
+	if mem == nil {
+		return "", errors.New("module has no exported memory")
+	}
+	raw, ok := mem.Read(ptr, n)
+	if !ok {
+		return "", fmt.Errorf("name out of range (ptr=%d len=%d)", ptr, n)
+	}
+	return string(raw), nil
+}